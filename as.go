@@ -0,0 +1,56 @@
+package di
+
+import "reflect"
+
+// AddAs registers dep under the interface type I as well as under its own type. A parameter of type I then gets dep
+// directly, without searching for implementations of I, so it is never ambiguous even when other dependencies implement
+// I too. This makes the intent explicit when a value satisfies many interfaces:
+//
+//	di.AddAs[io.Reader](ctx, conn)
+//
+// Unless the InterfaceOnly option is given, dep can still be resolved by its own type. The Name option registers dep
+// under a name, as with AddNamed. A nil dep is a no-op. Registering on a frozen Context is an error wrapping ErrFrozen.
+func AddAs[I any](ctx *Context, dep I, opts ...RegisterOption) error {
+	v := reflect.ValueOf(&dep).Elem()
+	if v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+
+	e := &entry{val: v}
+	for _, opt := range opts {
+		opt(e)
+	}
+	if !ctx.enabled(e) {
+		return nil
+	}
+
+	ctx.lock.Lock()
+	defer ctx.commit()
+
+	keys := []key{{typeOf[I](), e.name}}
+	if !e.interfaceOnly && v.Type() != typeOf[I]() {
+		keys = append(keys, key{v.Type(), e.name})
+	}
+	// check both first, so neither is registered if the other can't be
+	for _, k := range keys {
+		if err := admit(ctx, k, e); err != nil {
+			return err
+		}
+	}
+	for _, k := range keys {
+		register(ctx, k, e)
+	}
+	return nil
+}
+
+// InterfaceOnly keeps a dependency registered with AddAs from being registered under its own type, so it can only be
+// resolved as the interface. This enforces that consumers depend on the abstraction, keeping implementation types out of
+// the wiring. It has no effect on other registrations.
+func InterfaceOnly() RegisterOption {
+	return func(e *entry) {
+		e.interfaceOnly = true
+	}
+}
@@ -0,0 +1,165 @@
+package di_test
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"testing"
+
+	"github.com/mcvoid/di"
+)
+
+func TestAddAs(t *testing.T) {
+	t.Run("resolves the interface without ambiguity", func(t *testing.T) {
+		b := &bytes.Buffer{}
+		ctx := di.New().Add(os.Stdout)
+		err := di.AddAs[io.Writer](ctx, b)
+		if err != nil {
+			t.Errorf("expected %v got %v", nil, err)
+		}
+
+		w, err := di.Resolve[io.Writer](ctx)
+		if w != b {
+			t.Errorf("expected %p got %v", b, w)
+		}
+		if err != nil {
+			t.Errorf("expected %v got %v", nil, err)
+		}
+	})
+
+	t.Run("resolves the concrete type", func(t *testing.T) {
+		b := &bytes.Buffer{}
+		ctx := di.New()
+		di.AddAs[io.Writer](ctx, b)
+
+		actual, _ := di.Resolve[*bytes.Buffer](ctx)
+		if actual != b {
+			t.Errorf("expected %p got %p", b, actual)
+		}
+	})
+
+	t.Run("other interfaces match once", func(t *testing.T) {
+		b := &bytes.Buffer{}
+		ctx := di.New()
+		di.AddAs[io.Writer](ctx, b)
+
+		s, err := di.Resolve[fmt.Stringer](ctx)
+		if s != b {
+			t.Errorf("expected %p got %v", b, s)
+		}
+		if err != nil {
+			t.Errorf("expected %v got %v", nil, err)
+		}
+
+		group, _ := di.Resolve[[]io.Writer](ctx)
+		if len(group) != 1 {
+			t.Errorf("expected %v got %v", 1, len(group))
+		}
+	})
+
+	t.Run("named", func(t *testing.T) {
+		b := &bytes.Buffer{}
+		ctx := di.New()
+		di.AddAs[io.Writer](ctx, b, di.Name("replica"))
+
+		w, _ := di.Resolve[io.Writer](ctx)
+		if w != nil {
+			t.Errorf("expected %v got %v", nil, w)
+		}
+		named, _ := di.Resolve[di.Named[io.Writer, replica]](ctx)
+		if named.Value != b {
+			t.Errorf("expected %p got %v", b, named.Value)
+		}
+		m, _ := di.Resolve[map[string]io.Writer](ctx)
+		if len(m) != 1 {
+			t.Errorf("expected %v got %v", 1, len(m))
+		}
+	})
+
+	t.Run("nil", func(t *testing.T) {
+		ctx := di.New()
+		err := di.AddAs[io.Writer](ctx, nil)
+		if err != nil {
+			t.Errorf("expected %v got %v", nil, err)
+		}
+		if di.Has[io.Writer](ctx) {
+			t.Errorf("expected %v got %v", false, true)
+		}
+	})
+
+	t.Run("frozen", func(t *testing.T) {
+		ctx := di.New().Freeze()
+
+		err := di.AddAs[io.Writer](ctx, os.Stdout)
+		if !errors.Is(err, di.ErrFrozen) {
+			t.Errorf("expected %v got %v", di.ErrFrozen, err)
+		}
+	})
+
+	t.Run("neither type is registered if one can't be", func(t *testing.T) {
+		ctx := di.New(di.WithNoOverwrite()).Add(os.Stdin)
+
+		err := di.AddAs[io.Writer](ctx, os.Stdout)
+		if !errors.Is(err, di.ErrDuplicate) {
+			t.Errorf("expected %v got %v", di.ErrDuplicate, err)
+		}
+		if regs := ctx.Registrations(); len(regs) != 1 {
+			t.Errorf("expected only %v to be registered got %v", "*os.File", regs)
+		}
+	})
+
+	t.Run("removing removes both types", func(t *testing.T) {
+		ctx := di.New()
+		di.AddAs[io.Writer](ctx, os.Stdout)
+		ctx.Remove(os.Stdout)
+		if di.Has[io.Writer](ctx) {
+			t.Errorf("expected %v to be removed", "io.Writer")
+		}
+
+		di.AddAs[io.Writer](ctx, os.Stdout)
+		if !di.Remove[io.Writer](ctx) {
+			t.Errorf("expected %v to be removed", "io.Writer")
+		}
+		if di.Has[*os.File](ctx) {
+			t.Errorf("expected %v to be removed", "*os.File")
+		}
+	})
+}
+
+func TestInterfaceOnly(t *testing.T) {
+	t.Run("concrete type is not registered", func(t *testing.T) {
+		b := &bytes.Buffer{}
+		ctx := di.New()
+		di.AddAs[io.Writer](ctx, b, di.InterfaceOnly())
+
+		if di.Has[*bytes.Buffer](ctx) {
+			t.Errorf("expected %v got %v", false, true)
+		}
+		actual, _ := di.Resolve[*bytes.Buffer](ctx)
+		if actual != nil {
+			t.Errorf("expected %v got %p", nil, actual)
+		}
+	})
+
+	t.Run("interface is registered", func(t *testing.T) {
+		b := &bytes.Buffer{}
+		ctx := di.New()
+		di.AddAs[io.Writer](ctx, b, di.InterfaceOnly())
+
+		w, _ := di.Resolve[io.Writer](ctx)
+		if w != b {
+			t.Errorf("expected %p got %v", b, w)
+		}
+	})
+
+	t.Run("other interfaces of the concrete type are hidden", func(t *testing.T) {
+		ctx := di.New()
+		di.AddAs[io.Writer](ctx, &bytes.Buffer{}, di.InterfaceOnly())
+
+		if di.Has[fmt.Stringer](ctx) {
+			t.Errorf("expected %v got %v", false, true)
+		}
+	})
+}
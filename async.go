@@ -0,0 +1,78 @@
+package di
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// Handle tracks a target being run by InjectAsync.
+type Handle struct {
+	done chan struct{}
+	err  error
+}
+
+// Done returns a channel which is closed once the target has returned, or straight away if it couldn't be injected.
+func (h *Handle) Done() <-chan struct{} {
+	return h.done
+}
+
+// Err returns nil if Done is not yet closed. Once it is, Err returns the error Inject would have returned: the reason the
+// target couldn't be injected, or the error it returned, if any.
+func (h *Handle) Err() error {
+	select {
+	case <-h.done:
+		return h.err
+	default:
+		return nil
+	}
+}
+
+// InjectAsync is like Inject, but runs the target in a new goroutine, which makes it convenient for starting long-running
+// servers and workers. The target's dependencies are resolved before InjectAsync returns, so a target which can't be
+// injected is reported straight away, and the target is never started:
+//
+//	h := ctx.InjectAsync(server)
+//	select {
+//	case <-h.Done():
+//		log.Fatal(h.Err())
+//	case <-shutdown:
+//	}
+//
+// A panic in the target crashes the program, as in any goroutine, unless the Context was created with WithRecover.
+func (ctx *Context) InjectAsync(target interface{}) *Handle {
+	return injectAsync(ctx, newResolution(), target)
+}
+
+// injectAsync resolves the target's dependencies as part of r, then runs it
+// in a new goroutine.
+func injectAsync(ctx *Context, r *resolution, target interface{}) *Handle {
+	h := &Handle{done: make(chan struct{})}
+
+	fn, err := injectable(ctx, target)
+	if err != nil {
+		h.finish(err)
+		return h
+	}
+	in, err := planFor(ctx, fn.Type()).args(ctx, r)
+	r.release()
+	if err != nil {
+		h.finish(fmt.Errorf("injecting %s: %w", describe(target, ctx.bindMethodName()), err))
+		return h
+	}
+
+	go func() {
+		_, err := callResolved(ctx, target, fn, ctx.bindMethodName(), in)
+		putArgs(in)
+		if err == nil && reflect.ValueOf(target).Kind() != reflect.Func {
+			err = afterInject(target)
+		}
+		h.finish(err)
+	}()
+	return h
+}
+
+// finish records the target's error and closes Done.
+func (h *Handle) finish(err error) {
+	h.err = err
+	close(h.done)
+}
@@ -0,0 +1,86 @@
+package di_test
+
+import (
+	"errors"
+	"io"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/mcvoid/di"
+)
+
+func TestInjectAsync(t *testing.T) {
+	t.Run("target runs in the background", func(t *testing.T) {
+		ctx := di.New().Add(os.Stdout)
+		release := make(chan struct{})
+		var got *os.File
+		h := ctx.InjectAsync(func(f *os.File) {
+			<-release
+			got = f
+		})
+
+		select {
+		case <-h.Done():
+			t.Errorf("expected target to still be running")
+		default:
+		}
+		if err := h.Err(); err != nil {
+			t.Errorf("expected %v got %v", nil, err)
+		}
+
+		close(release)
+		<-h.Done()
+		if got != os.Stdout {
+			t.Errorf("expected %v got %v", os.Stdout, got)
+		}
+		if err := h.Err(); err != nil {
+			t.Errorf("expected %v got %v", nil, err)
+		}
+	})
+
+	t.Run("target's error is returned", func(t *testing.T) {
+		errBoom := errors.New("boom")
+		h := di.New().InjectAsync(func() error { return errBoom })
+		<-h.Done()
+		if err := h.Err(); !errors.Is(err, errBoom) {
+			t.Errorf("expected %v got %v", errBoom, err)
+		}
+	})
+
+	t.Run("injection errors are reported straight away", func(t *testing.T) {
+		ctx := di.New().Add(&strings.Builder{}, os.Stdout)
+		h := ctx.InjectAsync(func(io.Writer) {
+			t.Errorf("expected func to not be called")
+		})
+
+		select {
+		case <-h.Done():
+		default:
+			t.Fatalf("expected handle to be done")
+		}
+		if err := h.Err(); !errors.Is(err, di.ErrAmbiguous) {
+			t.Errorf("expected %v got %v", di.ErrAmbiguous, err)
+		}
+	})
+
+	t.Run("target must be injectable", func(t *testing.T) {
+		h := di.New().InjectAsync(42)
+		<-h.Done()
+		if err := h.Err(); !errors.Is(err, di.ErrNotInjectable) {
+			t.Errorf("expected %v got %v", di.ErrNotInjectable, err)
+		}
+	})
+
+	t.Run("AfterInject is called on bound objects", func(t *testing.T) {
+		i := initializer{}
+		h := di.New().Add(os.Stdin).InjectAsync(&i)
+		<-h.Done()
+		if err := h.Err(); err != nil {
+			t.Errorf("expected %v got %v", nil, err)
+		}
+		if !i.inited {
+			t.Errorf("expected AfterInject to be called")
+		}
+	})
+}
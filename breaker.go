@@ -0,0 +1,87 @@
+package di
+
+import (
+	"reflect"
+	"sync"
+	"time"
+)
+
+// Breaker gives a provider a circuit breaker, so that a provider which keeps failing, such as a transient one connecting
+// to a backend which is down, isn't called on every injection. Once the provider has failed the given number of times
+// in a row, injections needing it fail straight away with a BreakerError for the cool-down period, without calling it.
+// After that, the provider is called once more: if it succeeds, it is called as usual again, and if it fails, it cools
+// down again.
+//
+//	ctx.Provide(newClient, di.Transient(), di.Breaker(5, 30*time.Second))
+//
+// A call given up on because the context.Context of InjectContext or Start is done doesn't count as a failure. With
+// Retry, a failure counts once all the attempts have been made.
+func Breaker(failures int, cooldown time.Duration) RegisterOption {
+	return func(e *entry) {
+		if e.prov != nil {
+			e.prov.breaker = &breaker{threshold: failures, cooldown: cooldown}
+		}
+	}
+}
+
+// breaker tracks how often a provider has failed in a row.
+type breaker struct {
+	threshold int
+	cooldown  time.Duration
+
+	lock     sync.Mutex
+	failures int
+	last     error
+	// the provider isn't called until then
+	until time.Time
+	// a call after the cool-down is in progress, which decides whether the
+	// provider is called again
+	trying bool
+}
+
+// allow returns an error if the provider for t shouldn't be called now.
+func (b *breaker) allow(t reflect.Type) error {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	if b.failures < b.threshold {
+		return nil
+	}
+	if b.trying || time.Now().Before(b.until) {
+		return &BreakerError{Type: t, Failures: b.failures, Until: b.until, Last: b.last}
+	}
+	b.trying = true
+	return nil
+}
+
+// record records the outcome of a call which was allowed. A call which was
+// canceled doesn't count either way.
+func (b *breaker) record(err error, canceled bool) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	b.trying = false
+	switch {
+	case canceled:
+	case err == nil:
+		b.failures, b.last = 0, nil
+	default:
+		b.failures, b.last = b.failures+1, err
+		if b.failures >= b.threshold {
+			b.until = time.Now().Add(b.cooldown)
+		}
+	}
+}
+
+// callUnlessOpen calls the provider, unless its circuit breaker is open.
+func (p *provider) callUnlessOpen(ctx *Context, r *resolution) (reflect.Value, error) {
+	if p.breaker == nil {
+		return p.call(ctx, r)
+	}
+	if err := p.breaker.allow(p.fn.Type().Out(0)); err != nil {
+		return reflect.Value{}, err
+	}
+	val, err := p.call(ctx, r)
+	p.breaker.record(err, r.context().Err() != nil)
+	return val, err
+}
@@ -0,0 +1,112 @@
+package di_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/mcvoid/di"
+)
+
+func TestBreaker(t *testing.T) {
+	errBoom := errors.New("boom")
+
+	t.Run("opens after repeated failures", func(t *testing.T) {
+		calls := 0
+		ctx := di.New()
+		ctx.Provide(func() (*bytes.Buffer, error) {
+			calls++
+			return nil, errBoom
+		}, di.Transient(), di.Breaker(2, time.Hour))
+
+		for i := 0; i < 2; i++ {
+			if _, err := di.Resolve[*bytes.Buffer](ctx); errors.Is(err, di.ErrBreakerOpen) || !errors.Is(err, errBoom) {
+				t.Errorf("expected %v got %v", errBoom, err)
+			}
+		}
+		_, err := di.Resolve[*bytes.Buffer](ctx)
+		var open *di.BreakerError
+		if !errors.As(err, &open) {
+			t.Fatalf("expected %T got %v", open, err)
+		}
+		if !errors.Is(err, di.ErrBreakerOpen) || !errors.Is(err, errBoom) {
+			t.Errorf("expected %v and %v got %v", di.ErrBreakerOpen, errBoom, err)
+		}
+		if open.Type != reflect.TypeOf(&bytes.Buffer{}) || open.Failures != 2 {
+			t.Errorf("expected %v and %v got %v and %v", reflect.TypeOf(&bytes.Buffer{}), 2, open.Type, open.Failures)
+		}
+		if calls != 2 {
+			t.Errorf("expected %v got %v", 2, calls)
+		}
+	})
+
+	t.Run("successes reset the count", func(t *testing.T) {
+		calls := 0
+		ctx := di.New()
+		ctx.Provide(func() (*bytes.Buffer, error) {
+			calls++
+			if calls%2 == 1 {
+				return nil, errBoom
+			}
+			return &bytes.Buffer{}, nil
+		}, di.Transient(), di.Breaker(2, time.Hour))
+
+		for i := 0; i < 6; i++ {
+			if _, err := di.Resolve[*bytes.Buffer](ctx); errors.Is(err, di.ErrBreakerOpen) {
+				t.Errorf("expected breaker to stay closed got %v", err)
+			}
+		}
+		if calls != 6 {
+			t.Errorf("expected %v got %v", 6, calls)
+		}
+	})
+
+	t.Run("provider is tried again after the cool-down", func(t *testing.T) {
+		fail := true
+		ctx := di.New()
+		ctx.Provide(func() (*bytes.Buffer, error) {
+			if fail {
+				return nil, errBoom
+			}
+			return &bytes.Buffer{}, nil
+		}, di.Transient(), di.Breaker(1, 20*time.Millisecond))
+
+		di.Resolve[*bytes.Buffer](ctx)
+		if _, err := di.Resolve[*bytes.Buffer](ctx); !errors.Is(err, di.ErrBreakerOpen) {
+			t.Errorf("expected %v got %v", di.ErrBreakerOpen, err)
+		}
+
+		time.Sleep(30 * time.Millisecond)
+		if _, err := di.Resolve[*bytes.Buffer](ctx); errors.Is(err, di.ErrBreakerOpen) || !errors.Is(err, errBoom) {
+			t.Errorf("expected %v got %v", errBoom, err)
+		}
+		if _, err := di.Resolve[*bytes.Buffer](ctx); !errors.Is(err, di.ErrBreakerOpen) {
+			t.Errorf("expected %v got %v", di.ErrBreakerOpen, err)
+		}
+
+		fail = false
+		time.Sleep(30 * time.Millisecond)
+		for i := 0; i < 2; i++ {
+			if _, err := di.Resolve[*bytes.Buffer](ctx); err != nil {
+				t.Errorf("expected %v got %v", nil, err)
+			}
+		}
+	})
+
+	t.Run("cancellation isn't a failure", func(t *testing.T) {
+		stdctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		ctx := di.New()
+		ctx.Provide(func() *bytes.Buffer { return &bytes.Buffer{} }, di.Transient(), di.Breaker(1, time.Hour))
+
+		if err := ctx.InjectContext(stdctx, func(*bytes.Buffer) {}); !errors.Is(err, context.Canceled) {
+			t.Errorf("expected %v got %v", context.Canceled, err)
+		}
+		if _, err := di.Resolve[*bytes.Buffer](ctx); err != nil {
+			t.Errorf("expected %v got %v", nil, err)
+		}
+	})
+}
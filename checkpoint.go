@@ -0,0 +1,49 @@
+package di
+
+import "fmt"
+
+// Checkpoint is a saved set of dependencies which a Context can be rolled back to.
+type Checkpoint struct {
+	ctx  *Context
+	deps map[key]*entry
+}
+
+// Checkpoint saves the current set of dependencies, so that later changes can be undone with Rollback.
+func (ctx *Context) Checkpoint() Checkpoint {
+	ctx.lock.RLock()
+	defer ctx.lock.RUnlock()
+
+	deps := make(map[key]*entry, len(ctx.deps))
+	for k, e := range ctx.deps {
+		deps[k] = e
+	}
+	return Checkpoint{ctx: ctx, deps: deps}
+}
+
+// Rollback restores the set of dependencies saved by Checkpoint, undoing every Add, Provide, Replace, and Remove since.
+// Providers which were registered at the time of the checkpoint keep any value they have constructed since.
+// Registrations which are restored in place of others are reported to Watch, and targets injected with InjectReactive
+// which depend on them are injected again. A checkpoint can be rolled back to more than once. Rolling back a frozen
+// Context is an error wrapping ErrFrozen.
+func (ctx *Context) Rollback(cp Checkpoint) error {
+	if cp.ctx != ctx {
+		return ErrInvalidCheckpoint
+	}
+
+	ctx.lock.Lock()
+	defer ctx.commit()
+
+	if ctx.frozen {
+		return fmt.Errorf("%w: cannot roll back", ErrFrozen)
+	}
+
+	previous := ctx.deps
+	ctx.deps = make(map[key]*entry, len(cp.deps))
+	for k, e := range cp.deps {
+		ctx.deps[k] = e
+		ctx.replaced(k, previous[k], e)
+	}
+	ctx.reindex = true
+	ctx.changed()
+	return nil
+}
@@ -0,0 +1,83 @@
+package di_test
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/mcvoid/di"
+)
+
+func TestCheckpoint(t *testing.T) {
+	t.Run("rollback undoes changes", func(t *testing.T) {
+		ctx := di.New().Add(os.Stdin)
+		cp := ctx.Checkpoint()
+		ctx.Add(os.Stdout, &bytes.Buffer{})
+
+		err := ctx.Rollback(cp)
+		if err != nil {
+			t.Errorf("expected %v got %v", nil, err)
+		}
+		ctx.Inject(func(f *os.File) {
+			if f != os.Stdin {
+				t.Errorf("expected %v got %v", os.Stdin, f)
+			}
+		})
+		if di.Has[*bytes.Buffer](ctx) {
+			t.Errorf("expected dependency to be rolled back")
+		}
+	})
+
+	t.Run("rollback restores removed dependencies", func(t *testing.T) {
+		ctx := di.New().Add(os.Stdin)
+		cp := ctx.Checkpoint()
+		ctx.Remove(os.Stdin)
+		ctx.Rollback(cp)
+
+		if !di.Has[*os.File](ctx) {
+			t.Errorf("expected dependency to be restored")
+		}
+	})
+
+	t.Run("checkpoint from another context", func(t *testing.T) {
+		cp := di.New().Checkpoint()
+
+		err := di.New().Rollback(cp)
+		if !errors.Is(err, di.ErrInvalidCheckpoint) {
+			t.Errorf("expected %v got %v", di.ErrInvalidCheckpoint, err)
+		}
+	})
+
+	t.Run("frozen context", func(t *testing.T) {
+		ctx := di.New()
+		cp := ctx.Checkpoint()
+		ctx.Freeze()
+
+		err := ctx.Rollback(cp)
+		if !errors.Is(err, di.ErrFrozen) {
+			t.Errorf("expected %v got %v", di.ErrFrozen, err)
+		}
+	})
+
+	t.Run("restored registrations are watched and rebound", func(t *testing.T) {
+		ctx := di.New().Add(os.Stdout)
+		cp := ctx.Checkpoint()
+		ctx.Add(os.Stderr)
+
+		var watched *os.File
+		di.Watch(ctx, func(old, new *os.File) { watched = new })
+		r := &reporter{}
+		ctx.InjectReactive(r)
+
+		if err := ctx.Rollback(cp); err != nil {
+			t.Fatalf("expected %v got %v", nil, err)
+		}
+		if watched != os.Stdout {
+			t.Errorf("expected %v got %v", os.Stdout, watched)
+		}
+		if r.out != os.Stdout {
+			t.Errorf("expected %v got %v", os.Stdout, r.out)
+		}
+	})
+}
@@ -0,0 +1,14 @@
+// Command dicheck runs the dicheck analyzer, which checks Bind methods for wiring mistakes. It can be run directly or
+// by go vet:
+//
+//	go vet -vettool=$(which dicheck) ./...
+package main
+
+import (
+	"github.com/mcvoid/di/dicheck"
+	"golang.org/x/tools/go/analysis/singlechecker"
+)
+
+func main() {
+	singlechecker.Main(dicheck.Analyzer)
+}
@@ -0,0 +1,444 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/printer"
+	"go/token"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+const (
+	staticFile = "digen_static.go"
+	devFile    = "digen_dev.go"
+
+	provideDirective = "//di:provide"
+	injectDirective  = "//di:inject"
+	diImport         = "github.com/mcvoid/di"
+)
+
+var (
+	// Returned when the package has no functions marked //di:inject
+	errNoTargets = errors.New("no functions marked " + injectDirective)
+	// Returned when a marked function can't be used
+	errInvalidFunc = errors.New("invalid function")
+	// Returned when providers depend on each other in a cycle
+	errCycle = errors.New("dependency cycle")
+)
+
+// function is a marked function, with its types written as source.
+type function struct {
+	name     string
+	params   []string
+	results  []string
+	hasError bool // whether the last result is an error
+	pos      token.Position
+}
+
+// pkg is what digen knows about the package it is generating for.
+type pkg struct {
+	name      string
+	providers map[string]*function // by the type they provide
+	targets   []*function
+	imports   map[string]string   // import path by the name it's used under
+	uses      map[string][]string // import names used by each type
+}
+
+// file is a generated file being written.
+type file struct {
+	body  bytes.Buffer
+	types map[string]bool // types written in the body, needing imports
+}
+
+func newFile() *file {
+	return &file{types: map[string]bool{}}
+}
+
+// generate parses the package in dir and returns the source of the static
+// and development wiring files.
+func generate(dir string) ([]byte, []byte, error) {
+	p, err := parse(dir)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	static, dev := newFile(), newFile()
+	for _, target := range p.targets {
+		order, err := p.providersFor(target)
+		if err != nil {
+			return nil, nil, err
+		}
+		writeStatic(static, target, order)
+		writeDev(dev, target, order)
+	}
+
+	staticSrc, err := p.source(static, "digen")
+	if err != nil {
+		return nil, nil, err
+	}
+	devSrc, err := p.source(dev, "!digen")
+	if err != nil {
+		return nil, nil, err
+	}
+	return staticSrc, devSrc, nil
+}
+
+// parse finds the marked functions in the package in dir.
+func parse(dir string) (*pkg, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.go"))
+	if err != nil {
+		return nil, err
+	}
+
+	fset := token.NewFileSet()
+	p := &pkg{providers: map[string]*function{}, imports: map[string]string{}, uses: map[string][]string{}}
+	for _, name := range files {
+		base := filepath.Base(name)
+		if strings.HasSuffix(base, "_test.go") || base == staticFile || base == devFile {
+			continue
+		}
+		src, err := os.ReadFile(name)
+		if err != nil {
+			return nil, err
+		}
+		file, err := parser.ParseFile(fset, name, src, parser.ParseComments)
+		if err != nil {
+			return nil, err
+		}
+		p.name = file.Name.Name
+
+		for _, decl := range file.Decls {
+			fn, ok := decl.(*ast.FuncDecl)
+			if !ok || fn.Doc == nil {
+				continue
+			}
+			provide, inject := directives(fn.Doc)
+			if !provide && !inject {
+				continue
+			}
+			f, err := p.function(fset, file, fn)
+			if err != nil {
+				return nil, err
+			}
+			if provide {
+				if err := p.addProvider(f); err != nil {
+					return nil, err
+				}
+			}
+			if inject {
+				p.targets = append(p.targets, f)
+			}
+		}
+	}
+
+	if len(p.targets) == 0 {
+		return nil, fmt.Errorf("%s: %w", dir, errNoTargets)
+	}
+	sort.Slice(p.targets, func(i, j int) bool { return p.targets[i].name < p.targets[j].name })
+	return p, nil
+}
+
+// directives reports which directives a doc comment has.
+func directives(doc *ast.CommentGroup) (provide, inject bool) {
+	for _, c := range doc.List {
+		switch strings.TrimSpace(c.Text) {
+		case provideDirective:
+			provide = true
+		case injectDirective:
+			inject = true
+		}
+	}
+	return provide, inject
+}
+
+// function describes a marked function declaration, recording the imports
+// its types use.
+func (p *pkg) function(fset *token.FileSet, file *ast.File, fn *ast.FuncDecl) (*function, error) {
+	f := &function{name: fn.Name.Name, pos: fset.Position(fn.Pos())}
+	if fn.Recv != nil || fn.Type.TypeParams != nil {
+		return nil, fmt.Errorf("%v: %w %s: methods and generic functions can't be marked", f.pos, errInvalidFunc, f.name)
+	}
+
+	for _, field := range fn.Type.Params.List {
+		if _, ok := field.Type.(*ast.Ellipsis); ok {
+			return nil, fmt.Errorf("%v: %w %s: variadic functions can't be marked", f.pos, errInvalidFunc, f.name)
+		}
+		typ, err := p.typeString(fset, file, field.Type)
+		if err != nil {
+			return nil, err
+		}
+		// a, b T declares two parameters
+		for i := 0; i < max(len(field.Names), 1); i++ {
+			f.params = append(f.params, typ)
+		}
+	}
+	if fn.Type.Results != nil {
+		for _, field := range fn.Type.Results.List {
+			typ, err := p.typeString(fset, file, field.Type)
+			if err != nil {
+				return nil, err
+			}
+			for i := 0; i < max(len(field.Names), 1); i++ {
+				f.results = append(f.results, typ)
+			}
+		}
+	}
+	if n := len(f.results); n > 0 && f.results[n-1] == "error" {
+		f.results, f.hasError = f.results[:n-1], true
+	}
+	return f, nil
+}
+
+// addProvider registers a provider under the type it returns.
+func (p *pkg) addProvider(f *function) error {
+	if len(f.results) != 1 {
+		return fmt.Errorf("%v: %w %s: providers must return a value and optionally an error", f.pos, errInvalidFunc, f.name)
+	}
+	if other, ok := p.providers[f.results[0]]; ok {
+		return fmt.Errorf("%v: %w %s: %s is already provided by %s", f.pos, errInvalidFunc, f.name, f.results[0], other.name)
+	}
+	p.providers[f.results[0]] = f
+	return nil
+}
+
+// typeString prints a type expression, recording the imports it uses.
+func (p *pkg) typeString(fset *token.FileSet, file *ast.File, expr ast.Expr) (string, error) {
+	var err error
+	used := []string{}
+	ast.Inspect(expr, func(n ast.Node) bool {
+		sel, ok := n.(*ast.SelectorExpr)
+		if !ok {
+			return true
+		}
+		if id, ok := sel.X.(*ast.Ident); ok && err == nil {
+			err = p.addImport(file, id.Name)
+			used = append(used, id.Name)
+		}
+		return false
+	})
+	if err != nil {
+		return "", err
+	}
+
+	b := &bytes.Buffer{}
+	if err := printer.Fprint(b, fset, expr); err != nil {
+		return "", err
+	}
+	p.uses[b.String()] = used
+	return b.String(), nil
+}
+
+// addImport records the import a file uses under the name.
+func (p *pkg) addImport(file *ast.File, name string) error {
+	for _, spec := range file.Imports {
+		importPath, err := strconv.Unquote(spec.Path.Value)
+		if err != nil {
+			return err
+		}
+		used := path.Base(importPath)
+		if spec.Name != nil {
+			used = spec.Name.Name
+		}
+		if used != name {
+			continue
+		}
+		if other, ok := p.imports[name]; ok && other != importPath {
+			return fmt.Errorf("%s is imported as both %q and %q", name, other, importPath)
+		}
+		p.imports[name] = importPath
+		return nil
+	}
+	return fmt.Errorf("no import for %s in %s", name, file.Name.Name)
+}
+
+// providersFor returns the providers a target needs, each after the
+// providers it needs in turn.
+func (p *pkg) providersFor(target *function) ([]*function, error) {
+	order := []*function{}
+	done := map[*function]bool{}
+	visiting := []*function{}
+
+	var visit func(f *function) error
+	visit = func(f *function) error {
+		for i, v := range visiting {
+			if v == f {
+				names := []string{}
+				for _, c := range append(visiting[i:], f) {
+					names = append(names, c.results[0])
+				}
+				return fmt.Errorf("%v: %w: %s", f.pos, errCycle, strings.Join(names, " -> "))
+			}
+		}
+		if done[f] {
+			return nil
+		}
+
+		visiting = append(visiting, f)
+		for _, param := range f.params {
+			if dep, ok := p.providers[param]; ok {
+				if err := visit(dep); err != nil {
+					return err
+				}
+			}
+		}
+		visiting = visiting[:len(visiting)-1]
+
+		done[f] = true
+		order = append(order, f)
+		return nil
+	}
+
+	for _, param := range target.params {
+		if dep, ok := p.providers[param]; ok {
+			if err := visit(dep); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return order, nil
+}
+
+// source formats a generated file, importing what its body uses.
+func (p *pkg) source(f *file, tag string) ([]byte, error) {
+	used := map[string]bool{}
+	for t := range f.types {
+		for _, name := range p.uses[t] {
+			used[name] = true
+		}
+	}
+	names := make([]string, 0, len(used))
+	for name := range used {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	b := &bytes.Buffer{}
+	fmt.Fprintf(b, "// Code generated by digen. DO NOT EDIT.\n\n//go:build %s\n\npackage %s\n\n", tag, p.name)
+	b.WriteString("import (\n")
+	for _, std := range []bool{true, false} {
+		for _, name := range names {
+			importPath := p.imports[name]
+			if isStd(importPath) != std {
+				continue
+			}
+			if path.Base(importPath) == name {
+				fmt.Fprintf(b, "\t%q\n", importPath)
+			} else {
+				fmt.Fprintf(b, "\t%s %q\n", name, importPath)
+			}
+		}
+		if std {
+			fmt.Fprintf(b, "\n\t%q\n", diImport)
+		}
+	}
+	b.WriteString(")\n")
+	b.Write(f.body.Bytes())
+	return format.Source(b.Bytes())
+}
+
+// isStd reports whether an import path is in the standard library, whose
+// paths have no dot in their first element.
+func isStd(importPath string) bool {
+	return !strings.Contains(strings.Split(importPath, "/")[0], ".")
+}
+
+// injectName is the name of the generated function for a target.
+func injectName(target *function) string {
+	r := []rune(target.name)
+	r[0] = unicode.ToUpper(r[0])
+	return "Inject" + string(r)
+}
+
+// signature writes the signature of the generated function for a target.
+func signature(f *file, target *function) {
+	results := []string{}
+	for i, r := range target.results {
+		results = append(results, fmt.Sprintf("r%d %s", i, r))
+		f.types[r] = true
+	}
+	results = append(results, "err error")
+	fmt.Fprintf(&f.body, "\n// %s calls %s with its dependencies.\nfunc %s(ctx *di.Context) (%s) {\n",
+		injectName(target), target.name, injectName(target), strings.Join(results, ", "))
+}
+
+// writeStatic writes the generated function for a target which calls
+// everything directly.
+func writeStatic(f *file, target *function, order []*function) {
+	signature(f, target)
+	b := &f.body
+
+	vars := map[string]string{}
+	next := 0
+	args := func(fn *function) []string {
+		args := []string{}
+		for _, param := range fn.params {
+			v, ok := vars[param]
+			if !ok {
+				v = fmt.Sprintf("v%d", next)
+				next++
+				vars[param] = v
+				f.types[param] = true
+				fmt.Fprintf(b, "\t%s, err := di.Resolve[%s](ctx)\n\tif err != nil {\n\t\treturn\n\t}\n", v, param)
+			}
+			args = append(args, v)
+		}
+		return args
+	}
+
+	for _, fn := range order {
+		in := args(fn)
+		v := fmt.Sprintf("v%d", next)
+		next++
+		if fn.hasError {
+			fmt.Fprintf(b, "\t%s, err := %s(%s)\n\tif err != nil {\n\t\treturn\n\t}\n", v, fn.name, strings.Join(in, ", "))
+		} else {
+			fmt.Fprintf(b, "\t%s := %s(%s)\n", v, fn.name, strings.Join(in, ", "))
+		}
+		vars[fn.results[0]] = v
+	}
+
+	in := args(target)
+	out := []string{}
+	for i := range target.results {
+		out = append(out, fmt.Sprintf("r%d", i))
+	}
+	if target.hasError {
+		out = append(out, "err")
+	}
+	call := fmt.Sprintf("%s(%s)", target.name, strings.Join(in, ", "))
+	if len(out) > 0 {
+		call = strings.Join(out, ", ") + " = " + call
+	}
+	fmt.Fprintf(b, "\t%s\n\treturn\n}\n", call)
+}
+
+// writeDev writes the generated function for a target which goes through
+// the Context.
+func writeDev(f *file, target *function, order []*function) {
+	signature(f, target)
+	b := &f.body
+
+	b.WriteString("\tchild := ctx.Child()\n")
+	for _, fn := range order {
+		fmt.Fprintf(b, "\tif err = child.Provide(%s); err != nil {\n\t\treturn\n\t}\n", fn.name)
+	}
+	if len(target.results) == 0 {
+		fmt.Fprintf(b, "\t_, err = child.Invoke(%s)\n\treturn\n}\n", target.name)
+		return
+	}
+	fmt.Fprintf(b, "\tout, err := child.Invoke(%s)\n\tif out == nil {\n\t\treturn\n\t}\n", target.name)
+	for i, r := range target.results {
+		fmt.Fprintf(b, "\tr%d, _ = out[%d].(%s)\n", i, i, r)
+	}
+	b.WriteString("\treturn\n}\n")
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestGenerate(t *testing.T) {
+	static, dev, err := generate(filepath.Join("testdata", "app"))
+	if err != nil {
+		t.Fatalf("expected %v got %v", nil, err)
+	}
+
+	t.Run("static", func(t *testing.T) {
+		for _, expected := range []string{
+			"//go:build digen\n",
+			"func InjectServe(ctx *di.Context) (err error) {",
+			"func InjectAddr(ctx *di.Context) (r0 string, err error) {",
+			"v0, err := di.Resolve[*Config](ctx)",
+			"v1, err := NewDB(v0)",
+			"v3 := NewLogger(v2)",
+			"v4 := NewServer(v1, v3)",
+			"err = serve(v4)",
+			"r0 = addr(v4, v0)",
+			`"io"`,
+		} {
+			if !strings.Contains(string(static), expected) {
+				t.Errorf("expected %q in\n%s", expected, static)
+			}
+		}
+		if strings.Contains(string(static), "stdlog") {
+			t.Errorf("expected unused import to be left out of\n%s", static)
+		}
+	})
+
+	t.Run("dev", func(t *testing.T) {
+		for _, expected := range []string{
+			"//go:build !digen\n",
+			"child.Provide(NewDB)",
+			"child.Provide(NewLogger)",
+			"_, err = child.Invoke(serve)",
+			"out, err := child.Invoke(addr)",
+			"r0, _ = out[0].(string)",
+		} {
+			if !strings.Contains(string(dev), expected) {
+				t.Errorf("expected %q in\n%s", expected, dev)
+			}
+		}
+	})
+}
+
+func TestGenerateErrors(t *testing.T) {
+	for _, test := range []struct {
+		name     string
+		src      string
+		expected error
+	}{
+		{"no targets", "package p\n\n//di:provide\nfunc newInt() int { return 1 }\n", errNoTargets},
+		{"method", "package p\n\ntype T struct{}\n\n//di:inject\nfunc (T) run() {}\n", errInvalidFunc},
+		{"no result", "package p\n\n//di:provide\nfunc newInt() {}\n\n//di:inject\nfunc run() {}\n", errInvalidFunc},
+		{"duplicate", "package p\n\n//di:provide\nfunc a() int { return 1 }\n\n//di:provide\nfunc b() int { return 2 }\n\n//di:inject\nfunc run(int) {}\n", errInvalidFunc},
+		{"cycle", "package p\n\n//di:provide\nfunc a(string) int { return 1 }\n\n//di:provide\nfunc b(int) string { return \"\" }\n\n//di:inject\nfunc run(int) {}\n", errCycle},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			dir := t.TempDir()
+			if err := os.WriteFile(filepath.Join(dir, "p.go"), []byte(test.src), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			if _, _, err := generate(dir); !errors.Is(err, test.expected) {
+				t.Errorf("expected %v got %v", test.expected, err)
+			}
+		})
+	}
+}
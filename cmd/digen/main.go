@@ -0,0 +1,69 @@
+// Command digen generates static wiring code for a package, so that hot paths can avoid the reflection di uses.
+//
+// Functions in the package are marked with directives in their doc comments. A function marked //di:provide is a
+// provider, returning a single value or a value and an error, as with di.Context.Provide. A function marked //di:inject
+// is a target. For each target, digen generates a function named Inject followed by the target's name, which takes a
+// *di.Context and returns the target's results, with an error added if the target doesn't return one:
+//
+//	//di:provide
+//	func NewServer(db *DB, log Logger) *Server { ... }
+//
+//	//di:inject
+//	func serve(s *Server) error { ... }
+//
+//	// generated:
+//	func InjectServe(ctx *di.Context) (err error)
+//
+// Two files are written. digen_static.go, built with the digen build tag, calls the providers and the target directly,
+// in dependency order. Each parameter which no provider returns is resolved from the Context with di.Resolve. digen_dev.go,
+// built without the tag, does the same through the Context's reflection, by providing the providers to a child Context and
+// invoking the target in it. Both call each provider at most once per call. Development builds thus keep di's
+// flexibility, while production builds made with -tags digen don't use reflect.Call at all.
+//
+// Providers are matched to parameters by their exact type, as written in the source. Unlike di, digen doesn't match
+// interface parameters to providers of types which implement them.
+//
+// Usage:
+//
+//	digen [dir]
+//
+// dir defaults to the current directory. It is typically run with go generate:
+//
+//	//go:generate go run github.com/mcvoid/di/cmd/digen
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+func main() {
+	flag.Usage = func() {
+		fmt.Fprintln(flag.CommandLine.Output(), "usage: digen [dir]")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	dir := "."
+	if flag.NArg() > 0 {
+		dir = flag.Arg(0)
+	}
+	if err := run(dir); err != nil {
+		fmt.Fprintln(os.Stderr, "digen:", err)
+		os.Exit(1)
+	}
+}
+
+// run generates the wiring for the package in dir and writes it there.
+func run(dir string) error {
+	static, dev, err := generate(dir)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(dir, staticFile), static, 0o644); err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, devFile), dev, 0o644)
+}
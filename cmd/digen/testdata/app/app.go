@@ -0,0 +1,49 @@
+package app
+
+import (
+	"errors"
+	"io"
+	stdlog "log"
+)
+
+type Config struct {
+	Addr string
+}
+
+type DB struct {
+	cfg *Config
+}
+
+type Server struct {
+	db  *DB
+	log *stdlog.Logger
+}
+
+//di:provide
+func NewDB(cfg *Config) (*DB, error) {
+	if cfg.Addr == "" {
+		return nil, errors.New("no address")
+	}
+	return &DB{cfg}, nil
+}
+
+//di:provide
+func NewLogger(w io.Writer) *stdlog.Logger {
+	return stdlog.New(w, "", 0)
+}
+
+//di:provide
+func NewServer(db *DB, log *stdlog.Logger) *Server {
+	return &Server{db, log}
+}
+
+//di:inject
+func serve(s *Server) error {
+	s.log.Println("serving", s.db.cfg.Addr)
+	return nil
+}
+
+//di:inject
+func addr(s *Server, cfg *Config) string {
+	return cfg.Addr
+}
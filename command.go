@@ -0,0 +1,48 @@
+package di
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Commands maps the names of a command-line tool's subcommands to functions to inject when they're run, which may
+// return an error:
+//
+//	cmds := di.Commands{
+//		"migrate": func(db *sql.DB, log Logger) error { ... },
+//		"serve":   func(srv *Server, args di.Args) error { ... },
+//	}
+//	if err := cmds.Run(ctx, os.Args[1:]); err != nil {
+//		log.Fatal(err)
+//	}
+type Commands map[string]interface{}
+
+// Args are the command-line arguments following the name of the command being run by Commands.Run.
+type Args []string
+
+// Run runs the command named by args[0], injecting it from a child of ctx which has the rest of args as Args. Only the
+// command's own dependencies are resolved, so providers for the other commands are never called. The command's error,
+// or the error injecting it, is returned.
+//
+// If args is empty or names no command, an error wrapping ErrUnknownCommand is returned, listing the commands.
+func (cmds Commands) Run(ctx *Context, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("%w: expected one of %s", ErrUnknownCommand, cmds.names())
+	}
+	cmd, ok := cmds[args[0]]
+	if !ok {
+		return fmt.Errorf("%w %q: expected one of %s", ErrUnknownCommand, args[0], cmds.names())
+	}
+	return ctx.Child().Add(Args(args[1:])).Inject(cmd)
+}
+
+// names returns the sorted names of the commands.
+func (cmds Commands) names() string {
+	names := make([]string, 0, len(cmds))
+	for name := range cmds {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
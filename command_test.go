@@ -0,0 +1,52 @@
+package di_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/mcvoid/di"
+)
+
+func TestCommands(t *testing.T) {
+	t.Run("only the invoked command's dependencies are resolved", func(t *testing.T) {
+		ctx := di.New()
+		ctx.Provide(func() logConfig { return logConfig{Level: "info"} })
+		ctx.Provide(func() *logger {
+			t.Errorf("expected logger to not be built")
+			return nil
+		})
+		var got []string
+		cmds := di.Commands{
+			"migrate": func(cfg logConfig, args di.Args) error {
+				got = append([]string{cfg.Level}, args...)
+				return nil
+			},
+			"serve": func(*logger) {},
+		}
+		if err := cmds.Run(ctx, []string{"migrate", "up", "3"}); err != nil {
+			t.Fatalf("expected %v got %v", nil, err)
+		}
+		if strings.Join(got, " ") != "info up 3" {
+			t.Errorf("expected %v got %v", "info up 3", got)
+		}
+	})
+
+	t.Run("command errors are returned", func(t *testing.T) {
+		expected := errors.New("failed")
+		err := di.Commands{"fail": func() error { return expected }}.Run(di.New(), []string{"fail"})
+		if !errors.Is(err, expected) {
+			t.Errorf("expected %v got %v", expected, err)
+		}
+	})
+
+	t.Run("unknown commands", func(t *testing.T) {
+		cmds := di.Commands{"b": func() {}, "a": func() {}}
+		for _, args := range [][]string{nil, {"c"}} {
+			err := cmds.Run(di.New(), args)
+			if !errors.Is(err, di.ErrUnknownCommand) || !strings.Contains(err.Error(), "a, b") {
+				t.Errorf("expected %v got %v", di.ErrUnknownCommand, err)
+			}
+		}
+	})
+}
@@ -0,0 +1,203 @@
+package di
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sync/atomic"
+)
+
+// Compile prepares target for repeated injection, for code which injects the same function or method many times, such
+// as a server injecting a handler for every request. The dependency each parameter gets is found once, by the same rules
+// Inject uses, and the returned function only fetches those dependencies and calls target, without searching the Context
+// again:
+//
+//	handle, err := ctx.Compile(handler)
+//	...
+//	for req := range requests {
+//		err := handle()
+//	}
+//
+// Any error Inject would return before calling target, such as an ambiguous or missing dependency, is returned by
+// Compile instead, without calling any providers. Calling the returned function behaves like Inject: providers are
+// called when their values are needed, AfterInject is called on a target with a Bind method, and an error from either
+// is returned. If the dependencies of the Context or its ancestors change, the next call finds the dependencies again,
+// returning any error Inject would.
+//
+// Parameters which get more than one dependency, such as slices of interfaces, maps, Named parameters and In structs,
+// and those with no matching dependency, are resolved in full on every call.
+func (ctx *Context) Compile(target interface{}) (func() error, error) {
+	fn, err := injectable(ctx, target)
+	if err != nil {
+		return nil, err
+	}
+	name := describe(target, ctx.bindMethodName())
+	t := fn.Type()
+
+	p, err := compile(ctx, t)
+	if err != nil {
+		return nil, fmt.Errorf("injecting %s: %w", name, err)
+	}
+	var cur atomic.Pointer[plan]
+	cur.Store(p)
+
+	return func() error {
+		p := cur.Load()
+		if !p.current() {
+			p = planFor(ctx, t)
+			cur.Store(p)
+		}
+		r := newResolution()
+		in, err := p.args(ctx, r)
+		r.release()
+		if err != nil {
+			return fmt.Errorf("injecting %s: %w", name, err)
+		}
+		_, err = callResolved(ctx, target, fn, ctx.bindMethodName(), in)
+		putArgs(in)
+		if err == nil && reflect.ValueOf(target).Kind() != reflect.Func {
+			err = afterInject(target)
+		}
+		return err
+	}, nil
+}
+
+// plan is where the arguments of a function type come from. Plans are
+// cached by Inject, so that each call doesn't have to search the Context.
+type plan struct {
+	typ   reflect.Type
+	steps []step
+	// the Context the plan was made in, and the snapshots of it and each of
+	// its ancestors at the time
+	home   *Context
+	states []*state
+}
+
+// step is the dependency a parameter gets: the entry, and the Context it is
+// registered in. A nil entry means the parameter is resolved in full.
+type step struct {
+	owner *Context
+	e     *entry
+}
+
+// use returns the value of the entry chosen for a dependency, recording the
+// choice if a plan is being made for it.
+func (r *resolution) use(ctx *Context, e *entry) (reflect.Value, error) {
+	if r.picked != nil && len(r.building) == 0 {
+		*r.picked = step{ctx, e}
+	}
+	return e.value(ctx, r)
+}
+
+// compile makes the plan for a function type, checking that every parameter
+// can be resolved without calling any providers. The plan is incomplete if
+// they can't.
+func compile(ctx *Context, t reflect.Type) (*plan, error) {
+	p := &plan{typ: t, steps: make([]step, t.NumIn()), home: ctx}
+	// taken first, so that changes made while compiling make the plan stale
+	for c := ctx; c != nil; c = c.parent {
+		p.states = append(p.states, c.load())
+	}
+	errs := []error{}
+	for i := range p.steps {
+		r := &resolution{validate: true}
+		if direct(t, i) {
+			r.picked = &p.steps[i]
+		}
+		if _, err := resolveParam(ctx, r, t, i); err != nil {
+			errs = append(errs, paramError(i, t.In(i), err))
+		}
+	}
+	return p, errors.Join(errs...)
+}
+
+// planFor returns the plan for injecting a function type, reusing the
+// cached one if nothing has changed since it was made. If the function can't
+// be injected, every parameter is resolved in full, so the errors are
+// reported as usual.
+//
+// A Context with no registrations of its own, such as a new Child, resolves
+// everything exactly as its parent would, so it shares its parent's plans.
+func planFor(ctx *Context, t reflect.Type) *plan {
+	home := ctx
+	for home.parent != nil && !home.load().own() {
+		home = home.parent
+	}
+	s := home.load()
+	if p, ok := s.plans.Load(t); ok && p.(*plan).current() {
+		return p.(*plan)
+	}
+
+	p, err := compile(home, t)
+	if err != nil {
+		p.steps = make([]step, t.NumIn())
+	}
+	if s != empty {
+		s.plans.Store(t, p)
+	}
+	return p
+}
+
+// current reports whether nothing has changed in the Context the plan was
+// made in or its ancestors since.
+func (p *plan) current() bool {
+	i := 0
+	for c := p.home; c != nil; c = c.parent {
+		if i >= len(p.states) || c.load() != p.states[i] {
+			return false
+		}
+		i++
+	}
+	return i == len(p.states)
+}
+
+// direct reports whether parameter i of the function type t gets a single
+// dependency, which a plan can record.
+func direct(t reflect.Type, i int) bool {
+	argType := t.In(i)
+	switch {
+	case t.IsVariadic() && i == t.NumIn()-1:
+		return false
+	case isIn(argType), argType.Implements(qualifiedType), argType.Implements(secretType), argType == contextType:
+		return false
+	case argType.Kind() == reflect.Slice && argType.Elem().Kind() == reflect.Interface:
+		return false
+	case argType.Kind() == reflect.Map && argType.Key().Kind() == reflect.String:
+		return false
+	}
+	return true
+}
+
+// args resolves the arguments for a call following the plan. The slice
+// should be given back with putArgs once the call is done.
+func (p *plan) args(ctx *Context, r *resolution) ([]reflect.Value, error) {
+	in := getArgs(len(p.steps))
+	var errs []error
+	for i, s := range p.steps {
+		argType := p.typ.In(i)
+		beforeResolve(ctx, r, argType)
+		var val reflect.Value
+		var err error
+		if s.e == nil {
+			val, err = resolveParam(ctx, r, p.typ, i)
+		} else {
+			val, err = s.value(r)
+		}
+		afterResolve(ctx, r, argType, val, err)
+		if err != nil {
+			errs = append(errs, paramError(i, argType, err))
+			continue
+		}
+		in[i] = val
+	}
+	if len(errs) > 0 {
+		putArgs(in)
+		return nil, errors.Join(errs...)
+	}
+	return in, nil
+}
+
+// value returns the value of the step's entry.
+func (s step) value(r *resolution) (reflect.Value, error) {
+	return s.e.value(s.owner, r)
+}
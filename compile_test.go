@@ -0,0 +1,247 @@
+package di_test
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/mcvoid/di"
+)
+
+func TestCompile(t *testing.T) {
+	t.Run("compiled function injects target on every call", func(t *testing.T) {
+		ctx := di.New().Add(os.Stdout)
+		calls := 0
+		fn, err := ctx.Compile(func(w io.Writer) {
+			calls++
+			if w != os.Stdout {
+				t.Errorf("expected %v got %v", os.Stdout, w)
+			}
+		})
+		if err != nil {
+			t.Fatalf("expected %v got %v", nil, err)
+		}
+		for i := 0; i < 3; i++ {
+			if err := fn(); err != nil {
+				t.Errorf("expected %v got %v", nil, err)
+			}
+		}
+		if calls != 3 {
+			t.Errorf("expected %v got %v", 3, calls)
+		}
+	})
+
+	t.Run("providers are called when the function is", func(t *testing.T) {
+		ctx := di.New()
+		singletons, transients := 0, 0
+		ctx.Provide(func() *bytes.Buffer { singletons++; return &bytes.Buffer{} })
+		ctx.Provide(func() *strings.Builder { transients++; return &strings.Builder{} }, di.Transient())
+
+		fn, err := ctx.Compile(func(*bytes.Buffer, *strings.Builder) {})
+		if err != nil {
+			t.Fatalf("expected %v got %v", nil, err)
+		}
+		if singletons != 0 || transients != 0 {
+			t.Errorf("expected %v got %v, %v", 0, singletons, transients)
+		}
+		fn()
+		fn()
+		if singletons != 1 {
+			t.Errorf("expected %v got %v", 1, singletons)
+		}
+		if transients != 2 {
+			t.Errorf("expected %v got %v", 2, transients)
+		}
+	})
+
+	t.Run("dependencies in a parent are used", func(t *testing.T) {
+		parent := di.New()
+		parent.Provide(func() *bytes.Buffer { return bytes.NewBufferString("parent") })
+		fn, err := parent.Child().Compile(func(r io.Reader) {
+			b, _ := io.ReadAll(r)
+			if string(b) != "parent" {
+				t.Errorf("expected %v got %v", "parent", string(b))
+			}
+		})
+		if err != nil {
+			t.Fatalf("expected %v got %v", nil, err)
+		}
+		if err := fn(); err != nil {
+			t.Errorf("expected %v got %v", nil, err)
+		}
+	})
+
+	t.Run("errors are returned by Compile", func(t *testing.T) {
+		ctx := di.New(di.WithStrict()).Add(&bytes.Buffer{}, &strings.Builder{})
+		fn, err := ctx.Compile(func(fmt.Stringer, *os.File) {
+			t.Errorf("expected func to not be called")
+		})
+		if fn != nil {
+			t.Errorf("expected no function")
+		}
+		if !errors.Is(err, di.ErrAmbiguous) {
+			t.Errorf("expected %v got %v", di.ErrAmbiguous, err)
+		}
+		if !errors.Is(err, di.ErrMissing) {
+			t.Errorf("expected %v got %v", di.ErrMissing, err)
+		}
+	})
+
+	t.Run("target must be injectable", func(t *testing.T) {
+		_, err := di.New().Compile(42)
+		if !errors.Is(err, di.ErrNotInjectable) {
+			t.Errorf("expected %v got %v", di.ErrNotInjectable, err)
+		}
+	})
+
+	t.Run("target's error is returned", func(t *testing.T) {
+		errBoom := errors.New("boom")
+		fn, _ := di.New().Compile(func() error { return errBoom })
+		if err := fn(); !errors.Is(err, errBoom) {
+			t.Errorf("expected %v got %v", errBoom, err)
+		}
+	})
+
+	t.Run("AfterInject is called on bound objects", func(t *testing.T) {
+		i := initializer{}
+		fn, err := di.New().Add(os.Stdin).Compile(&i)
+		if err != nil {
+			t.Fatalf("expected %v got %v", nil, err)
+		}
+		i.err = errors.New("not ready")
+		if err := fn(); !errors.Is(err, i.err) {
+			t.Errorf("expected %v got %v", i.err, err)
+		}
+		if !i.inited {
+			t.Errorf("expected AfterInject to be called")
+		}
+	})
+
+	t.Run("provider errors are returned by the function", func(t *testing.T) {
+		errBoom := errors.New("boom")
+		ctx := di.New()
+		ctx.Provide(func() (*bytes.Buffer, error) { return nil, errBoom })
+		fn, err := ctx.Compile(func(*bytes.Buffer) {
+			t.Errorf("expected func to not be called")
+		})
+		if err != nil {
+			t.Fatalf("expected %v got %v", nil, err)
+		}
+		if err := fn(); !errors.Is(err, errBoom) {
+			t.Errorf("expected %v got %v", errBoom, err)
+		}
+	})
+
+	t.Run("groups are resolved on every call", func(t *testing.T) {
+		ctx := di.New().Add(&bytes.Buffer{})
+		got := 0
+		fn, _ := ctx.Compile(func(ws []io.Writer) { got = len(ws) })
+		ctx.Add(&strings.Builder{})
+		fn()
+		if got != 2 {
+			t.Errorf("expected %v got %v", 2, got)
+		}
+	})
+}
+
+// text and otherText are readers which can be read any number of times.
+type text string
+
+func (t text) Read(p []byte) (int, error) { return copy(p, t), io.EOF }
+
+type otherText string
+
+func (t otherText) Read(p []byte) (int, error) { return copy(p, t), io.EOF }
+
+func TestPlanCache(t *testing.T) {
+	for _, test := range []struct {
+		name   string
+		change func(ctx *di.Context, parent *di.Context)
+	}{
+		{"Replace", func(ctx *di.Context, parent *di.Context) { ctx.Replace(text("changed")) }},
+		{"Add", func(ctx *di.Context, parent *di.Context) {
+			ctx.Remove(text(""))
+			ctx.Add(otherText("changed"))
+		}},
+		{"Rollback", func(ctx *di.Context, parent *di.Context) {
+			cp := ctx.Checkpoint()
+			ctx.Add(otherText("changed"))
+			ctx.Rollback(cp)
+			ctx.Remove(text(""))
+			parent.Add(otherText("changed"))
+		}},
+		{"parent", func(ctx *di.Context, parent *di.Context) {
+			ctx.Remove(text(""))
+			parent.Add(otherText("changed"))
+		}},
+		{"Prefer", func(ctx *di.Context, parent *di.Context) {
+			ctx.Add(otherText("changed"))
+			di.Prefer[io.Reader](ctx, otherText(""))
+		}},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			parent := di.New()
+			ctx := parent.Child().Add(text("original"))
+			got := ""
+			target := func(r io.Reader) {
+				b, _ := io.ReadAll(r)
+				got = string(b)
+			}
+
+			ctx.Inject(target)
+			if got != "original" {
+				t.Errorf("expected %v got %v", "original", got)
+			}
+
+			// a compiled function sees changes too
+			fn, _ := ctx.Compile(target)
+			test.change(ctx, parent)
+			ctx.Inject(target)
+			if got != "changed" {
+				t.Errorf("expected %v got %v", "changed", got)
+			}
+			got = ""
+			if err := fn(); err != nil {
+				t.Errorf("expected %v got %v", nil, err)
+			}
+			if got != "changed" {
+				t.Errorf("expected %v got %v", "changed", got)
+			}
+		})
+	}
+
+	t.Run("errors are reported after a change", func(t *testing.T) {
+		ctx := di.New().Add(&bytes.Buffer{})
+		target := func(io.Reader) {}
+		fn, _ := ctx.Compile(target)
+		ctx.Add(strings.NewReader(""))
+
+		if err := ctx.Inject(target); !errors.Is(err, di.ErrAmbiguous) {
+			t.Errorf("expected %v got %v", di.ErrAmbiguous, err)
+		}
+		if err := fn(); !errors.Is(err, di.ErrAmbiguous) {
+			t.Errorf("expected %v got %v", di.ErrAmbiguous, err)
+		}
+	})
+}
+
+func BenchmarkCompile(b *testing.B) {
+	ctx := di.New().Add(&strings.Builder{}, strings.NewReader(""))
+	target := func(w io.Writer, r io.Reader) {}
+
+	b.Run("Inject", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			ctx.Inject(target)
+		}
+	})
+	b.Run("Compile", func(b *testing.B) {
+		fn, _ := ctx.Compile(target)
+		for i := 0; i < b.N; i++ {
+			fn()
+		}
+	})
+}
@@ -0,0 +1,39 @@
+package di
+
+// If limits a registration made with Provide or AddAs to when cond holds. cond is called with the Context when the
+// registration is made; if it returns false, Provide or AddAs does nothing. This allows auto-configuration, where a
+// module only registers what the application hasn't, or what its environment calls for:
+//
+//	ctx.Provide(newMemoryCache, di.If(func(ctx *di.Context) bool { return !di.Has[Cache](ctx) }))
+//	ctx.Provide(newTracer, di.If(func(*di.Context) bool { return os.Getenv("TRACING") != "" }))
+//
+// Since cond is called at registration, it only sees what was registered before it. In a module, that includes what the
+// Context the module is used in has, and what the modules used before it registered. Given more than once, every
+// condition must hold.
+func If(cond func(ctx *Context) bool) RegisterOption {
+	return func(e *entry) {
+		e.conditions = append(e.conditions, cond)
+	}
+}
+
+// AddIf is like Add, but only registers deps if cond holds when it is called. See If.
+func (ctx *Context) AddIf(cond func(ctx *Context) bool, deps ...interface{}) *Context {
+	if cond != nil && !cond(ctx) {
+		return ctx
+	}
+	return ctx.Add(deps...)
+}
+
+// enabled reports whether a registration should be made, given its profiles
+// and conditions. Conditions may use the Context, so it mustn't be locked.
+func (ctx *Context) enabled(e *entry) bool {
+	if !ctx.active(e.profiles) {
+		return false
+	}
+	for _, cond := range e.conditions {
+		if cond != nil && !cond(ctx) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,95 @@
+package di_test
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/mcvoid/di"
+)
+
+func TestIf(t *testing.T) {
+	always := func(*di.Context) bool { return true }
+	never := func(*di.Context) bool { return false }
+
+	t.Run("registration is made when the condition holds", func(t *testing.T) {
+		ctx := di.New()
+		ctx.Provide(func() *bytes.Buffer { return &bytes.Buffer{} }, di.If(always))
+		di.AddAs[io.Reader](ctx, os.Stdin, di.If(always))
+		if !di.Has[*bytes.Buffer](ctx) || !di.Has[io.Reader](ctx) {
+			t.Errorf("expected registrations to be made got %v", ctx.Registrations())
+		}
+	})
+
+	t.Run("registration is skipped when a condition fails", func(t *testing.T) {
+		ctx := di.New()
+		if err := ctx.Provide(func() *bytes.Buffer { return &bytes.Buffer{} }, di.If(always), di.If(never)); err != nil {
+			t.Errorf("expected %v got %v", nil, err)
+		}
+		if err := di.AddAs[io.Reader](ctx, os.Stdin, di.If(never)); err != nil {
+			t.Errorf("expected %v got %v", nil, err)
+		}
+		if len(ctx.Registrations()) != 0 {
+			t.Errorf("expected nothing registered got %v", ctx.Registrations())
+		}
+	})
+
+	t.Run("conditions can check the context", func(t *testing.T) {
+		missing := func(ctx *di.Context) bool { return !di.Has[io.Writer](ctx) }
+		ctx := di.New().Add(os.Stdout)
+		ctx.Provide(func() *strings.Builder { return &strings.Builder{} }, di.If(missing))
+		if di.Has[*strings.Builder](ctx) {
+			t.Errorf("expected %v to not be registered", "*strings.Builder")
+		}
+
+		ctx = di.New()
+		ctx.Provide(func() *strings.Builder { return &strings.Builder{} }, di.If(missing))
+		if !di.Has[*strings.Builder](ctx) {
+			t.Errorf("expected %v to be registered", "*strings.Builder")
+		}
+	})
+
+	t.Run("conditions in modules see the context they're used in", func(t *testing.T) {
+		defaults := di.Module{Name: "defaults", Register: func(ctx *di.Context) error {
+			return ctx.Provide(func() io.Writer { return &strings.Builder{} }, di.If(func(ctx *di.Context) bool { return !di.Has[io.Writer](ctx) }))
+		}}
+
+		ctx := di.New().Add(os.Stdout)
+		if err := ctx.Use(defaults); err != nil {
+			t.Errorf("expected %v got %v", nil, err)
+		}
+		if w, _ := di.Resolve[io.Writer](ctx); w != os.Stdout {
+			t.Errorf("expected %v got %v", os.Stdout, w)
+		}
+
+		app := di.Module{Name: "app", Register: func(ctx *di.Context) error {
+			ctx.Add(os.Stderr)
+			return nil
+		}}
+		ctx = di.New()
+		if err := ctx.Use(app, defaults); err != nil {
+			t.Errorf("expected %v got %v", nil, err)
+		}
+		if w, _ := di.Resolve[io.Writer](ctx); w != os.Stderr {
+			t.Errorf("expected %v got %v", os.Stderr, w)
+		}
+	})
+}
+
+func TestAddIf(t *testing.T) {
+	t.Run("adds when the condition holds", func(t *testing.T) {
+		ctx := di.New().AddIf(func(*di.Context) bool { return true }, os.Stdout)
+		if !di.Has[*os.File](ctx) {
+			t.Errorf("expected %v to be registered", "*os.File")
+		}
+	})
+
+	t.Run("does nothing when the condition fails", func(t *testing.T) {
+		ctx := di.New().AddIf(func(*di.Context) bool { return false }, os.Stdout)
+		if di.Has[*os.File](ctx) {
+			t.Errorf("expected %v to not be registered", "*os.File")
+		}
+	})
+}
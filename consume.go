@@ -0,0 +1,116 @@
+package di
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"reflect"
+)
+
+// ConsumerSource is a queue of messages which Context.Consume hands to an injected handler. Adapters for message brokers
+// such as Kafka and NATS can implement it; ChanSource adapts a channel.
+type ConsumerSource interface {
+	// Receive waits for the next message. It returns io.EOF once there are no more messages, and must return once
+	// stdctx is canceled.
+	Receive(stdctx context.Context) (msg interface{}, err error)
+	// Ack is called once msg has been handled, with the handler's error, if any, so that the message can be
+	// acknowledged, or arranged to be delivered again.
+	Ack(stdctx context.Context, msg interface{}, err error) error
+}
+
+// ChanSource is a ConsumerSource which receives messages from a channel until it's closed.
+type ChanSource[T any] <-chan T
+
+// Receive returns the next message sent on the channel, or io.EOF once it's closed.
+func (c ChanSource[T]) Receive(stdctx context.Context) (interface{}, error) {
+	select {
+	case msg, ok := <-c:
+		if !ok {
+			return nil, io.EOF
+		}
+		return msg, nil
+	case <-stdctx.Done():
+		return nil, stdctx.Err()
+	}
+}
+
+// Ack does nothing, since a channel can't deliver a message again.
+func (c ChanSource[T]) Ack(context.Context, interface{}, error) error {
+	return nil
+}
+
+// Consume hands each message received from source to handler, a function taking the message as its first parameter,
+// with its other parameters injected from the Context as InjectContext does, giving queue workers the same injection as
+// HTTP handlers:
+//
+//	ctx.Consume(di.ChanSource[Order](orders), func(o Order, stdctx context.Context, db *sql.DB) error {
+//		return save(stdctx, db, o)
+//	})
+//
+// Messages are handled one at a time, in a goroutine started with Go. Once each has been handled, it's passed to the
+// source's Ack method with the handler's error, or the reason the handler couldn't be injected, which is also logged.
+// The goroutine returns once Receive returns io.EOF or any other error, which Wait then returns, or once the consumer
+// is stopped, by calling the returned function or by Stop. Stopping cancels the context.Context given to Receive and to
+// handler, and waits for the message in progress to be handled. A message received as the consumer is stopped isn't
+// handled, but is passed to Ack with the context's error, so the source can deliver it again.
+//
+// If handler isn't a function taking at least one parameter, an error wrapping ErrConsumer is returned.
+func (ctx *Context) Consume(source ConsumerSource, handler interface{}) (stop func(), err error) {
+	if handler == nil {
+		return nil, ErrNilInjectee
+	}
+	v := reflect.ValueOf(handler)
+	if v.Kind() != reflect.Func || v.Type().NumIn() < 1 || v.Type().IsVariadic() && v.Type().NumIn() == 1 {
+		return nil, fmt.Errorf("%v %w", v.Type(), ErrConsumer)
+	}
+	s := newSubscriber(v)
+
+	return ctx.background(func(stdctx context.Context) error {
+		for {
+			msg, err := source.Receive(stdctx)
+			// a message received as the consumer was stopped is given back,
+			// rather than lost until the source delivers it again
+			if err == nil && stdctx.Err() != nil {
+				ctx.ack(source, stdctx, v, msg, stdctx.Err())
+				return nil
+			}
+			if errors.Is(err, io.EOF) || stdctx.Err() != nil {
+				return nil
+			}
+			if err != nil {
+				return fmt.Errorf("receiving message: %w", err)
+			}
+
+			err = ctx.handle(stdctx, s, msg)
+			if err != nil {
+				logDebug(ctx, "di: handling message failed", slog.String("handler", v.Type().String()), slog.String("error", err.Error()))
+			}
+			ctx.ack(source, stdctx, v, msg, err)
+		}
+	}), nil
+}
+
+// ack passes a message to the source's Ack method, logging any failure. The
+// consumer may have been stopped, but the acknowledgement still has to be
+// made, so it isn't given a canceled context.
+func (ctx *Context) ack(source ConsumerSource, stdctx context.Context, handler reflect.Value, msg interface{}, err error) {
+	if err := source.Ack(context.WithoutCancel(stdctx), msg, err); err != nil {
+		logDebug(ctx, "di: acknowledging message failed", slog.String("handler", handler.Type().String()), slog.String("error", err.Error()))
+	}
+}
+
+// handle injects a message handler with a message.
+func (ctx *Context) handle(stdctx context.Context, s *subscriber, msg interface{}) error {
+	v := reflect.ValueOf(msg)
+	if !v.IsValid() {
+		v = reflect.Zero(s.event)
+	}
+	if !v.Type().AssignableTo(s.event) {
+		return fmt.Errorf("message of type %v can't be handled by %v", v.Type(), s.fn.Type())
+	}
+	r := newResolution()
+	r.stdctx, r.passContext, r.progress = stdctx, true, &progress{}
+	return s.call(ctx, r, v)
+}
@@ -0,0 +1,132 @@
+package di_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/mcvoid/di"
+)
+
+// a ConsumerSource which records acknowledgements
+type acks struct {
+	di.ChanSource[string]
+	lock sync.Mutex
+	errs []error
+	err  error
+}
+
+func (a *acks) Receive(stdctx context.Context) (interface{}, error) {
+	if a.err != nil {
+		return nil, a.err
+	}
+	return a.ChanSource.Receive(stdctx)
+}
+
+func (a *acks) Ack(_ context.Context, _ interface{}, err error) error {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	a.errs = append(a.errs, err)
+	return nil
+}
+
+// a ConsumerSource whose message arrives just as the consumer is stopped
+type stopping struct {
+	acks
+	received chan struct{}
+}
+
+func (s *stopping) Receive(stdctx context.Context) (interface{}, error) {
+	close(s.received)
+	<-stdctx.Done()
+	return "late", nil
+}
+
+func TestConsume(t *testing.T) {
+	t.Run("messages are handled with injected dependencies", func(t *testing.T) {
+		ctx := di.New().Add(logConfig{Level: "info"})
+		msgs := make(chan string, 2)
+		msgs <- "a"
+		msgs <- "b"
+		close(msgs)
+
+		got := []string{}
+		_, err := ctx.Consume(di.ChanSource[string](msgs), func(msg string, cfg logConfig) {
+			got = append(got, cfg.Level+" "+msg)
+		})
+		if err != nil {
+			t.Fatalf("expected %v got %v", nil, err)
+		}
+		if err := ctx.Wait(); err != nil {
+			t.Errorf("expected %v got %v", nil, err)
+		}
+		if strings.Join(got, ",") != "info a,info b" {
+			t.Errorf("expected %v got %v", "info a,info b", got)
+		}
+	})
+
+	t.Run("handler errors are acknowledged", func(t *testing.T) {
+		ctx := di.New()
+		msgs := make(chan string, 2)
+		msgs <- "ok"
+		msgs <- "bad"
+		close(msgs)
+		expected := errors.New("bad message")
+		source := &acks{ChanSource: msgs}
+		ctx.Consume(source, func(msg string) error {
+			if msg == "bad" {
+				return expected
+			}
+			return nil
+		})
+		ctx.Wait()
+		if len(source.errs) != 2 || source.errs[0] != nil || !errors.Is(source.errs[1], expected) {
+			t.Errorf("expected %v got %v", []error{nil, expected}, source.errs)
+		}
+	})
+
+	t.Run("receive errors are returned by wait", func(t *testing.T) {
+		ctx := di.New()
+		expected := errors.New("connection lost")
+		ctx.Consume(&acks{err: expected}, func(string) {})
+		if err := ctx.Wait(); !errors.Is(err, expected) {
+			t.Errorf("expected %v got %v", expected, err)
+		}
+	})
+
+	t.Run("stopping the context stops its consumers", func(t *testing.T) {
+		ctx := di.New()
+		ctx.Consume(di.ChanSource[string](make(chan string)), func(string) {})
+		if err := ctx.Stop(context.Background()); err != nil {
+			t.Errorf("expected %v got %v", nil, err)
+		}
+		if err := ctx.Wait(); err != nil {
+			t.Errorf("expected %v got %v", nil, err)
+		}
+	})
+
+	t.Run("messages received while stopping are given back", func(t *testing.T) {
+		ctx := di.New()
+		received := make(chan struct{})
+		source := &stopping{received: received}
+		stop, _ := ctx.Consume(source, func(msg string) {
+			t.Errorf("expected handler to not be called")
+		})
+		<-received
+		stop()
+		if len(source.errs) != 1 || !errors.Is(source.errs[0], context.Canceled) {
+			t.Errorf("expected %v got %v", []error{context.Canceled}, source.errs)
+		}
+	})
+
+	t.Run("handlers must take a message", func(t *testing.T) {
+		source := di.ChanSource[string](nil)
+		for _, fn := range []interface{}{func() {}, func(...string) {}, "handler"} {
+			if _, err := di.New().Consume(source, fn); !errors.Is(err, di.ErrConsumer) {
+				t.Errorf("expected %v got %v", di.ErrConsumer, err)
+			}
+		}
+	})
+}
@@ -0,0 +1,66 @@
+package di
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sync"
+)
+
+var contextType = reflect.TypeOf((*context.Context)(nil)).Elem()
+
+// InjectContext is like Inject, but takes part in the usual cancellation of a context.Context. Any parameter of type
+// context.Context, whether of the target or of a provider called for it, is given stdctx instead of being resolved from
+// the Context:
+//
+//	err := ctx.InjectContext(req.Context(), func(stdctx context.Context, db *sql.DB) error {
+//		return db.PingContext(stdctx)
+//	})
+//
+// While dependencies are being resolved, stdctx's deadline and cancellation are respected: once stdctx is done, no more
+// providers are called, and a provider which is still running is given up on and left to finish in the background. The
+// injection then fails with a CanceledError listing the providers which were called, which wraps stdctx.Err(), and the
+// target isn't called. Providers which take a context.Context can stop early, since theirs is canceled too.
+func (ctx *Context) InjectContext(stdctx context.Context, target interface{}) error {
+	fn, err := injectable(ctx, target)
+	if err != nil {
+		return err
+	}
+	r := newResolution()
+	r.stdctx, r.passContext, r.progress = stdctx, true, &progress{}
+	if _, err = injectFunc(ctx, r, target, fn, ctx.bindMethodName()); err != nil {
+		return err
+	}
+	if reflect.ValueOf(target).Kind() != reflect.Func {
+		return afterInject(target)
+	}
+	return nil
+}
+
+// progress records the providers called during an injection, so that if it
+// is canceled, the error can say how far it got. Providers may be called from
+// several goroutines at once.
+type progress struct {
+	lock  sync.Mutex
+	built []reflect.Type
+}
+
+// add records that the provider for t returned.
+func (p *progress) add(t reflect.Type) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.built = append(p.built, t)
+}
+
+// canceled returns err as a CanceledError if it was caused by stdctx being
+// done, or unchanged otherwise.
+func (p *progress) canceled(stdctx context.Context, err error) error {
+	if err == nil || stdctx.Err() == nil || !errors.Is(err, stdctx.Err()) {
+		return err
+	}
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	return &CanceledError{Built: append([]reflect.Type{}, p.built...), Err: err}
+}
@@ -0,0 +1,206 @@
+package di_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mcvoid/di"
+)
+
+type contextKey struct{}
+
+// a dependency which cancels a context once it is built
+type canceler struct {
+	cancel context.CancelFunc
+}
+
+func (c *canceler) AfterInject() error {
+	c.cancel()
+	return nil
+}
+
+func TestInjectContext(t *testing.T) {
+	t.Run("target gets the context", func(t *testing.T) {
+		stdctx := context.WithValue(context.Background(), contextKey{}, "request")
+		ctx := di.New()
+
+		wasCalled := false
+		err := ctx.InjectContext(stdctx, func(got context.Context) {
+			wasCalled = true
+			if got.Value(contextKey{}) != "request" {
+				t.Errorf("expected %v got %v", "request", got.Value(contextKey{}))
+			}
+		})
+		if err != nil {
+			t.Errorf("expected %v got %v", nil, err)
+		}
+		if !wasCalled {
+			t.Errorf("expected %v got %v", true, wasCalled)
+		}
+	})
+
+	t.Run("providers get the context", func(t *testing.T) {
+		stdctx := context.WithValue(context.Background(), contextKey{}, "request")
+		ctx := di.New()
+		ctx.Provide(func(got context.Context) *bytes.Buffer {
+			return bytes.NewBufferString(got.Value(contextKey{}).(string))
+		}, di.Transient())
+
+		err := ctx.InjectContext(stdctx, func(b *bytes.Buffer) {
+			if b.String() != "request" {
+				t.Errorf("expected %v got %v", "request", b.String())
+			}
+		})
+		if err != nil {
+			t.Errorf("expected %v got %v", nil, err)
+		}
+	})
+
+	t.Run("the context takes priority over registrations", func(t *testing.T) {
+		registered := context.WithValue(context.Background(), contextKey{}, "registered")
+		stdctx := context.WithValue(context.Background(), contextKey{}, "request")
+		ctx := di.New().Add(registered)
+
+		ctx.InjectContext(stdctx, func(got context.Context) {
+			if got.Value(contextKey{}) != "request" {
+				t.Errorf("expected %v got %v", "request", got.Value(contextKey{}))
+			}
+		})
+		ctx.Inject(func(got context.Context) {
+			if got.Value(contextKey{}) != "registered" {
+				t.Errorf("expected %v got %v", "registered", got.Value(contextKey{}))
+			}
+		})
+	})
+
+	t.Run("canceled context stops providers", func(t *testing.T) {
+		stdctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		ctx := di.New()
+		ctx.Provide(func() *bytes.Buffer {
+			t.Errorf("expected provider to not be called")
+			return &bytes.Buffer{}
+		})
+
+		err := ctx.InjectContext(stdctx, func(*bytes.Buffer) {
+			t.Errorf("expected func to not be called")
+		})
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("expected %v got %v", context.Canceled, err)
+		}
+	})
+
+	t.Run("canceled context stops the target", func(t *testing.T) {
+		stdctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		ctx := di.New()
+
+		err := ctx.InjectContext(stdctx, func() {
+			t.Errorf("expected func to not be called")
+		})
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("expected %v got %v", context.Canceled, err)
+		}
+	})
+
+	t.Run("running provider is given up on at the deadline", func(t *testing.T) {
+		release := make(chan struct{})
+		defer close(release)
+		stdctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		ctx := di.New()
+		ctx.Provide(func() *bytes.Buffer {
+			<-release
+			return &bytes.Buffer{}
+		})
+
+		err := ctx.InjectContext(stdctx, func(*bytes.Buffer) {
+			t.Errorf("expected func to not be called")
+		})
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("expected %v got %v", context.DeadlineExceeded, err)
+		}
+	})
+
+	t.Run("provider panics reach the caller", func(t *testing.T) {
+		stdctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		ctx := di.New()
+		ctx.Provide(func() *bytes.Buffer { panic("boom") })
+
+		defer func() {
+			if r := recover(); r != "boom" {
+				t.Errorf("expected %v got %v", "boom", r)
+			}
+		}()
+		ctx.InjectContext(stdctx, func(*bytes.Buffer) {})
+		t.Errorf("expected a panic")
+	})
+
+	t.Run("bind methods are called", func(t *testing.T) {
+		ctx := di.New().Add(os.Stdin)
+		b := testBinder{t: t}
+		if err := ctx.InjectContext(context.Background(), &b); err != nil {
+			t.Errorf("expected %v got %v", nil, err)
+		}
+		if !b.wasCalled {
+			t.Errorf("expected %v got %v", true, b.wasCalled)
+		}
+	})
+
+	t.Run("cancellation says which providers were built", func(t *testing.T) {
+		stdctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		ctx := di.New()
+		ctx.Provide(func() *bytes.Buffer { return &bytes.Buffer{} })
+		ctx.Provide(func(*bytes.Buffer) *canceler { return &canceler{cancel} })
+		ctx.Provide(func(*canceler) *strings.Reader {
+			t.Errorf("expected provider to not be called")
+			return strings.NewReader("")
+		})
+
+		err := ctx.InjectContext(stdctx, func(*strings.Reader) {
+			t.Errorf("expected func to not be called")
+		})
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("expected %v got %v", context.Canceled, err)
+		}
+		var canceled *di.CanceledError
+		if !errors.As(err, &canceled) {
+			t.Fatalf("expected %T got %v", canceled, err)
+		}
+		expected := []reflect.Type{reflect.TypeOf(&bytes.Buffer{}), reflect.TypeOf(&canceler{})}
+		if !reflect.DeepEqual(canceled.Built, expected) {
+			t.Errorf("expected %v got %v", expected, canceled.Built)
+		}
+	})
+
+	t.Run("running providers see the cancellation", func(t *testing.T) {
+		stdctx, cancel := context.WithCancel(context.Background())
+		stopped := make(chan error, 1)
+		release := make(chan struct{})
+		defer close(release)
+		ctx := di.New()
+		ctx.Provide(func(stdctx context.Context) *bytes.Buffer {
+			cancel()
+			<-stdctx.Done()
+			stopped <- stdctx.Err()
+			<-release
+			return &bytes.Buffer{}
+		})
+
+		err := ctx.InjectContext(stdctx, func(*bytes.Buffer) {})
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("expected %v got %v", context.Canceled, err)
+		}
+		if err := <-stopped; err != context.Canceled {
+			t.Errorf("expected %v got %v", context.Canceled, err)
+		}
+	})
+}
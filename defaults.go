@@ -0,0 +1,47 @@
+package di
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+// SetDefault registers fallback dependencies, which are only used when no dependency registered with Add or Provide
+// matches. Defaults are matched by the same rules as other dependencies, but only among themselves, and before the
+// WithOnMissing callback. This lets a library ship safe defaults, such as a no-op logger, which an application can
+// override just by adding its own.
+//
+// Defaults in a parent Context are used by its children. As with Add, nil values are ignored, and failures are reported
+// by Err.
+func (ctx *Context) SetDefault(deps ...interface{}) *Context {
+	ctx.lock.Lock()
+	defer ctx.commit()
+
+	if ctx.frozen {
+		ctx.err = errors.Join(ctx.err, fmt.Errorf("%w: cannot set defaults", ErrFrozen))
+		return ctx
+	}
+	if ctx.defaults == nil {
+		ctx.defaults = &Context{}
+	}
+	ctx.defaults.Add(deps...)
+	ctx.changed()
+	return ctx
+}
+
+// resolveDefault finds a default for the type in ctx or its ancestors,
+// returning an invalid Value if there is none.
+func resolveDefault(ctx *Context, r *resolution, t reflect.Type) (reflect.Value, error) {
+	for c := ctx; c != nil; c = c.parent {
+		defaults := c.load().defaults
+		if defaults == nil {
+			continue
+		}
+
+		val, err := resolveNamed(defaults, r, t, "")
+		if err != nil || val.IsValid() {
+			return val, err
+		}
+	}
+	return reflect.Value{}, nil
+}
@@ -0,0 +1,74 @@
+package di_test
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/mcvoid/di"
+)
+
+func TestSetDefault(t *testing.T) {
+	t.Run("default is used when nothing matches", func(t *testing.T) {
+		ctx := di.New().SetDefault(noopStringer{})
+
+		s, err := di.Resolve[fmt.Stringer](ctx)
+		if _, ok := s.(noopStringer); !ok {
+			t.Errorf("expected %T got %T", noopStringer{}, s)
+		}
+		if err != nil {
+			t.Errorf("expected %v got %v", nil, err)
+		}
+	})
+
+	t.Run("real dependencies win", func(t *testing.T) {
+		b := bytes.NewBufferString("real")
+		ctx := di.New().SetDefault(noopStringer{}).Add(b)
+
+		s, _ := di.Resolve[fmt.Stringer](ctx)
+		if s != b {
+			t.Errorf("expected %v got %v", b, s)
+		}
+	})
+
+	t.Run("defaults don't count as ambiguous", func(t *testing.T) {
+		ctx := di.New().SetDefault(noopStringer{}).Add(&bytes.Buffer{})
+
+		_, err := di.Resolve[fmt.Stringer](ctx)
+		if err != nil {
+			t.Errorf("expected %v got %v", nil, err)
+		}
+	})
+
+	t.Run("satisfies strict mode", func(t *testing.T) {
+		ctx := di.New(di.WithStrict()).SetDefault(os.Stdin)
+
+		err := ctx.Inject(func(f *os.File) {
+			if f != os.Stdin {
+				t.Errorf("expected %v got %v", os.Stdin, f)
+			}
+		})
+		if err != nil {
+			t.Errorf("expected %v got %v", nil, err)
+		}
+	})
+
+	t.Run("children use parent defaults", func(t *testing.T) {
+		ctx := di.New().SetDefault(noopStringer{}).Child()
+
+		s, _ := di.Resolve[fmt.Stringer](ctx)
+		if _, ok := s.(noopStringer); !ok {
+			t.Errorf("expected %T got %T", noopStringer{}, s)
+		}
+	})
+
+	t.Run("frozen", func(t *testing.T) {
+		ctx := di.New().Freeze().SetDefault(noopStringer{})
+
+		if !errors.Is(ctx.Err(), di.ErrFrozen) {
+			t.Errorf("expected %v got %v", di.ErrFrozen, ctx.Err())
+		}
+	})
+}
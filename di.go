@@ -6,10 +6,16 @@
 package di
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"log/slog"
 	"reflect"
+	"runtime"
+	"runtime/debug"
+	"sort"
 	"sync"
+	"sync/atomic"
 )
 
 const methodName = "Bind"
@@ -18,133 +24,1108 @@ var (
 	// Returned when the target is nil
 	ErrNilInjectee = errors.New("cannot inject into nil value")
 	// Returned when the target is not injectable (it is not a function and does not have a bind method)
-	ErrNotInjectable = errors.New("is not a function and does not have a 'Bind' method")
+	ErrNotInjectable = errors.New("is not a function and does not have a bind method")
+	// Returned, wrapped in a MissingError, when a dependency can't be found in a strict Context
+	ErrMissing = errors.New("no dependency matches")
+	// Returned when the target's bind method has a pointer receiver, but the target was passed by value
+	ErrPointerReceiver = errors.New("has a pointer receiver, so the target must be passed as a pointer")
 	// Returned when it is ambiguous which dependency should be injected (target is an interface which more than one dependency implements)
 	ErrAmbiguous = errors.New("more than one dependency implements the interface")
+	// Returned when a provider passed to Provide is not a function returning a value and optionally an error
+	ErrInvalidProvider = errors.New("is not a function returning a value and optionally an error")
+	// Returned when replacing a dependency whose type is not registered
+	ErrNotRegistered = errors.New("no dependency of that type is registered")
+	// Returned when the function passed to Call does not return a value of the requested type
+	ErrResultType = errors.New("does not return a value of the requested type")
+	// Returned when merging contexts which both register a dependency of the same type
+	ErrConflict = errors.New("both contexts register a dependency of the same type")
+	// Returned when changing the dependencies of a Context after Freeze has been called
+	ErrFrozen = errors.New("context is frozen")
+	// Returned when overwriting, replacing, or removing a dependency registered with the Final option
+	ErrFinal = errors.New("dependency is final")
+	// Returned when registering a dependency whose type is already registered, in a Context created with WithNoOverwrite
+	ErrDuplicate = errors.New("a dependency of that type is already registered")
+	// Returned when adding a nil dependency, in a Context created with WithNilPolicy(NilError)
+	ErrNilDependency = errors.New("dependency is nil")
+	// Returned when an injected function, Bind method, or provider panics, in a Context created with WithRecover
+	ErrPanic = errors.New("panicked")
+	// Returned when providers depend on each other in a cycle, so none of them can be called
+	ErrCycle = errors.New("dependency cycle")
+	// Returned when a provider registered with the Timeout option takes longer than its timeout
+	ErrTimeout = errors.New("timed out")
+	// Returned, wrapped in a BreakerError, when a provider registered with the Breaker option has failed too many times in a row
+	ErrBreakerOpen = errors.New("circuit breaker is open")
+	// Returned when a dependency registered with the WhenFlag option is needed while its flag is off, and nothing else is registered for it
+	ErrFlagOff = errors.New("feature flag is off")
+	// Returned when rolling back to a checkpoint taken from a different Context
+	ErrInvalidCheckpoint = errors.New("checkpoint was not taken from this context")
+	// Returned when the target of Fill is not a non-nil pointer to a struct
+	ErrNotStruct = errors.New("is not a pointer to a struct")
+	// Returned when a struct field has a 'di' tag which can't be parsed
+	ErrInvalidTag = errors.New("invalid struct tag")
+	// Returned when the function passed to HandlerFunc doesn't take an http.ResponseWriter and an *http.Request first
+	ErrHandlerFunc = errors.New("is not a function taking an http.ResponseWriter and an *http.Request first")
+	// Returned when running a command which isn't one of the Commands
+	ErrUnknownCommand = errors.New("unknown command")
+	// Returned when a job is scheduled to run at an interval which isn't positive
+	ErrInterval = errors.New("interval must be positive")
+	// Returned, by Err, when WithExpvar is given a name which package expvar already publishes as something other than a map
+	ErrExpvar = errors.New("expvar name is already published as another kind of var")
+	// Returned when the function passed to Subscribe doesn't take an event as its first parameter
+	ErrSubscriber = errors.New("is not a function taking an event first")
+	// Returned when the function passed to Consume doesn't take a message as its first parameter
+	ErrConsumer = errors.New("is not a function taking a message first")
 )
 
 // Context is a set of dependencies which can be injected into a bindable object.
+//
+// A Context is safe for concurrent use. Changes to its dependencies are made one at a time, but injections read a
+// snapshot of them without locking, so they never wait for one another or for a change in progress.
 type Context struct {
-	lock sync.Mutex
-	deps map[reflect.Type]reflect.Value
+	// guards changes to the registrations and the other fields below; only
+	// held for reading by methods which don't change them
+	lock   sync.RWMutex
+	deps   map[key]*entry
+	parent *Context
+	frozen bool
+	err    error
+
+	onStart  []Hook
+	onStop   []Hook
+	onReload []func([]reflect.Type)
+	// the functions registered with Watch for each type
+	watchers map[reflect.Type][]*watcher
+	// the targets to inject again when their dependencies are replaced, and
+	// those waiting to be
+	bindings []*binding
+	stale    []*binding
+	// the goroutines started by Go which Wait hasn't waited for, and those
+	// which Stop must wait for
+	running []*Handle
+	live    []*Handle
+	// the context.Context given to goroutines started by Go until Stop
+	// cancels it, and whether Stop has been hooked to do so
+	goctx    context.Context
+	gocancel context.CancelFunc
+	goHooked bool
+	// the functions registered with Subscribe
+	subscribers []*subscriber
+
+	// fallbacks registered with SetDefault
+	defaults *Context
+	// the preferred implementation of each interface, set by Prefer
+	prefs map[reflect.Type]reflect.Type
+
+	// the snapshot of the registrations which injections read
+	current atomic.Pointer[state]
+	// whether the registrations have changed since the last snapshot
+	dirty bool
+	// the keys registered since the last snapshot, to update its index
+	added []key
+	// whether the registrations were replaced, so the index must be rebuilt
+	reindex bool
+	// calls to user code waiting for the lock to be released
+	pending []func()
+
+	// the module registering into the Context, for a Context made by Use
+	module string
+	// the names of the modules used in the Context
+	modules []string
+
+	options
 }
 
-// New creates a new Context.
-func New() *Context {
-	return &Context{
-		deps: map[reflect.Type]reflect.Value{},
+// key identifies a registration by its type and, for named dependencies, its name.
+type key struct {
+	typ  reflect.Type
+	name string
+}
+
+// entry is a single registration in a Context: either a fixed value or a
+// provider which constructs the value on demand.
+type entry struct {
+	name string
+	val  reflect.Value
+	prov *provider
+
+	// the index of the field this entry is for, when the provider returns
+	// an Out struct
+	field []int
+
+	// orders entries by when they were first registered
+	seq uint64
+
+	// keeps AddAs from registering the entry under its concrete type
+	interfaceOnly bool
+	// keeps the registration from being overwritten, replaced, or removed
+	final bool
+	// the module which made the registration, if any
+	module string
+	// the profiles the registration is limited to, if any
+	profiles []string
+	// must all hold for the registration to be made
+	conditions []func(*Context) bool
+	// the feature flag the registration is behind, if any, and the
+	// registration used while it is off
+	flag string
+	off  *entry
+}
+
+// seq numbers registrations in the order they were made, across all contexts.
+var seq atomic.Uint64
+
+// New creates a new Context, configured by the given options.
+func New(opts ...Option) *Context {
+	ctx := &Context{
+		deps: map[key]*entry{},
 	}
+	for _, opt := range opts {
+		opt(ctx)
+	}
+	return ctx
 }
 
-// Add registers a new dependency to the context. If a nil value is passed, that dependency is ignored and no action is taken.
+// Add registers a new dependency to the context. If a nil value is passed, that dependency is skipped and the rest are still
+// added, unless WithNilPolicy says otherwise.
 // Dependencies are indexed by type. If two dependencies of the same type are added, the second one overwrites the first.
+// This includes providers registered with Provide. Use WithOverwriteHook to be notified when this happens, or Replace to
+// make overwriting explicit.
+//
+// Since Add returns the Context for chaining, any registration which fails, such as after Freeze, is reported by Err instead.
 func (ctx *Context) Add(deps ...interface{}) *Context {
 	// Don't change the list while injecting
 	// or while adding in another goroutine
 	ctx.lock.Lock()
-	defer ctx.lock.Unlock()
+	defer ctx.commit()
 
-	if ctx.deps == nil {
-		ctx.deps = map[reflect.Type]reflect.Value{}
+	for i, dep := range deps {
+		if dep == nil {
+			nilDependency(ctx, i)
+			continue
+		}
+
+		v := reflect.ValueOf(dep)
+		t := v.Type()
+		if err := register(ctx, key{typ: t}, &entry{val: v}); err != nil {
+			ctx.err = errors.Join(ctx.err, err)
+		}
 	}
 
-	for _, dep := range deps {
+	return ctx
+}
 
-		// nil deps are a no-op
+// Replace overwrites the registered dependencies of the same types as the given values. Unlike Add, the types must already
+// be registered: if any of them isn't, an error wrapping ErrNotRegistered is returned and nothing is changed.
+// Replacing does not call the overwrite hook. Only the value is replaced: the registration keeps the options it was
+// made with, such as Profile and WhenFlag, and one made with AddAs is replaced under its interface type too.
+func (ctx *Context) Replace(deps ...interface{}) error {
+	ctx.lock.Lock()
+	defer ctx.commit()
+
+	if ctx.frozen {
+		return fmt.Errorf("%w: cannot replace dependencies", ErrFrozen)
+	}
+
+	for _, dep := range deps {
 		if dep == nil {
-			return ctx
+			return fmt.Errorf("%w: %v", ErrNotRegistered, dep)
+		}
+		e, ok := ctx.deps[key{typ: reflect.TypeOf(dep)}]
+		if !ok {
+			return fmt.Errorf("%w: %T", ErrNotRegistered, dep)
+		}
+		if e.final {
+			return fmt.Errorf("%w: cannot replace %T", ErrFinal, dep)
 		}
+	}
 
+	for _, dep := range deps {
 		v := reflect.ValueOf(dep)
-		t := v.Type()
-		ctx.deps[t] = v
+		old := ctx.deps[key{typ: v.Type()}]
+		// the registration keeps its options, only its value changes
+		e := *old
+		e.val, e.prov, e.field = v, nil, nil
+		// including under the interface it was registered as with AddAs
+		for k, other := range ctx.deps {
+			if other == old {
+				ctx.deps[k] = &e
+				ctx.replaced(k, old, &e)
+			}
+		}
+	}
+	ctx.changed()
+	return nil
+}
+
+// Clone returns a new Context with the same dependencies and options. Adding, replacing, or removing dependencies in the
+// clone does not affect the original, or vice versa. Providers are shared between them, so a singleton constructed
+// through either Context is seen by both.
+func (ctx *Context) Clone() *Context {
+	ctx.lock.RLock()
+	defer ctx.lock.RUnlock()
+
+	clone := &Context{
+		deps:    make(map[key]*entry, len(ctx.deps)),
+		parent:  ctx.parent,
+		options: ctx.options,
+	}
+	if ctx.defaults != nil {
+		clone.defaults = ctx.defaults.Clone()
+	}
+	if ctx.prefs != nil {
+		clone.prefs = make(map[reflect.Type]reflect.Type, len(ctx.prefs))
+		for iface, t := range ctx.prefs {
+			clone.prefs[iface] = t
+		}
+	}
+	for k, e := range ctx.deps {
+		clone.deps[k] = e
+	}
+	clone.modules = append([]string{}, ctx.modules...)
+	clone.publish()
+	return clone
+}
+
+// Child returns a new, empty Context which falls back to ctx for any dependency it can't resolve itself. Dependencies
+// added to the child shadow those in ctx without changing it, which makes a child suitable for overriding dependencies
+// for a single request on top of an application-wide Context. The child has the same options as ctx.
+//
+// A dependency resolved from ctx is resolved entirely within ctx, so providers registered in ctx never see dependencies
+// which were only added to the child.
+func (ctx *Context) Child() *Context {
+	ctx.lock.RLock()
+	defer ctx.lock.RUnlock()
+
+	return &Context{
+		deps:    map[key]*entry{},
+		parent:  ctx,
+		options: ctx.options,
+	}
+}
+
+// Merge adds all the dependencies registered directly in other to ctx, so that contexts built separately can be combined.
+// If both contexts register a dependency under the same type and name, an error wrapping ErrConflict is returned listing
+// every conflicting type, and nothing is merged. Registrations which both contexts share through Clone do not conflict.
+func (ctx *Context) Merge(other *Context) error {
+	if other == nil || other == ctx {
+		return nil
+	}
+
+	other.lock.RLock()
+	deps := make(map[key]*entry, len(other.deps))
+	for k, e := range other.deps {
+		deps[k] = e
+	}
+	other.lock.RUnlock()
+
+	ctx.lock.Lock()
+	defer ctx.commit()
+
+	if ctx.frozen {
+		return fmt.Errorf("%w: cannot merge dependencies", ErrFrozen)
+	}
+	conflicts := []reflect.Type{}
+	for k, e := range deps {
+		if existing, ok := ctx.deps[k]; ok && existing != e {
+			conflicts = append(conflicts, k.typ)
+		}
+	}
+	if len(conflicts) > 0 {
+		return fmt.Errorf("%w: %v", ErrConflict, conflicts)
+	}
+	// check everything first, so a merge is made in full or not at all
+	for k, e := range deps {
+		if err := admit(ctx, k, e); err != nil {
+			return err
+		}
+	}
+
+	for k, e := range deps {
+		register(ctx, k, e)
 	}
+	return nil
+}
+
+// Freeze makes the Context's dependencies immutable. After it is called, every attempt to add, provide, replace, or
+// remove a dependency fails with an error wrapping ErrFrozen. Injection is unaffected. Clones and children of a frozen
+// Context are not frozen.
+func (ctx *Context) Freeze() *Context {
+	ctx.lock.Lock()
+	defer ctx.lock.Unlock()
 
+	ctx.frozen = true
 	return ctx
 }
 
-// Inject injects the set of dependencies into a bindable object. Can be called on a function or any value with a method called Bind.
+// Frozen reports whether Freeze has been called.
+func (ctx *Context) Frozen() bool {
+	ctx.lock.RLock()
+	defer ctx.lock.RUnlock()
+
+	return ctx.frozen
+}
+
+// Err returns the errors from any calls to Add, AddNamed, or Remove which failed, joined together, or nil if there
+// were none. Those methods return the Context for chaining, so this is where their failures are reported.
+func (ctx *Context) Err() error {
+	ctx.lock.RLock()
+	defer ctx.lock.RUnlock()
+
+	return ctx.err
+}
+
+// nilDependency applies the nil policy to the nil dependency at position
+// index in a call to Add or AddNamed.
+// The caller must hold ctx.lock.
+func nilDependency(ctx *Context, index int) {
+	switch ctx.nilPolicy {
+	case NilError:
+		ctx.err = errors.Join(ctx.err, fmt.Errorf("%w: argument %d", ErrNilDependency, index))
+	case NilPanic:
+		panic(fmt.Errorf("%w: argument %d", ErrNilDependency, index))
+	}
+}
+
+// register stores a registration, reporting it to the overwrite hook if it
+// replaces an existing one, once the lock is released.
+// The caller must hold ctx.lock.
+func register(ctx *Context, k key, e *entry) error {
+	if err := admit(ctx, k, e); err != nil {
+		return err
+	}
+	if ctx.deps == nil {
+		ctx.deps = map[key]*entry{}
+	}
+	existing, ok := ctx.deps[k]
+	// registrations behind a feature flag are kept alongside the others
+	paired := ok && existing != e && (e.flag != "" || existing.flag != "")
+	if paired {
+		e = pair(existing, e)
+	}
+	if ok && !paired && ctx.onOverwrite != nil {
+		hook := ctx.onOverwrite
+		ctx.later(func() { hook(k.typ) })
+	}
+	if e.seq == 0 {
+		e.seq = seq.Add(1)
+		e.module = ctx.module
+	}
+	if !ok {
+		ctx.added = append(ctx.added, k)
+	}
+	ctx.deps[k] = e
+	ctx.replaced(k, existing, e)
+	ctx.changed()
+	ctx.later(func() {
+		logDebug(ctx, "di: registered", slog.String("type", k.typ.String()), slog.String("name", k.name), slog.Bool("provider", e.prov != nil))
+	})
+	return nil
+}
+
+// admit returns the error register would return for registering e under k,
+// without registering it, so that several registrations can be checked
+// before any is made.
+// The caller must hold ctx.lock.
+func admit(ctx *Context, k key, e *entry) error {
+	if ctx.frozen {
+		return fmt.Errorf("%w: cannot register %v", ErrFrozen, k.typ)
+	}
+	existing, ok := ctx.deps[k]
+	if !ok || existing == e {
+		return nil
+	}
+	if existing.final {
+		return fmt.Errorf("%w: cannot overwrite %v", ErrFinal, k.typ)
+	}
+	if ctx.noOverwrite && e.flag == "" && existing.flag == "" {
+		return fmt.Errorf("%w: %v", ErrDuplicate, k.typ)
+	}
+	return nil
+}
+
+// Remove unregisters the dependencies of the same types as the given values, whether they were registered with Add or
+// Provide. A dependency registered with AddAs is removed under both its types. Values whose type isn't registered, and
+// nil values, are ignored. Removing from a frozen Context is reported by Err.
+func (ctx *Context) Remove(deps ...interface{}) *Context {
+	ctx.lock.Lock()
+	defer ctx.commit()
+
+	if ctx.frozen {
+		ctx.err = errors.Join(ctx.err, fmt.Errorf("%w: cannot remove dependencies", ErrFrozen))
+		return ctx
+	}
+
+	for _, dep := range deps {
+		if dep == nil {
+			continue
+		}
+		k := key{typ: reflect.TypeOf(dep)}
+		if e, ok := ctx.deps[k]; ok && e.final {
+			ctx.err = errors.Join(ctx.err, fmt.Errorf("%w: cannot remove %v", ErrFinal, k.typ))
+			continue
+		}
+		if e, ok := ctx.deps[k]; ok {
+			unregister(ctx, e)
+		}
+	}
+
+	return ctx
+}
+
+// unregister removes a registration under every key it was made under,
+// such as both the interface and the concrete type for AddAs.
+// The caller must hold ctx.lock.
+func unregister(ctx *Context, e *entry) {
+	for k, other := range ctx.deps {
+		if other == e {
+			delete(ctx.deps, k)
+		}
+	}
+	ctx.changed()
+}
+
+// Inject injects the set of dependencies into a bindable object. Can be called on a function or any value with a method called Bind,
+// or the name given by WithBindMethod.
 // Returns nil if the binding was successful, nil otherwise.
 //
 // On a function: Calls the function, populating the arguments with values previously added to the Context. The function's return
-// value, if any, is discarded.
+// value, if any, is discarded, unless it is an error. Use Invoke to keep it.
 //
 // On an object with a Bind method: Calls the Bind method, populating the arguments with values previously added to the Context. The
 // function's return value, if any, is discarded.
 //
 // Dependencies are bound according to the following rules:
 //
-//   - If the parameter type is an exact match to a dependency added to the context, that value is used.
+//   - If the parameter type is an exact match to a dependency added to the context, that value is used. If the dependency
+//     was registered with Provide, the provider is called to construct the value.
 //   - If the parameter type is an interface which exactly one dependency implements, that value is used.
 //   - If the parameter type is an interface which no dependencies implement, an error is not returned, but rather the argument will
-//     be the zero value of the parameter type.
-//   - If the parameter type is an interface which more than one dependency implements, an error is returned.
+//     be the zero value of the parameter type, or the value given by the WithOnMissing callback. In a Context created with
+//     WithStrict, a MissingError is returned instead. But if a default was registered with SetDefault which matches by
+//     these same rules, it is used first.
+//   - If the parameter type is an interface which more than one dependency implements, an error is returned, unless one
+//     of them has been chosen with Prefer.
+//   - If the parameter type is a slice of an interface, and no dependency matches that slice type exactly, it is given
+//     every dependency which implements the interface, in the order they were registered.
+//   - If the parameter type is a map with string keys, and no dependency matches that map type exactly, it is given every
+//     named dependency assignable to the map's element type, keyed by name.
+//   - A variadic parameter is resolved like a slice parameter, so a variadic interface gets every implementation. A
+//     variadic concrete type gets the dependency of that type, if there is one.
+//
+// Parameters which are structs embedding In have each of their fields resolved by these rules instead.
+//
+// Parameters of type Named are resolved by the same rules, but only among dependencies registered under that name. Named
+// dependencies are never used for ordinary parameters.
+//
+// If a provider needed to construct a parameter returns an error, that error is returned, wrapped, and the function or
+// method is not invoked.
+//
+// If the function or method's last return value is an error, and it returns a non-nil error, that error is returned,
+// wrapped. This lets a Bind method reject dependencies it can't work with, rather than leaving its object half
+// initialized:
 //
-// If an error is returned, the function or method is not invoked.
+//	func (s *Server) Bind(db *sql.DB) error {
+//		if db == nil {
+//			return errors.New("server needs a database")
+//		}
+//		s.db = db
+//		return nil
+//	}
+//
+// After a Bind method has been called successfully, if the object implements AfterInjecter, its AfterInject method is
+// called, and any error it returns is returned.
+//
+// No lock is held while the function, method, or any provider runs, so they may use the Context themselves, such as by
+// calling Inject or Add. The exception is a singleton provider which, while it runs, makes an injection that needs its
+// own value: that injection waits for the provider to finish, which never happens.
 func (ctx *Context) Inject(target interface{}) error {
-	if target == nil {
+	fn, err := injectable(ctx, target)
+	if err != nil {
+		return err
+	}
+	if _, err = injectFunc(ctx, newResolution(), target, fn, ctx.bindMethodName()); err != nil {
+		return err
+	}
+	if reflect.ValueOf(target).Kind() != reflect.Func {
+		return afterInject(target)
+	}
+	return nil
+}
+
+// InjectMethod injects into the named method of obj, as if it were obj's bind method. This allows injecting into types,
+// such as those from third-party packages, which can't be given a Bind method. Errors are handled as with Inject.
+func (ctx *Context) InjectMethod(obj interface{}, name string) error {
+	if obj == nil {
 		return ErrNilInjectee
 	}
+	method, err := methodByName(reflect.ValueOf(obj), name)
+	if err != nil {
+		return err
+	}
+	if _, err = injectFunc(ctx, newResolution(), obj, method, name); err != nil {
+		return err
+	}
+	return afterInject(obj)
+}
+
+// Validate checks that target could be injected, resolving each of its parameters by the same rules as Inject, but
+// without calling target or any providers. Providers which haven't been called yet are checked the same way in turn, so
+// Validate reports any dependency which can't be resolved, however deep in the graph it is. This lets tests assert that
+// a handler is injectable with the production Context without side effects.
+//
+// A callback set with WithOnMissing is assumed to supply its value, since calling it may have side effects.
+func (ctx *Context) Validate(target interface{}) error {
+	fn, err := injectable(ctx, target)
+	if err != nil {
+		return err
+	}
+
+	in, err := resolveArgs(ctx, &resolution{validate: true}, fn.Type())
+	putArgs(in)
+	if err != nil {
+		return fmt.Errorf("validating %s: %w", describe(target, ctx.bindMethodName()), err)
+	}
+	return nil
+}
+
+// ValidateAll checks that every provider registered in the Context, and each of the given targets, could be injected,
+// as with Validate, returning all the problems found joined together, or nil if there were none. Calling it once the
+// Context is wired makes wiring mistakes fail at startup rather than the first time a dependency is needed.
+// Providers registered only in a parent Context are not checked.
+func (ctx *Context) ValidateAll(targets ...interface{}) error {
+	errs := []error{}
+
+	deps := ctx.load().deps
+	entries := make([]*entry, 0, len(deps))
+	for _, e := range deps {
+		entries = append(entries, e)
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].seq < entries[j].seq })
+	// results registered from an Out struct share one provider
+	checked := map[*provider]bool{}
+	for _, e := range entries {
+		// and every registration a feature flag chooses between
+		for ; e != nil; e = e.off {
+			if e.prov == nil || checked[e.prov] {
+				continue
+			}
+			checked[e.prov] = true
+			if _, err := e.prov.get(ctx, &resolution{validate: true}); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+
+	for i, target := range targets {
+		if err := ctx.Validate(target); err != nil {
+			errs = append(errs, fmt.Errorf("target %d: %w", i, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// InjectAll injects into each of the targets in turn, as with Inject. Every target is attempted even if an earlier one
+// fails, and all the failures are returned joined together, or nil if there were none.
+func (ctx *Context) InjectAll(targets ...interface{}) error {
+	errs := []error{}
+	for i, target := range targets {
+		if err := ctx.Inject(target); err != nil {
+			errs = append(errs, fmt.Errorf("target %d: %w", i, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Invoke is like Inject, but returns the values returned by the function or Bind method instead of discarding them, so
+// that injection can be used to construct objects as well as to run functions for their side effects. If the function
+// returns an error, it is returned as with Inject, along with all the returned values.
+func (ctx *Context) Invoke(target interface{}) ([]interface{}, error) {
+	fn, err := injectable(ctx, target)
+	if err != nil {
+		return nil, err
+	}
+	out, err := injectFunc(ctx, newResolution(), target, fn, ctx.bindMethodName())
+	if out == nil {
+		return nil, err
+	}
+
+	results := make([]interface{}, len(out))
+	for i, val := range out {
+		results[i] = val.Interface()
+	}
+	return results, err
+}
+
+// injectable finds the function to call for a target: either the target
+// itself, if it is a function, or its bind method.
+func injectable(ctx *Context, target interface{}) (reflect.Value, error) {
+	if target == nil {
+		return reflect.Value{}, ErrNilInjectee
+	}
 	val := reflect.ValueOf(target)
-	t := val.Type()
 
 	if val.Kind() == reflect.Func {
-		return injectFunc(ctx, val, t)
+		return val, nil
+	}
+
+	return methodByName(val, ctx.bindMethodName())
+}
+
+// methodKey is a method of a type, for caching its lookup.
+type methodKey struct {
+	typ  reflect.Type
+	name string
+}
+
+// methodIndexes caches where each method looked up by methodByName is in its
+// type's method set, or -1 if the type has no such method, since objects of
+// the same type are often injected many times.
+var methodIndexes sync.Map
+
+// methodByName finds the named method of val. If the method exists, but only
+// on a pointer to val, an error is returned explaining that.
+func methodByName(val reflect.Value, name string) (reflect.Value, error) {
+	k := methodKey{val.Type(), name}
+	index, ok := methodIndexes.Load(k)
+	if !ok {
+		index = -1
+		if m, found := val.Type().MethodByName(name); found {
+			index = m.Index
+		}
+		methodIndexes.Store(k, index)
+	}
+	if i := index.(int); i >= 0 {
+		return val.Method(i), nil
 	}
 
-	method := val.MethodByName(methodName)
-	if method.IsValid() && !method.IsZero() {
-		methodType := method.Type()
-		return injectFunc(ctx, method, methodType)
+	// the value can't be made addressable, and a copy would be pointless to bind,
+	// so the best we can do is explain
+	if val.Kind() != reflect.Pointer {
+		if _, ok := reflect.PointerTo(val.Type()).MethodByName(name); ok {
+			return reflect.Value{}, fmt.Errorf("%v method of %v %w", name, val.Type(), ErrPointerReceiver)
+		}
 	}
 
-	return fmt.Errorf("%w: %v", ErrNotInjectable, target)
+	return reflect.Value{}, fmt.Errorf("%w %q: %v", ErrNotInjectable, name, val)
 }
 
-func injectFunc(ctx *Context, fn reflect.Value, t reflect.Type) error {
-	// don't let the list change while we're iterating
-	ctx.lock.Lock()
-	defer ctx.lock.Unlock()
+// MustInject is like Inject but panics if the injection fails. It is intended for program startup, where any wiring
+// failure is fatal.
+func (ctx *Context) MustInject(target interface{}) {
+	if err := ctx.Inject(target); err != nil {
+		panic(err)
+	}
+}
+
+// injectFunc resolves the arguments of fn, which is target or the named
+// method of it, as part of r, and calls it. r is released once the arguments
+// are resolved.
+func injectFunc(ctx *Context, r *resolution, target interface{}, fn reflect.Value, method string) ([]reflect.Value, error) {
+	in, err := resolveCall(ctx, r, fn.Type())
+	if err != nil {
+		return nil, fmt.Errorf("injecting %s: %w", describe(target, method), err)
+	}
+	out, err := callResolved(ctx, target, fn, method, in)
+	putArgs(in)
+	return out, err
+}
+
+// resolveCall resolves the arguments for a function of type t as part of r,
+// and releases r. The slice should be given back with putArgs once the call
+// is done.
+func resolveCall(ctx *Context, r *resolution, t reflect.Type) ([]reflect.Value, error) {
+	in, err := planFor(ctx, t).args(ctx, r)
+	if err == nil {
+		// the context may have been canceled after the last provider returned
+		if err = r.context().Err(); err != nil {
+			putArgs(in)
+		}
+	}
+	if r.progress != nil {
+		err = r.progress.canceled(r.context(), err)
+	}
+	r.release()
+	return in, err
+}
+
+// callResolved calls fn with its resolved arguments, wrapping any error it
+// returns with the name of target.
+func callResolved(ctx *Context, target interface{}, fn reflect.Value, method string, in []reflect.Value) ([]reflect.Value, error) {
+	t := fn.Type()
+	out, err := guardedCall(ctx, target, fn, in)
+	if err != nil {
+		return nil, fmt.Errorf("%s %w", describe(target, method), err)
+	}
+	if err := returnedError(t, out); err != nil {
+		return out, fmt.Errorf("%s returned an error: %w", describe(target, method), err)
+	}
+	return out, nil
+}
+
+// describe names a target for error messages: the name of a function, or the
+// type and method name of an object.
+func describe(target interface{}, method string) string {
+	val := reflect.ValueOf(target)
+	if val.Kind() == reflect.Func {
+		if f := runtime.FuncForPC(val.Pointer()); f != nil {
+			return f.Name()
+		}
+		return val.Type().String()
+	}
+	return fmt.Sprintf("%v.%s", val.Type(), method)
+}
+
+// returnedError returns the error a function returned as its last value, if
+// it has one.
+func returnedError(t reflect.Type, out []reflect.Value) error {
+	n := t.NumOut()
+	if n == 0 || t.Out(n-1) != errorType || out[n-1].IsNil() {
+		return nil
+	}
+	return out[n-1].Interface().(error)
+}
+
+// call calls fn with the resolved arguments, passing the last one as the
+// variadic arguments if fn is variadic.
+func call(fn reflect.Value, in []reflect.Value) []reflect.Value {
+	if fn.Type().IsVariadic() {
+		return fn.CallSlice(in)
+	}
+	return fn.Call(in)
+}
+
+// guardedCall calls fn like call, but if the Context was created with
+// WithRecover, a panic is recovered and returned as a PanicError. Observers
+// are told about the call to target, which fn is, or is the bind method of.
+func guardedCall(ctx *Context, target interface{}, fn reflect.Value, in []reflect.Value) (out []reflect.Value, err error) {
+	for _, o := range ctx.observers {
+		o.BeforeCall(target)
+	}
+	if len(ctx.observers) > 0 {
+		defer func() {
+			callErr := err
+			if callErr == nil {
+				callErr = returnedError(fn.Type(), out)
+			}
+			for _, o := range ctx.observers {
+				o.AfterCall(target, callErr)
+			}
+		}()
+	}
+	if ctx.recover {
+		defer func() {
+			if r := recover(); r != nil {
+				err = &PanicError{Value: r, Stack: debug.Stack()}
+			}
+		}()
+	}
+	return call(fn, in), nil
+}
+
+// resolution is the state of a single top-level injection, shared by every
+// dependency resolved for it.
+type resolution struct {
+	// check that everything can be resolved without calling any providers
+	validate bool
+	// the providers being called, outermost first, to detect cycles
+	building []*provider
+	// where Explain records how the parameter is resolved
+	explain *ParamExplanation
+	// the context providers are called in, for tracing
+	stdctx context.Context
+	// where Compile records the dependency chosen for the parameter
+	picked *step
+	// give the context to parameters of type context.Context, for InjectContext
+	passContext bool
+	// where the providers called are recorded, if the context can be canceled
+	progress *progress
+}
 
+// resolveVariadic resolves a variadic parameter whose element type is not an
+// interface, giving a slice of the one dependency of that type, if any.
+func resolveVariadic(ctx *Context, r *resolution, sliceType reflect.Type) (reflect.Value, error) {
+	val, err := resolve(ctx, r, sliceType.Elem())
+	if err != nil || !val.IsValid() {
+		return val, err
+	}
+	return reflect.Append(reflect.MakeSlice(sliceType, 0, 1), val), nil
+}
+
+// resolveArgs finds a value for every parameter of the function type t. If
+// any can't be resolved, the errors for all of them are returned together.
+func resolveArgs(ctx *Context, r *resolution, t reflect.Type) ([]reflect.Value, error) {
 	// iterate the parameters
 	// All code paths leading here already validated
 	// that the Kind is Func, so no need to worry about panic
 	numParams := t.NumIn()
-	in := make([]reflect.Value, numParams)
+	in := getArgs(numParams)
+	var errs []error
 	for i := 0; i < numParams; i++ {
-		argType := t.In(i)
-		if val, ok := ctx.deps[argType]; ok {
-			in[i] = val
+		beforeResolve(ctx, r, t.In(i))
+		val, err := resolveParam(ctx, r, t, i)
+		afterResolve(ctx, r, t.In(i), val, err)
+		if err != nil {
+			errs = append(errs, paramError(i, t.In(i), err))
 			continue
 		}
+		in[i] = val
+	}
+	if len(errs) > 0 {
+		putArgs(in)
+		return nil, errors.Join(errs...)
+	}
+	return in, nil
+}
+
+// resolveParam finds the value for parameter i of the function type t.
+func resolveParam(ctx *Context, r *resolution, t reflect.Type, i int) (reflect.Value, error) {
+	argType := t.In(i)
+	val, err := resolve(ctx, r, argType)
+	if err != nil {
+		return reflect.Value{}, err
+	}
+
+	// a variadic parameter of a concrete type gets its one match
+	if !val.IsValid() && t.IsVariadic() && i == t.NumIn()-1 {
+		val, err = resolveVariadic(ctx, r, argType)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+	}
+
+	// no matches means we pass zero
+	if !val.IsValid() {
+		return missing(ctx, r, argType, i)
+	}
+	return val, nil
+}
+
+// paramError adds the position and type of the parameter being resolved to
+// err, unless it already has them.
+func paramError(index int, t reflect.Type, err error) error {
+	switch err := err.(type) {
+	case *AmbiguousError:
+		err.Index = index
+		return err
+	case *MissingError:
+		return err
+	}
+	return fmt.Errorf("parameter %d of type %v: %w", index, t, err)
+}
+
+// missing decides what to inject for a type which has no matching
+// dependency: a default registered with SetDefault, the value given by the
+// OnMissing callback if there is one, or else the zero value, unless the
+// Context is strict. index is the position of
+// the parameter being resolved, or -1 if it isn't a parameter.
+func missing(ctx *Context, r *resolution, t reflect.Type, index int) (reflect.Value, error) {
+	val, err := resolveDefault(ctx, r, t)
+	if err != nil || val.IsValid() {
+		r.note(MatchDefault, nil, nil, nil)
+		return val, err
+	}
 
-		// can't find a one-to-one type match
-		// do a search and find everything that
-		// implements the requested type
-		candidateVals := []reflect.Value{}
-		candidateTypes := []reflect.Type{}
-		for t, val := range ctx.deps {
-			if t.Implements(argType) {
-				candidateVals = append(candidateVals, val)
-				candidateTypes = append(candidateTypes, t)
+	if ctx.onMissing != nil {
+		r.note(MatchCallback, nil, nil, nil)
+	}
+	// the callback may have side effects, so assume it can supply a value
+	if ctx.onMissing != nil && r.validate {
+		return reflect.Zero(t), nil
+	}
+	if ctx.onMissing != nil {
+		v, err := ctx.onMissing(t)
+		if err != nil {
+			return reflect.Value{}, fmt.Errorf("no dependency for %v: %w", t, err)
+		}
+		if v != nil {
+			val := reflect.ValueOf(v)
+			if !val.Type().AssignableTo(t) {
+				return reflect.Value{}, fmt.Errorf("%w: missing dependency callback returned %v for %v", ErrResultType, val.Type(), t)
 			}
+			return val, nil
 		}
+	}
 
-		// no matches means we pass zero
-		if len(candidateVals) == 0 {
-			in[i] = reflect.Zero(argType)
-			continue
+	if ctx.strict {
+		return reflect.Value{}, &MissingError{Type: t, Index: index}
+	}
+	r.note(MatchZero, nil, nil, nil)
+	if !r.validate {
+		logDebug(ctx, "di: zero value", slog.String("type", t.String()))
+		countZero(ctx, t)
+	}
+	return reflect.Zero(t), nil
+}
+
+// resolve finds the value to inject for a single parameter type, returning
+// an invalid Value if there is no match.
+func resolve(ctx *Context, r *resolution, argType reflect.Type) (reflect.Value, error) {
+	if r.passContext && argType == contextType {
+		stdctx := r.context()
+		return reflect.ValueOf(&stdctx).Elem(), nil
+	}
+	if isIn(argType) {
+		val, err := resolveIn(ctx, r, argType)
+		r.note(MatchParams, nil, nil, nil)
+		return val, err
+	}
+	if argType.Implements(qualifiedType) {
+		return resolveQualified(ctx, r, argType)
+	}
+	if argType.Implements(secretType) {
+		return resolveSecretParam(ctx, r, argType)
+	}
+	return resolveNamed(ctx, r, argType, "")
+}
+
+// resolveGroup finds every dependency registered under the given name which
+// implements the element type of sliceType, and collects them in a slice in
+// the order they were registered, returning an invalid Value if there are none.
+// Dependencies in ancestor contexts are included unless shadowed.
+func resolveGroup(ctx *Context, r *resolution, sliceType reflect.Type, name string) (reflect.Value, error) {
+	elemType := sliceType.Elem()
+	seen := map[key]bool{}
+	vals := []reflect.Value{}
+	// a dependency registered with AddAs is under more than one key
+	added := map[*entry]bool{}
+	for c := ctx; c != nil; c = c.parent {
+		s := c.load()
+		members := []*entry{}
+		for _, k := range implementers(s, elemType) {
+			if e := s.deps[k]; k.name == name && !seen[k] && !added[e] {
+				seen[k] = true
+				added[e] = true
+				members = append(members, e)
+			}
 		}
+		sort.Slice(members, func(i, j int) bool { return members[i].seq < members[j].seq })
 
-		// too many matches
-		if len(candidateVals) > 1 {
-			return fmt.Errorf("%w, bound types with possible match: %v", ErrAmbiguous, candidateTypes)
+		for _, e := range members {
+			val, err := e.value(c, r)
+			if err != nil {
+				return reflect.Value{}, err
+			}
+			vals = append(vals, val)
 		}
+	}
 
-		// exactly one match - perfect
-		in[i] = candidateVals[0]
+	if len(vals) == 0 {
+		return reflect.Value{}, nil
 	}
+	group := reflect.MakeSlice(sliceType, len(vals), len(vals))
+	for i, val := range vals {
+		group.Index(i).Set(val)
+	}
+	return group, nil
+}
 
-	fn.Call(in)
-	return nil
+// resolveNamed finds the value to inject for a type among the dependencies
+// registered under the given name, returning an invalid Value if there is
+// no match.
+func resolveNamed(ctx *Context, r *resolution, argType reflect.Type, name string) (reflect.Value, error) {
+	s := ctx.load()
+	if e, ok := s.deps[key{argType, name}]; ok {
+		r.note(MatchExact, argType, e, nil)
+		return r.use(ctx, e)
+	}
+
+	// a slice of interfaces gets every implementation
+	if argType.Kind() == reflect.Slice && argType.Elem().Kind() == reflect.Interface {
+		r.note(MatchGroup, nil, nil, nil)
+		return resolveGroup(ctx, r, argType, name)
+	}
+
+	// a map keyed by strings gets every named dependency
+	if name == "" && argType.Kind() == reflect.Map && argType.Key().Kind() == reflect.String {
+		r.note(MatchMap, nil, nil, nil)
+		return resolveMap(ctx, r, argType)
+	}
+
+	// can't find a one-to-one type match
+	// do a search and find everything that
+	// implements the requested type
+	found := getCandidates()
+	defer found.put()
+	if argType.Kind() == reflect.Interface {
+		for _, k := range implementers(s, argType) {
+			if k.name == name {
+				found.keys = append(found.keys, k)
+			}
+		}
+		// sort so errors list the candidates in a stable order
+		if len(found.keys) > 1 {
+			keys := found.keys
+			sort.Slice(keys, func(i, j int) bool { return keys[i].typ.String() < keys[j].typ.String() })
+		}
+		for _, k := range found.keys {
+			if e := s.deps[k]; !containsEntry(found.entries, e) {
+				found.entries = append(found.entries, e)
+				found.types = append(found.types, k.typ)
+			}
+		}
+	}
+	candidates, candidateTypes := found.entries, found.types
+
+	// no matches means we try the parent
+	if len(candidates) == 0 {
+		if ctx.parent != nil {
+			return resolveNamed(ctx.parent, r, argType, name)
+		}
+		return reflect.Value{}, nil
+	}
+
+	// too many matches, unless one is preferred or more specific
+	if len(candidates) > 1 {
+		if preferred, ok := s.prefs[argType]; ok {
+			for i, t := range candidateTypes {
+				if t == preferred {
+					r.note(MatchPreferred, t, candidates[i], candidateTypes)
+					return r.use(ctx, candidates[i])
+				}
+			}
+		}
+		if ctx.specific {
+			if i := mostSpecific(candidateTypes); i >= 0 {
+				r.note(MatchSpecific, candidateTypes[i], candidates[i], candidateTypes)
+				return r.use(ctx, candidates[i])
+			}
+		}
+		return reflect.Value{}, &AmbiguousError{Type: argType, Name: name, Candidates: copyTypes(candidateTypes), Index: -1}
+	}
+
+	// exactly one match - perfect
+	r.note(MatchInterface, candidateTypes[0], candidates[0], candidateTypes)
+	return r.use(ctx, candidates[0])
+}
+
+// value returns the entry's value, calling its provider if it has one.
+func (e *entry) value(ctx *Context, r *resolution) (reflect.Value, error) {
+	if e.flag != "" {
+		chosen, err := e.choose(ctx, r)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		if chosen != e {
+			return chosen.value(ctx, r)
+		}
+	}
+	if e.prov == nil {
+		return e.val, nil
+	}
+	val, err := e.prov.get(ctx, r)
+	if err != nil || e.field == nil {
+		return val, err
+	}
+	return val.FieldByIndex(e.field), nil
+}
+
+// containsEntry reports whether e is in entries. A dependency registered with
+// AddAs is under more than one key, so it can be found more than once.
+func containsEntry(entries []*entry, e *entry) bool {
+	for _, other := range entries {
+		if other == e {
+			return true
+		}
+	}
+	return false
 }
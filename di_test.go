@@ -2,8 +2,12 @@ package di_test
 
 import (
 	"bytes"
+	"errors"
+	"fmt"
 	"io"
 	"os"
+	"reflect"
+	"strings"
 	"testing"
 
 	"github.com/mcvoid/di"
@@ -22,6 +26,19 @@ func (b *testBinder) Bind(f *os.File) {
 	}
 }
 
+// a test value whose Bind method can fail
+type failingBinder struct {
+	f *os.File
+}
+
+func (b *failingBinder) Bind(f *os.File) error {
+	if f == nil {
+		return errors.New("missing file")
+	}
+	b.f = f
+	return nil
+}
+
 func TestAdd(t *testing.T) {
 	t.Run("doesn't panic on nil input", func(t *testing.T) {
 		defer func() {
@@ -45,6 +62,355 @@ func TestAdd(t *testing.T) {
 	})
 }
 
+func TestReplace(t *testing.T) {
+	t.Run("replaces a registered type", func(t *testing.T) {
+		ctx := di.New().Add(os.Stdin)
+
+		err := ctx.Replace(os.Stdout)
+		if err != nil {
+			t.Errorf("expected %v got %v", nil, err)
+		}
+		ctx.Inject(func(f *os.File) {
+			if f != os.Stdout {
+				t.Errorf("expected %v got %v", os.Stdout, f)
+			}
+		})
+	})
+
+	t.Run("unregistered type", func(t *testing.T) {
+		ctx := di.New().Add(os.Stdin)
+
+		err := ctx.Replace(os.Stdout, &bytes.Buffer{})
+		if !errors.Is(err, di.ErrNotRegistered) {
+			t.Errorf("expected %v got %v", di.ErrNotRegistered, err)
+		}
+		ctx.Inject(func(f *os.File) {
+			if f != os.Stdin {
+				t.Errorf("expected %v got %v", os.Stdin, f)
+			}
+		})
+	})
+
+	t.Run("registrations keep their options", func(t *testing.T) {
+		flags := di.StaticFlags{}
+		ctx := di.New().Add(flags)
+		di.AddAs[io.Writer](ctx, os.Stdout, di.WhenFlag("new-writer"))
+
+		if err := ctx.Replace(os.Stderr); err != nil {
+			t.Errorf("expected %v got %v", nil, err)
+		}
+		if _, err := di.Resolve[*os.File](ctx); !errors.Is(err, di.ErrFlagOff) {
+			t.Errorf("expected %v got %v", di.ErrFlagOff, err)
+		}
+		flags["new-writer"] = true
+		if w := di.MustResolve[io.Writer](ctx); w != os.Stderr {
+			t.Errorf("expected %v got %v", os.Stderr, w)
+		}
+		if f := di.MustResolve[*os.File](ctx); f != os.Stderr {
+			t.Errorf("expected %v got %v", os.Stderr, f)
+		}
+	})
+
+	t.Run("overwrite hook", func(t *testing.T) {
+		var overwritten []reflect.Type
+		ctx := di.New(di.WithOverwriteHook(func(t reflect.Type) {
+			overwritten = append(overwritten, t)
+		}))
+
+		ctx.Add(os.Stdin, &bytes.Buffer{})
+		if len(overwritten) != 0 {
+			t.Errorf("expected %v got %v", 0, len(overwritten))
+		}
+		ctx.Replace(os.Stdout)
+		if len(overwritten) != 0 {
+			t.Errorf("expected %v got %v", 0, len(overwritten))
+		}
+		ctx.Add(os.Stderr)
+		if len(overwritten) != 1 || overwritten[0] != reflect.TypeOf(os.Stderr) {
+			t.Errorf("expected %v got %v", []reflect.Type{reflect.TypeOf(os.Stderr)}, overwritten)
+		}
+	})
+
+	t.Run("overwrite hook can use the Context", func(t *testing.T) {
+		var ctx *di.Context
+		var got *os.File
+		ctx = di.New(di.WithOverwriteHook(func(reflect.Type) {
+			ctx.Inject(func(f *os.File) { got = f })
+		}))
+
+		ctx.Add(os.Stdin, os.Stderr)
+		if got != os.Stderr {
+			t.Errorf("expected %v got %v", os.Stderr, got)
+		}
+	})
+}
+
+func TestClone(t *testing.T) {
+	t.Run("changes to the clone don't affect the original", func(t *testing.T) {
+		ctx := di.New().Add(os.Stdin)
+		clone := ctx.Clone().Add(os.Stdout, &bytes.Buffer{})
+
+		ctx.Inject(func(f *os.File) {
+			if f != os.Stdin {
+				t.Errorf("expected %v got %v", os.Stdin, f)
+			}
+		})
+		if di.Has[*bytes.Buffer](ctx) {
+			t.Errorf("expected original to not have clone's dependency")
+		}
+		clone.Inject(func(f *os.File) {
+			if f != os.Stdout {
+				t.Errorf("expected %v got %v", os.Stdout, f)
+			}
+		})
+	})
+
+	t.Run("changes to the original don't affect the clone", func(t *testing.T) {
+		ctx := di.New().Add(os.Stdin)
+		clone := ctx.Clone()
+		ctx.Remove(os.Stdin)
+
+		clone.Inject(func(f *os.File) {
+			if f != os.Stdin {
+				t.Errorf("expected %v got %v", os.Stdin, f)
+			}
+		})
+	})
+
+	t.Run("zero value", func(t *testing.T) {
+		var ctx di.Context
+		ctx.Clone().Add(os.Stdin)
+	})
+}
+
+func TestChild(t *testing.T) {
+	t.Run("falls back to the parent", func(t *testing.T) {
+		b := &bytes.Buffer{}
+		parent := di.New().Add(os.Stdin, b)
+		child := parent.Child().Add(os.Stdout)
+
+		wasCalled := false
+		err := child.Inject(func(f *os.File, r io.Reader, buf *bytes.Buffer) {
+			wasCalled = true
+			if f != os.Stdout {
+				t.Errorf("expected %v got %v", os.Stdout, f)
+			}
+			if buf != b {
+				t.Errorf("expected %p got %p", b, buf)
+			}
+		})
+		if !wasCalled {
+			t.Errorf("expected func to be called")
+		}
+		if err != nil {
+			t.Errorf("expected %v got %v", nil, err)
+		}
+	})
+
+	t.Run("child shadows interface matches", func(t *testing.T) {
+		parent := di.New().Add(os.Stdin)
+		child := parent.Child().Add(&bytes.Buffer{})
+
+		err := child.Inject(func(r io.Reader) {
+			if _, ok := r.(*bytes.Buffer); !ok {
+				t.Errorf("expected %T got %T", &bytes.Buffer{}, r)
+			}
+		})
+		if err != nil {
+			t.Errorf("expected %v got %v", nil, err)
+		}
+	})
+
+	t.Run("parent is unchanged", func(t *testing.T) {
+		parent := di.New().Add(os.Stdin)
+		parent.Child().Add(os.Stdout, &bytes.Buffer{})
+
+		parent.Inject(func(f *os.File) {
+			if f != os.Stdin {
+				t.Errorf("expected %v got %v", os.Stdin, f)
+			}
+		})
+		if di.Has[*bytes.Buffer](parent) {
+			t.Errorf("expected parent to not have child's dependency")
+		}
+	})
+
+	t.Run("has checks the parent", func(t *testing.T) {
+		child := di.New().Add(os.Stdin).Child()
+		if !di.Has[io.Reader](child) {
+			t.Errorf("expected %v got %v", true, false)
+		}
+	})
+}
+
+func TestMerge(t *testing.T) {
+	t.Run("combines dependencies", func(t *testing.T) {
+		b := &bytes.Buffer{}
+		ctx := di.New().Add(os.Stdin)
+		err := ctx.Merge(di.New().Add(b))
+		if err != nil {
+			t.Errorf("expected %v got %v", nil, err)
+		}
+
+		wasCalled := false
+		ctx.Inject(func(f *os.File, buf *bytes.Buffer) {
+			wasCalled = true
+			if f != os.Stdin {
+				t.Errorf("expected %v got %v", os.Stdin, f)
+			}
+			if buf != b {
+				t.Errorf("expected %p got %p", b, buf)
+			}
+		})
+		if !wasCalled {
+			t.Errorf("expected func to be called")
+		}
+	})
+
+	t.Run("conflicting dependencies", func(t *testing.T) {
+		ctx := di.New().Add(os.Stdin)
+		err := ctx.Merge(di.New().Add(os.Stdout, &bytes.Buffer{}))
+		if !errors.Is(err, di.ErrConflict) {
+			t.Errorf("expected %v got %v", di.ErrConflict, err)
+		}
+		if di.Has[*bytes.Buffer](ctx) {
+			t.Errorf("expected nothing to be merged")
+		}
+	})
+
+	t.Run("shared registrations don't conflict", func(t *testing.T) {
+		ctx := di.New().Add(os.Stdin)
+		err := ctx.Merge(ctx.Clone().Add(&bytes.Buffer{}))
+		if err != nil {
+			t.Errorf("expected %v got %v", nil, err)
+		}
+	})
+
+	t.Run("merging with itself", func(t *testing.T) {
+		ctx := di.New().Add(os.Stdin)
+		err := ctx.Merge(ctx)
+		if err != nil {
+			t.Errorf("expected %v got %v", nil, err)
+		}
+	})
+
+	t.Run("frozen contexts merge nothing", func(t *testing.T) {
+		ctx := di.New()
+		other := ctx.Clone().Add(os.Stdin, &bytes.Buffer{})
+		ctx.Freeze()
+		err := ctx.Merge(other)
+		if !errors.Is(err, di.ErrFrozen) {
+			t.Errorf("expected %v got %v", di.ErrFrozen, err)
+		}
+		if di.Has[*os.File](ctx) || di.Has[*bytes.Buffer](ctx) {
+			t.Errorf("expected nothing to be merged")
+		}
+	})
+
+	t.Run("shared final registrations merge", func(t *testing.T) {
+		ctx := di.New()
+		ctx.Provide(func() *bytes.Buffer { return &bytes.Buffer{} }, di.Final())
+		err := ctx.Merge(ctx.Clone().Add(os.Stdin))
+		if err != nil {
+			t.Errorf("expected %v got %v", nil, err)
+		}
+		if !di.Has[*os.File](ctx) {
+			t.Errorf("expected %v to be merged", "*os.File")
+		}
+	})
+}
+
+func TestFreeze(t *testing.T) {
+	t.Run("add is reported by err", func(t *testing.T) {
+		ctx := di.New().Add(os.Stdin).Freeze()
+		ctx.Add(os.Stdout)
+
+		if !errors.Is(ctx.Err(), di.ErrFrozen) {
+			t.Errorf("expected %v got %v", di.ErrFrozen, ctx.Err())
+		}
+		ctx.Inject(func(f *os.File) {
+			if f != os.Stdin {
+				t.Errorf("expected %v got %v", os.Stdin, f)
+			}
+		})
+	})
+
+	t.Run("replace and provide return errors", func(t *testing.T) {
+		ctx := di.New().Add(os.Stdin).Freeze()
+
+		err := ctx.Replace(os.Stdout)
+		if !errors.Is(err, di.ErrFrozen) {
+			t.Errorf("expected %v got %v", di.ErrFrozen, err)
+		}
+		err = ctx.Provide(func() *bytes.Buffer { return nil })
+		if !errors.Is(err, di.ErrFrozen) {
+			t.Errorf("expected %v got %v", di.ErrFrozen, err)
+		}
+		err = ctx.Merge(di.New().Add(&bytes.Buffer{}))
+		if !errors.Is(err, di.ErrFrozen) {
+			t.Errorf("expected %v got %v", di.ErrFrozen, err)
+		}
+	})
+
+	t.Run("remove is reported by err", func(t *testing.T) {
+		ctx := di.New().Add(os.Stdin).Freeze()
+		ctx.Remove(os.Stdin)
+
+		if !errors.Is(ctx.Err(), di.ErrFrozen) {
+			t.Errorf("expected %v got %v", di.ErrFrozen, ctx.Err())
+		}
+		if di.Remove[*os.File](ctx) {
+			t.Errorf("expected %v got %v", false, true)
+		}
+		if !di.Has[*os.File](ctx) {
+			t.Errorf("expected dependency to remain")
+		}
+	})
+
+	t.Run("children are not frozen", func(t *testing.T) {
+		child := di.New().Freeze().Child().Add(os.Stdin)
+		if child.Err() != nil {
+			t.Errorf("expected %v got %v", nil, child.Err())
+		}
+	})
+
+	t.Run("no errors before freezing", func(t *testing.T) {
+		ctx := di.New().Add(os.Stdin)
+		if ctx.Frozen() {
+			t.Errorf("expected %v got %v", false, true)
+		}
+		if ctx.Err() != nil {
+			t.Errorf("expected %v got %v", nil, ctx.Err())
+		}
+	})
+}
+
+func TestRemove(t *testing.T) {
+	t.Run("removes by type", func(t *testing.T) {
+		ctx := di.New().Add(os.Stdin).Remove(os.Stdout)
+
+		err := ctx.Inject(func(f *os.File) {
+			if f != nil {
+				t.Errorf("expected %v got %v", nil, f)
+			}
+		})
+		if err != nil {
+			t.Errorf("expected %v got %v", nil, err)
+		}
+	})
+
+	t.Run("ignores nil and missing types", func(t *testing.T) {
+		defer func() {
+			val := recover()
+			if val != nil {
+				t.Errorf("expected no panic got %v", val)
+			}
+		}()
+		var ctx di.Context
+		ctx.Remove(nil, os.Stdin)
+	})
+}
+
 func TestInject(t *testing.T) {
 	t.Run("nil injectee", func(t *testing.T) {
 		ctx := di.New().Add(os.Stdout)
@@ -65,7 +431,7 @@ func TestInject(t *testing.T) {
 		ctx := di.New().Add(os.Stdout)
 
 		wasCalled := false
-		fn := func(f io.WriterTo) {
+		fn := func(f fmt.Stringer) {
 			wasCalled = true
 			if f != nil {
 				t.Errorf("expected %v got %v", nil, f)
@@ -178,6 +544,21 @@ func TestInject(t *testing.T) {
 		}
 	})
 
+	t.Run("function ambiguous match lists candidates in order", func(t *testing.T) {
+		var b bytes.Buffer
+		var sb strings.Builder
+		fn := func(f io.Writer) {}
+
+		for i := 0; i < 10; i++ {
+			ctx := di.New().Add(os.Stdout, &sb, &b)
+			err := ctx.Inject(fn)
+			expected := "[*bytes.Buffer *os.File *strings.Builder]"
+			if err == nil || !strings.Contains(err.Error(), expected) {
+				t.Errorf("expected %v got %v", expected, err)
+			}
+		}
+	})
+
 	t.Run("method exact match", func(t *testing.T) {
 		ctx := di.New().Add(os.Stdin)
 
@@ -202,4 +583,542 @@ func TestInject(t *testing.T) {
 			t.Errorf("expected err got %v", err)
 		}
 	})
+
+	t.Run("injected function can use the Context", func(t *testing.T) {
+		ctx := di.New().Add(os.Stdout)
+
+		var got *bytes.Buffer
+		err := ctx.Inject(func(f *os.File) {
+			ctx.Add(&bytes.Buffer{})
+			ctx.Inject(func(b *bytes.Buffer) { got = b })
+		})
+		if err != nil {
+			t.Errorf("expected %v got %v", nil, err)
+		}
+		if got == nil {
+			t.Errorf("expected buffer got %v", got)
+		}
+	})
+
+	t.Run("provider can use the Context", func(t *testing.T) {
+		ctx := di.New().Add(os.Stdout)
+		ctx.Provide(func() *bytes.Buffer {
+			ctx.Add(&strings.Builder{})
+			return &bytes.Buffer{}
+		})
+
+		err := ctx.Inject(func(*bytes.Buffer) {})
+		if err != nil {
+			t.Errorf("expected %v got %v", nil, err)
+		}
+		if !di.Has[*strings.Builder](ctx) {
+			t.Errorf("expected builder")
+		}
+	})
+}
+
+func TestMustInject(t *testing.T) {
+	t.Run("calls the function", func(t *testing.T) {
+		ctx := di.New().Add(os.Stdin)
+
+		wasCalled := false
+		ctx.MustInject(func(f *os.File) {
+			wasCalled = true
+		})
+		if !wasCalled {
+			t.Errorf("expected func to be called")
+		}
+	})
+
+	t.Run("panics on error", func(t *testing.T) {
+		ctx := di.New().Add(os.Stdout)
+
+		defer func() {
+			val := recover()
+			err, _ := val.(error)
+			if !errors.Is(err, di.ErrNotInjectable) {
+				t.Errorf("expected %v got %v", di.ErrNotInjectable, val)
+			}
+		}()
+		ctx.MustInject(os.Stdout)
+	})
+}
+
+func TestGroup(t *testing.T) {
+	t.Run("slice of interface gets every implementation", func(t *testing.T) {
+		b := &bytes.Buffer{}
+		ctx := di.New().Add(os.Stdout, b)
+
+		wasCalled := false
+		err := ctx.Inject(func(ws []io.Writer) {
+			wasCalled = true
+			if len(ws) != 2 {
+				t.Fatalf("expected %v got %v", 2, len(ws))
+			}
+			if ws[0] != os.Stdout || ws[1] != b {
+				t.Errorf("expected %v got %v", []io.Writer{os.Stdout, b}, ws)
+			}
+		})
+		if !wasCalled {
+			t.Errorf("expected func to be called")
+		}
+		if err != nil {
+			t.Errorf("expected %v got %v", nil, err)
+		}
+	})
+
+	t.Run("exact match takes precedence", func(t *testing.T) {
+		ws := []io.Writer{os.Stderr}
+		ctx := di.New().Add(os.Stdout, &bytes.Buffer{}, ws)
+
+		ctx.Inject(func(got []io.Writer) {
+			if len(got) != 1 || got[0] != os.Stderr {
+				t.Errorf("expected %v got %v", ws, got)
+			}
+		})
+	})
+
+	t.Run("no implementations", func(t *testing.T) {
+		var ctx di.Context
+
+		ctx.Inject(func(ws []io.Writer) {
+			if ws != nil {
+				t.Errorf("expected %v got %v", nil, ws)
+			}
+		})
+	})
+
+	t.Run("includes parent dependencies", func(t *testing.T) {
+		ctx := di.New().Add(os.Stdout).Child().Add(&bytes.Buffer{})
+
+		ctx.Inject(func(ws []io.Writer) {
+			if len(ws) != 2 {
+				t.Errorf("expected %v got %v", 2, len(ws))
+			}
+		})
+	})
+}
+
+func TestVariadic(t *testing.T) {
+	t.Run("variadic interface gets every implementation", func(t *testing.T) {
+		b := &bytes.Buffer{}
+		ctx := di.New().Add(os.Stdout, b)
+
+		wasCalled := false
+		err := ctx.Inject(func(ws ...io.Writer) {
+			wasCalled = true
+			if len(ws) != 2 || ws[0] != os.Stdout || ws[1] != b {
+				t.Errorf("expected %v got %v", []io.Writer{os.Stdout, b}, ws)
+			}
+		})
+		if !wasCalled {
+			t.Errorf("expected func to be called")
+		}
+		if err != nil {
+			t.Errorf("expected %v got %v", nil, err)
+		}
+	})
+
+	t.Run("variadic concrete type", func(t *testing.T) {
+		ctx := di.New().Add(os.Stdin)
+
+		ctx.Inject(func(r io.Reader, fs ...*os.File) {
+			if len(fs) != 1 || fs[0] != os.Stdin {
+				t.Errorf("expected %v got %v", []*os.File{os.Stdin}, fs)
+			}
+		})
+	})
+
+	t.Run("no matches", func(t *testing.T) {
+		var ctx di.Context
+
+		wasCalled := false
+		ctx.Inject(func(ws ...io.Writer) {
+			wasCalled = true
+			if len(ws) != 0 {
+				t.Errorf("expected %v got %v", 0, len(ws))
+			}
+		})
+		if !wasCalled {
+			t.Errorf("expected func to be called")
+		}
+	})
+
+	t.Run("variadic provider", func(t *testing.T) {
+		ctx := di.New().Add(os.Stdout, &bytes.Buffer{})
+		ctx.Provide(func(ws ...io.Writer) *widget {
+			if len(ws) != 2 {
+				t.Errorf("expected %v got %v", 2, len(ws))
+			}
+			return &widget{}
+		})
+
+		_, err := di.Resolve[*widget](ctx)
+		if err != nil {
+			t.Errorf("expected %v got %v", nil, err)
+		}
+	})
+}
+
+func TestInvoke(t *testing.T) {
+	t.Run("returns the results", func(t *testing.T) {
+		ctx := di.New().Add(os.Stdin)
+
+		results, err := ctx.Invoke(func(f *os.File) (*os.File, int) {
+			return f, 42
+		})
+		if err != nil {
+			t.Errorf("expected %v got %v", nil, err)
+		}
+		if len(results) != 2 || results[0] != os.Stdin || results[1] != 42 {
+			t.Errorf("expected %v got %v", []interface{}{os.Stdin, 42}, results)
+		}
+	})
+
+	t.Run("no results", func(t *testing.T) {
+		results, err := di.New().Invoke(func() {})
+		if err != nil {
+			t.Errorf("expected %v got %v", nil, err)
+		}
+		if len(results) != 0 {
+			t.Errorf("expected %v got %v", 0, len(results))
+		}
+	})
+
+	t.Run("not injectable", func(t *testing.T) {
+		_, err := di.New().Invoke(os.Stdin)
+		if !errors.Is(err, di.ErrNotInjectable) {
+			t.Errorf("expected %v got %v", di.ErrNotInjectable, err)
+		}
+	})
+}
+
+func TestReturnedErrors(t *testing.T) {
+	errBoom := errors.New("boom")
+
+	t.Run("inject returns the error", func(t *testing.T) {
+		err := di.New().Inject(func() error { return errBoom })
+		if !errors.Is(err, errBoom) {
+			t.Errorf("expected %v got %v", errBoom, err)
+		}
+	})
+
+	t.Run("nil error", func(t *testing.T) {
+		err := di.New().Inject(func() (int, error) { return 1, nil })
+		if err != nil {
+			t.Errorf("expected %v got %v", nil, err)
+		}
+	})
+
+	t.Run("only the last return value", func(t *testing.T) {
+		err := di.New().Inject(func() (error, int) { return errBoom, 1 })
+		if err != nil {
+			t.Errorf("expected %v got %v", nil, err)
+		}
+	})
+
+	t.Run("invoke returns results and the error", func(t *testing.T) {
+		results, err := di.New().Invoke(func() (int, error) { return 1, errBoom })
+		if !errors.Is(err, errBoom) {
+			t.Errorf("expected %v got %v", errBoom, err)
+		}
+		if len(results) != 2 || results[0] != 1 {
+			t.Errorf("expected %v got %v", []interface{}{1, errBoom}, results)
+		}
+	})
+
+	t.Run("call returns the error", func(t *testing.T) {
+		_, err := di.Call[int](di.New(), func() (int, error) { return 1, errBoom })
+		if !errors.Is(err, errBoom) {
+			t.Errorf("expected %v got %v", errBoom, err)
+		}
+	})
+}
+
+func TestBindErrors(t *testing.T) {
+	t.Run("bind error is returned", func(t *testing.T) {
+		var ctx di.Context
+
+		b := failingBinder{}
+		err := ctx.Inject(&b)
+		if err == nil {
+			t.Errorf("expected err got %v", err)
+		}
+	})
+
+	t.Run("bind succeeds", func(t *testing.T) {
+		ctx := di.New().Add(os.Stdin)
+
+		b := failingBinder{}
+		err := ctx.Inject(&b)
+		if err != nil {
+			t.Errorf("expected %v got %v", nil, err)
+		}
+		if b.f != os.Stdin {
+			t.Errorf("expected %v got %v", os.Stdin, b.f)
+		}
+	})
+}
+
+func TestInjectAll(t *testing.T) {
+	t.Run("injects every target", func(t *testing.T) {
+		ctx := di.New().Add(os.Stdin)
+
+		calls := 0
+		fn := func(f *os.File) { calls++ }
+		b := testBinder{t: t}
+		err := ctx.InjectAll(fn, &b, fn)
+		if err != nil {
+			t.Errorf("expected %v got %v", nil, err)
+		}
+		if calls != 2 || !b.wasCalled {
+			t.Errorf("expected every target to be called")
+		}
+	})
+
+	t.Run("aggregates failures", func(t *testing.T) {
+		errBoom := errors.New("boom")
+		ctx := di.New().Add(os.Stdin)
+
+		wasCalled := false
+		err := ctx.InjectAll(
+			nil,
+			func() error { return errBoom },
+			func(f *os.File) { wasCalled = true },
+			os.Stdin,
+		)
+		if !errors.Is(err, di.ErrNilInjectee) || !errors.Is(err, errBoom) || !errors.Is(err, di.ErrNotInjectable) {
+			t.Errorf("expected every error got %v", err)
+		}
+		if !wasCalled {
+			t.Errorf("expected func to be called")
+		}
+	})
+}
+
+func TestInjectMethod(t *testing.T) {
+	t.Run("calls the named method", func(t *testing.T) {
+		ctx := di.New().Add(os.Stdin)
+
+		b := customBinder{}
+		err := ctx.InjectMethod(&b, "InjectDeps")
+		if err != nil {
+			t.Errorf("expected %v got %v", nil, err)
+		}
+		if b.f != os.Stdin {
+			t.Errorf("expected %v got %v", os.Stdin, b.f)
+		}
+	})
+
+	t.Run("method errors are returned", func(t *testing.T) {
+		var ctx di.Context
+
+		b := failingBinder{}
+		err := ctx.InjectMethod(&b, "Bind")
+		if err == nil {
+			t.Errorf("expected err got %v", err)
+		}
+	})
+
+	t.Run("missing method", func(t *testing.T) {
+		err := di.New().InjectMethod(&customBinder{}, "SetDependencies")
+		if !errors.Is(err, di.ErrNotInjectable) {
+			t.Errorf("expected %v got %v", di.ErrNotInjectable, err)
+		}
+	})
+
+	t.Run("nil object", func(t *testing.T) {
+		err := di.New().InjectMethod(nil, "Bind")
+		if !errors.Is(err, di.ErrNilInjectee) {
+			t.Errorf("expected %v got %v", di.ErrNilInjectee, err)
+		}
+	})
+}
+
+func TestPointerReceiver(t *testing.T) {
+	t.Run("bind method on value", func(t *testing.T) {
+		ctx := di.New().Add(os.Stdin)
+
+		err := ctx.Inject(testBinder{t: t})
+		if !errors.Is(err, di.ErrPointerReceiver) {
+			t.Errorf("expected %v got %v", di.ErrPointerReceiver, err)
+		}
+	})
+
+	t.Run("named method on value", func(t *testing.T) {
+		ctx := di.New().Add(os.Stdin)
+
+		err := ctx.InjectMethod(customBinder{}, "InjectDeps")
+		if !errors.Is(err, di.ErrPointerReceiver) {
+			t.Errorf("expected %v got %v", di.ErrPointerReceiver, err)
+		}
+	})
+}
+
+func namedTarget(w io.Writer, f *fillable) {}
+
+type failingProvided struct{}
+
+func TestErrorContext(t *testing.T) {
+	t.Run("function name and parameter", func(t *testing.T) {
+		ctx := di.New().Add(os.Stdout, &bytes.Buffer{})
+
+		err := ctx.Inject(namedTarget)
+		if err == nil || !strings.Contains(err.Error(), "di_test.namedTarget") || !strings.Contains(err.Error(), "parameter 0 of type io.Writer") {
+			t.Errorf("expected target and parameter in error got %v", err)
+		}
+	})
+
+	t.Run("bind receiver type", func(t *testing.T) {
+		ctx := di.New()
+
+		err := ctx.Inject(&failingBinder{})
+		if err == nil || !strings.Contains(err.Error(), "*di_test.failingBinder.Bind") {
+			t.Errorf("expected receiver in error got %v", err)
+		}
+	})
+
+	t.Run("provider failure", func(t *testing.T) {
+		ctx := di.New()
+		ctx.Provide(func() (*failingProvided, error) { return nil, io.EOF })
+
+		err := ctx.Inject(func(w io.Reader, p *failingProvided) {})
+		if !errors.Is(err, io.EOF) {
+			t.Errorf("expected %v got %v", io.EOF, err)
+		}
+		if err == nil || !strings.Contains(err.Error(), "parameter 1 of type *di_test.failingProvided") {
+			t.Errorf("expected parameter in error got %v", err)
+		}
+	})
+}
+
+func TestAggregateErrors(t *testing.T) {
+	t.Run("every parameter is reported", func(t *testing.T) {
+		ctx := di.New(di.WithStrict()).Add(os.Stdout, &bytes.Buffer{})
+
+		err := ctx.Inject(func(w io.Writer, f *os.File, r io.Reader, s *strings.Builder) {
+			t.Errorf("expected func to not be called")
+		})
+		for _, expected := range []string{"parameter 0 of type io.Writer", "parameter 2 of type io.Reader", "parameter 3 of type *strings.Builder"} {
+			if err == nil || !strings.Contains(err.Error(), expected) {
+				t.Errorf("expected %v got %v", expected, err)
+			}
+		}
+		if !errors.Is(err, di.ErrAmbiguous) || !errors.Is(err, di.ErrMissing) {
+			t.Errorf("expected %v and %v got %v", di.ErrAmbiguous, di.ErrMissing, err)
+		}
+	})
+
+	t.Run("every field is reported", func(t *testing.T) {
+		ctx := di.New(di.WithStrict())
+
+		var s struct {
+			R io.Reader
+			W io.Writer
+		}
+		err := ctx.Fill(&s)
+		for _, expected := range []string{"field R", "field W"} {
+			if err == nil || !strings.Contains(err.Error(), expected) {
+				t.Errorf("expected %v got %v", expected, err)
+			}
+		}
+	})
+}
+
+func TestValidate(t *testing.T) {
+	t.Run("resolvable target is not called", func(t *testing.T) {
+		ctx := di.New().Add(os.Stdout)
+
+		err := ctx.Validate(func(f *os.File) {
+			t.Errorf("expected func to not be called")
+		})
+		if err != nil {
+			t.Errorf("expected %v got %v", nil, err)
+		}
+	})
+
+	t.Run("unresolvable target", func(t *testing.T) {
+		ctx := di.New(di.WithStrict()).Add(os.Stdout)
+
+		err := ctx.Validate(func(f *os.File, b *bytes.Buffer) {})
+		if !errors.Is(err, di.ErrMissing) {
+			t.Errorf("expected %v got %v", di.ErrMissing, err)
+		}
+	})
+
+	t.Run("providers are not called", func(t *testing.T) {
+		ctx := di.New(di.WithStrict())
+		ctx.Provide(func(f *os.File) *bytes.Buffer {
+			t.Errorf("expected provider to not be called")
+			return &bytes.Buffer{}
+		})
+
+		err := ctx.Validate(func(b *bytes.Buffer) {})
+		if !errors.Is(err, di.ErrMissing) {
+			t.Errorf("expected %v got %v", di.ErrMissing, err)
+		}
+
+		ctx.Add(os.Stdout)
+		err = ctx.Validate(func(b *bytes.Buffer) {})
+		if err != nil {
+			t.Errorf("expected %v got %v", nil, err)
+		}
+	})
+
+	t.Run("bind method", func(t *testing.T) {
+		ctx := di.New(di.WithStrict())
+
+		b := testBinder{t: t}
+		err := ctx.Validate(&b)
+		if !errors.Is(err, di.ErrMissing) {
+			t.Errorf("expected %v got %v", di.ErrMissing, err)
+		}
+		if b.wasCalled {
+			t.Errorf("expected bind method to not be called")
+		}
+	})
+
+	t.Run("ambiguous", func(t *testing.T) {
+		ctx := di.New().Add(os.Stdout, &bytes.Buffer{})
+
+		err := ctx.Validate(func(w io.Writer) {})
+		if !errors.Is(err, di.ErrAmbiguous) {
+			t.Errorf("expected %v got %v", di.ErrAmbiguous, err)
+		}
+	})
+
+	t.Run("nil", func(t *testing.T) {
+		err := di.New().Validate(nil)
+		if !errors.Is(err, di.ErrNilInjectee) {
+			t.Errorf("expected %v got %v", di.ErrNilInjectee, err)
+		}
+	})
+}
+
+func TestValidateAll(t *testing.T) {
+	t.Run("everything resolvable", func(t *testing.T) {
+		ctx := di.New(di.WithStrict()).Add(os.Stdout)
+		ctx.Provide(func(f *os.File) *bytes.Buffer { return &bytes.Buffer{} })
+
+		err := ctx.ValidateAll(func(b *bytes.Buffer) {})
+		if err != nil {
+			t.Errorf("expected %v got %v", nil, err)
+		}
+	})
+
+	t.Run("every problem is reported", func(t *testing.T) {
+		ctx := di.New(di.WithStrict())
+		ctx.Provide(func(f *os.File) *bytes.Buffer {
+			t.Errorf("expected provider to not be called")
+			return &bytes.Buffer{}
+		})
+		ctx.Provide(func(r io.Reader) *strings.Builder { return &strings.Builder{} })
+
+		err := ctx.ValidateAll(func(w io.Writer) {}, func(b *bytes.Buffer) {})
+		for _, expected := range []string{"provider for *bytes.Buffer", "provider for *strings.Builder", "target 0", "target 1"} {
+			if err == nil || !strings.Contains(err.Error(), expected) {
+				t.Errorf("expected %v got %v", expected, err)
+			}
+		}
+	})
 }
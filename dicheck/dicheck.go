@@ -0,0 +1,218 @@
+// Package dicheck defines an Analyzer which checks Bind methods for wiring mistakes at build time, rather than when
+// di.Context.Inject fails or injects a zero value at runtime.
+//
+// It reports parameters of Bind methods which:
+//
+//   - have an unexported type, when the method belongs to an exported type, since other packages can't add a
+//     dependency of that type;
+//   - have a type which di can't sensibly inject, such as unsafe.Pointer, or an empty interface, which would match
+//     every dependency;
+//   - can't be satisfied by any dependency the package registers with Add, Replace, SetDefault, AddAs or Provide, in
+//     packages which register any at all.
+//
+// The last check only sees dependencies whose types are known at compile time. A package which adds values of
+// interface type is assumed to be able to satisfy anything.
+package dicheck
+
+import (
+	"go/ast"
+	"go/types"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/analysis/passes/inspect"
+	"golang.org/x/tools/go/ast/inspector"
+	"golang.org/x/tools/go/types/typeutil"
+)
+
+const diPath = "github.com/mcvoid/di"
+
+// Analyzer checks the parameters of Bind methods.
+var Analyzer = &analysis.Analyzer{
+	Name:     "dicheck",
+	Doc:      "check that Bind methods can be injected by github.com/mcvoid/di",
+	Requires: []*analysis.Analyzer{inspect.Analyzer},
+	Run:      run,
+}
+
+func run(pass *analysis.Pass) (interface{}, error) {
+	in := pass.ResultOf[inspect.Analyzer].(*inspector.Inspector)
+
+	added, known := addedTypes(pass, in)
+	in.Preorder([]ast.Node{(*ast.FuncDecl)(nil)}, func(n ast.Node) {
+		fn := n.(*ast.FuncDecl)
+		if fn.Recv == nil || fn.Name.Name != "Bind" {
+			return
+		}
+		obj, ok := pass.TypesInfo.Defs[fn.Name].(*types.Func)
+		if !ok {
+			return
+		}
+		sig := obj.Type().(*types.Signature)
+		recv := named(sig.Recv().Type())
+
+		for i := 0; i < sig.Params().Len(); i++ {
+			param := sig.Params().At(i)
+			t := param.Type()
+			if sig.Variadic() && i == sig.Params().Len()-1 {
+				t = t.(*types.Slice).Elem()
+			}
+
+			switch {
+			case recv != nil && recv.Obj().Exported() && unexported(t, pass.Pkg):
+				pass.Reportf(param.Pos(), "parameter %d of exported %s.Bind has unexported type %s, which other packages can't add",
+					i, recv.Obj().Name(), typeString(t, pass.Pkg))
+			case unsupported(t):
+				pass.Reportf(param.Pos(), "parameter %d of %s.Bind has type %s, which can't be injected",
+					i, recvName(recv), typeString(t, pass.Pkg))
+			case known && !special(t) && !satisfiable(t, added):
+				pass.Reportf(param.Pos(), "parameter %d of %s.Bind has type %s, which no dependency added in this package satisfies",
+					i, recvName(recv), typeString(t, pass.Pkg))
+			}
+		}
+	})
+	return nil, nil
+}
+
+// addedTypes collects the types of the dependencies registered in the
+// package. known is false if the package registers none, or registers some
+// whose dynamic types can't be known.
+func addedTypes(pass *analysis.Pass, in *inspector.Inspector) (added []types.Type, known bool) {
+	known = true
+	add := func(t types.Type) {
+		if types.IsInterface(t) {
+			known = false
+			return
+		}
+		added = append(added, t)
+	}
+
+	in.Preorder([]ast.Node{(*ast.CallExpr)(nil)}, func(n ast.Node) {
+		call := n.(*ast.CallExpr)
+		fn, ok := typeutil.Callee(pass.TypesInfo, call).(*types.Func)
+		if !ok || fn.Pkg() == nil || fn.Pkg().Path() != diPath {
+			return
+		}
+
+		switch fn.Name() {
+		case "Add", "Replace", "SetDefault":
+			if call.Ellipsis.IsValid() {
+				known = false
+				return
+			}
+			for _, arg := range call.Args {
+				add(pass.TypesInfo.TypeOf(arg))
+			}
+		case "AddAs":
+			if len(call.Args) < 2 {
+				return
+			}
+			sig, ok := pass.TypesInfo.TypeOf(call.Fun).(*types.Signature)
+			if !ok {
+				return
+			}
+			add(pass.TypesInfo.TypeOf(call.Args[1]))
+			added = append(added, sig.Params().At(1).Type())
+		case "Provide":
+			if len(call.Args) < 1 {
+				return
+			}
+			provider, ok := pass.TypesInfo.TypeOf(call.Args[0]).Underlying().(*types.Signature)
+			if !ok || provider.Results().Len() < 1 {
+				known = false
+				return
+			}
+			add(provider.Results().At(0).Type())
+		}
+	})
+	return added, known && len(added) > 0
+}
+
+// named returns the named type of a method receiver.
+func named(t types.Type) *types.Named {
+	if p, ok := t.(*types.Pointer); ok {
+		t = p.Elem()
+	}
+	n, _ := t.(*types.Named)
+	return n
+}
+
+// recvName is the name of a receiver type, for messages.
+func recvName(recv *types.Named) string {
+	if recv == nil {
+		return "?"
+	}
+	return recv.Obj().Name()
+}
+
+// unexported reports whether t, or the type it points to, is an unexported
+// named type declared in pkg.
+func unexported(t types.Type, pkg *types.Package) bool {
+	n := named(t)
+	return n != nil && n.Obj().Pkg() == pkg && !n.Obj().Exported()
+}
+
+// unsupported reports whether di can't sensibly inject a parameter of type t.
+func unsupported(t types.Type) bool {
+	if b, ok := t.Underlying().(*types.Basic); ok && b.Kind() == types.UnsafePointer {
+		return true
+	}
+	iface, ok := t.Underlying().(*types.Interface)
+	return ok && iface.Empty()
+}
+
+// special reports whether di resolves a parameter of type t by rules other
+// than matching a single dependency: groups, maps, Named, Secret and In
+// parameters, and the context.Context given to InjectContext or Go. These are
+// satisfied by dependencies this check doesn't track, or by none.
+func special(t types.Type) bool {
+	if n := named(t); n != nil && n.Obj().Pkg() != nil && n.Obj().Pkg().Path() == "context" && n.Obj().Name() == "Context" {
+		return true
+	}
+	switch u := t.Underlying().(type) {
+	case *types.Slice:
+		return types.IsInterface(u.Elem())
+	case *types.Map:
+		return true
+	case *types.Struct:
+		for i := 0; i < u.NumFields(); i++ {
+			if f := u.Field(i); f.Embedded() && fromDI(f.Type()) {
+				return true
+			}
+		}
+	}
+	return fromDI(t)
+}
+
+// fromDI reports whether t is one of the types declared by di which it
+// resolves by their own rules.
+func fromDI(t types.Type) bool {
+	n := named(t)
+	if n == nil || n.Obj().Pkg() == nil || n.Obj().Pkg().Path() != diPath {
+		return false
+	}
+	switch n.Obj().Name() {
+	case "In", "Named", "Secret":
+		return true
+	}
+	return false
+}
+
+// satisfiable reports whether any of the added types would be injected into
+// a parameter of type t.
+func satisfiable(t types.Type, added []types.Type) bool {
+	iface, isInterface := t.Underlying().(*types.Interface)
+	for _, a := range added {
+		if types.Identical(a, t) {
+			return true
+		}
+		if isInterface && types.Implements(a, iface) {
+			return true
+		}
+	}
+	return false
+}
+
+// typeString prints t qualified relative to pkg.
+func typeString(t types.Type, pkg *types.Package) string {
+	return types.TypeString(t, types.RelativeTo(pkg))
+}
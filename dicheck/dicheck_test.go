@@ -0,0 +1,124 @@
+package dicheck_test
+
+import (
+	"fmt"
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/mcvoid/di/dicheck"
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/analysis/passes/inspect"
+	"golang.org/x/tools/go/ast/inspector"
+)
+
+// analysistest loads packages with go/packages, which can't read the export
+// data of every toolchain, so the testdata is type-checked directly.
+
+// stubImporter imports the stand-in for di from testdata, and everything
+// else from the toolchain.
+type stubImporter struct {
+	t    *testing.T
+	fset *token.FileSet
+	std  types.Importer
+}
+
+func (i stubImporter) Import(path string) (*types.Package, error) {
+	if path == "github.com/mcvoid/di" {
+		pkg, _, _ := check(i.t, i.fset, path)
+		return pkg, nil
+	}
+	return i.std.Import(path)
+}
+
+// check parses and type-checks a package in testdata/src.
+func check(t *testing.T, fset *token.FileSet, path string) (*types.Package, []*ast.File, *types.Info) {
+	t.Helper()
+	names, err := filepath.Glob(filepath.Join("testdata", "src", path, "*.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	files := []*ast.File{}
+	for _, name := range names {
+		f, err := parser.ParseFile(fset, name, nil, parser.ParseComments)
+		if err != nil {
+			t.Fatal(err)
+		}
+		files = append(files, f)
+	}
+
+	info := &types.Info{
+		Types:      map[ast.Expr]types.TypeAndValue{},
+		Defs:       map[*ast.Ident]types.Object{},
+		Uses:       map[*ast.Ident]types.Object{},
+		Selections: map[*ast.SelectorExpr]*types.Selection{},
+		Instances:  map[*ast.Ident]types.Instance{},
+	}
+	conf := types.Config{Importer: stubImporter{t, fset, importer.Default()}}
+	pkg, err := conf.Check(path, fset, files, info)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pkg, files, info
+}
+
+var want = regexp.MustCompile("// want `(.*)`")
+
+func TestAnalyzer(t *testing.T) {
+	for _, path := range []string{"app", "lib"} {
+		t.Run(path, func(t *testing.T) {
+			fset := token.NewFileSet()
+			pkg, files, info := check(t, fset, path)
+
+			got := map[string]string{}
+			pass := &analysis.Pass{
+				Analyzer:  dicheck.Analyzer,
+				Fset:      fset,
+				Files:     files,
+				Pkg:       pkg,
+				TypesInfo: info,
+				ResultOf:  map[*analysis.Analyzer]interface{}{inspect.Analyzer: inspector.New(files)},
+				Report: func(d analysis.Diagnostic) {
+					got[line(fset, d.Pos)] = d.Message
+				},
+			}
+			if _, err := dicheck.Analyzer.Run(pass); err != nil {
+				t.Fatal(err)
+			}
+
+			expected := map[string]string{}
+			for _, f := range files {
+				for _, group := range f.Comments {
+					for _, c := range group.List {
+						if m := want.FindStringSubmatch(c.Text); m != nil {
+							expected[line(fset, c.Pos())] = m[1]
+						}
+					}
+				}
+			}
+
+			for pos, pattern := range expected {
+				if !regexp.MustCompile(pattern).MatchString(got[pos]) {
+					t.Errorf("%s: expected %q got %q", pos, pattern, got[pos])
+				}
+			}
+			for pos, msg := range got {
+				if _, ok := expected[pos]; !ok {
+					t.Errorf("%s: expected %v got %q", pos, nil, msg)
+				}
+			}
+		})
+	}
+}
+
+// line formats a position as file:line.
+func line(fset *token.FileSet, pos token.Pos) string {
+	p := fset.Position(pos)
+	return fmt.Sprintf("%s:%d", strings.TrimPrefix(p.Filename, "testdata/src/"), p.Line)
+}
@@ -0,0 +1,66 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"unsafe"
+
+	"github.com/mcvoid/di"
+)
+
+type config struct{}
+
+type Logger struct{}
+
+type Store interface{ Get() string }
+
+type Params struct {
+	di.In
+	Log *Logger
+}
+
+type Server struct{}
+
+func (s *Server) Bind(
+	cfg *config, // want `parameter 0 of exported Server.Bind has unexported type \*config, which other packages can't add`
+	log *Logger,
+	w io.Writer,
+	r fmt.Stringer,
+	store Store, // want `parameter 4 of Server.Bind has type Store, which no dependency added in this package satisfies`
+	writers []io.Writer,
+	p Params,
+	stdctx context.Context,
+	ctx *di.Context, // want `parameter 8 of Server.Bind has type \*github.com/mcvoid/di.Context, which no dependency added in this package satisfies`
+) {
+}
+
+type Raw struct{}
+
+func (Raw) Bind(
+	p unsafe.Pointer, // want `parameter 0 of Raw.Bind has type unsafe.Pointer, which can't be injected`
+	v interface{}, // want `parameter 1 of Raw.Bind has type interface{}, which can't be injected`
+) {
+}
+
+type primary struct{}
+
+func (primary) Qualifier() string { return "primary" }
+
+type Replica struct{}
+
+func (Replica) Bind(log di.Named[*Logger, primary], password di.Secret[primary]) {}
+
+type worker struct{}
+
+func (worker) Bind(cfg *config, b *strings.Builder) {}
+
+func newConfig() (*config, error) { return &config{}, nil }
+
+func wire(ctx *di.Context) {
+	ctx.Add(&Logger{}, os.Stdout)
+	di.AddAs[fmt.Stringer](ctx, &strings.Builder{})
+	ctx.Provide(newConfig)
+}
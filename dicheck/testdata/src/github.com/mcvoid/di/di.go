@@ -0,0 +1,19 @@
+// Package di is a stand-in for the real package, declaring what dicheck looks for.
+package di
+
+type Context struct{}
+
+func (ctx *Context) Add(deps ...interface{}) *Context        { return ctx }
+func (ctx *Context) Replace(deps ...interface{}) error       { return nil }
+func (ctx *Context) SetDefault(deps ...interface{}) *Context { return ctx }
+func (ctx *Context) Provide(fn interface{}) error            { return nil }
+
+func AddAs[I any](ctx *Context, dep I) error { return nil }
+
+type In struct{}
+
+type Qualifier interface{ Qualifier() string }
+
+type Named[T any, Q Qualifier] struct{ Value T }
+
+type Secret[Q Qualifier] struct{ Value string }
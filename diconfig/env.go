@@ -0,0 +1,230 @@
+// Package diconfig populates configuration structs, from environment variables or from JSON and YAML files, and
+// registers them in a di.Context, so that targets and providers can take their configuration as a parameter like any
+// other dependency:
+//
+//	type AppConfig struct {
+//		Port int    `env:"PORT,default=8080"`
+//		DSN  string `env:"DATABASE_URL,required"`
+//	}
+//
+//	if err := diconfig.Env[AppConfig](ctx); err != nil {
+//		log.Fatal(err)
+//	}
+//	ctx.Inject(func(cfg AppConfig) { ... })
+package diconfig
+
+import (
+	"encoding"
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mcvoid/di"
+)
+
+const envTag = "env"
+
+var (
+	// Returned when the target of LoadEnv is not a non-nil pointer to a struct
+	ErrNotStruct = errors.New("is not a pointer to a struct")
+	// Returned when a struct field has an 'env' tag which can't be parsed, or a type which can't be set from a string
+	ErrInvalidTag = errors.New("invalid struct tag")
+	// Returned when a variable marked as required is not set and has no default
+	ErrMissing = errors.New("required variable is not set")
+	// Returned when a variable's value can't be parsed as the type of its field
+	ErrInvalidValue = errors.New("invalid value")
+)
+
+// Env populates a T from environment variables with LoadEnv, and adds it to the Context, so that parameters of type T
+// get the configuration. Nothing is added if the configuration can't be loaded.
+func Env[T any](ctx *di.Context) error {
+	var cfg T
+	if err := LoadEnv(&cfg); err != nil {
+		return err
+	}
+	// unlike Add, whose failures collect in Err with any earlier ones, AddAs
+	// reports only its own
+	return di.AddAs[T](ctx, cfg)
+}
+
+// LoadEnv sets the fields of the struct cfg points to from environment variables, as named by their 'env' struct tags.
+// The tag gives the name of the variable, followed by any of these options, separated by commas:
+//
+//   - required: the variable must be set, unless a default is given
+//   - default=value: the value to use if the variable is not set; as it may contain commas, it must come last
+//
+// Fields without a tag are left alone, except for structs, whose fields are loaded in turn. Fields can be strings,
+// bools, numbers, time.Durations, types implementing encoding.TextUnmarshaler, pointers to any of these, and slices of
+// them, which are given as comma-separated lists. A field whose variable isn't set keeps its value.
+//
+// Every variable which is missing or can't be parsed is reported, in an error wrapping ErrMissing or ErrInvalidValue
+// which names the variable.
+func LoadEnv(cfg interface{}) error {
+	v := reflect.ValueOf(cfg)
+	if v.Kind() != reflect.Pointer || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("%T %w", cfg, ErrNotStruct)
+	}
+	return loadEnv(v.Elem())
+}
+
+// envOptions are the options given by a field's 'env' struct tag.
+type envOptions struct {
+	name       string
+	required   bool
+	def        string
+	hasDefault bool
+}
+
+// parseEnvTag parses the options in an 'env' struct tag.
+func parseEnvTag(tag string) (envOptions, error) {
+	name, rest, _ := strings.Cut(tag, ",")
+	opts := envOptions{name: strings.TrimSpace(name)}
+	if opts.name == "" {
+		return opts, fmt.Errorf("%w: no variable name in %q", ErrInvalidTag, tag)
+	}
+	for rest != "" {
+		var opt string
+		opt, rest, _ = strings.Cut(rest, ",")
+		opt = strings.TrimSpace(opt)
+		switch {
+		case opt == "required":
+			opts.required = true
+		case strings.HasPrefix(opt, "default="):
+			// the rest of the tag, commas and all
+			opts.def = strings.TrimPrefix(opt, "default=")
+			if rest != "" {
+				opts.def += "," + rest
+			}
+			opts.hasDefault, rest = true, ""
+		default:
+			return opts, fmt.Errorf("%w: unknown option %q", ErrInvalidTag, opt)
+		}
+	}
+	return opts, nil
+}
+
+// loadEnv sets the tagged fields of the struct v, collecting every error.
+func loadEnv(v reflect.Value) error {
+	errs := []error{}
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		tag, ok := field.Tag.Lookup(envTag)
+		if !ok {
+			if field.Type.Kind() == reflect.Struct && !isText(field.Type) {
+				if err := loadEnv(v.Field(i)); err != nil {
+					errs = append(errs, err)
+				}
+			}
+			continue
+		}
+		if tag == "-" {
+			continue
+		}
+
+		opts, err := parseEnvTag(tag)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("field %s: %w", field.Name, err))
+			continue
+		}
+		s, ok := os.LookupEnv(opts.name)
+		if !ok && opts.hasDefault {
+			s, ok = opts.def, true
+		}
+		if !ok && opts.required {
+			errs = append(errs, fmt.Errorf("%w: %s", ErrMissing, opts.name))
+			continue
+		}
+		if !ok {
+			continue
+		}
+		if err := setString(v.Field(i), s); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", opts.name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+var (
+	durationType      = reflect.TypeOf(time.Duration(0))
+	textUnmarshalType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+// isText reports whether values of t parse themselves from text.
+func isText(t reflect.Type) bool {
+	return reflect.PointerTo(t).Implements(textUnmarshalType)
+}
+
+// setString parses s as the type of v, and sets v to the result.
+func setString(v reflect.Value, s string) error {
+	if isText(v.Type()) {
+		if err := v.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(s)); err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidValue, err)
+		}
+		return nil
+	}
+
+	var err error
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(s)
+	case reflect.Bool:
+		var b bool
+		if b, err = strconv.ParseBool(s); err == nil {
+			v.SetBool(b)
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if v.Type() == durationType {
+			var d time.Duration
+			if d, err = time.ParseDuration(s); err == nil {
+				v.SetInt(int64(d))
+			}
+			break
+		}
+		var n int64
+		if n, err = strconv.ParseInt(s, 0, v.Type().Bits()); err == nil {
+			v.SetInt(n)
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		var n uint64
+		if n, err = strconv.ParseUint(s, 0, v.Type().Bits()); err == nil {
+			v.SetUint(n)
+		}
+	case reflect.Float32, reflect.Float64:
+		var f float64
+		if f, err = strconv.ParseFloat(s, v.Type().Bits()); err == nil {
+			v.SetFloat(f)
+		}
+	case reflect.Pointer:
+		p := reflect.New(v.Type().Elem())
+		if err := setString(p.Elem(), s); err != nil {
+			return err
+		}
+		v.Set(p)
+	case reflect.Slice:
+		parts := []string{}
+		if s != "" {
+			parts = strings.Split(s, ",")
+		}
+		slice := reflect.MakeSlice(v.Type(), len(parts), len(parts))
+		for i, part := range parts {
+			if err := setString(slice.Index(i), strings.TrimSpace(part)); err != nil {
+				return err
+			}
+		}
+		v.Set(slice)
+	default:
+		return fmt.Errorf("%w: can't set %v from a string", ErrInvalidTag, v.Type())
+	}
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidValue, err)
+	}
+	return nil
+}
@@ -0,0 +1,136 @@
+package diconfig_test
+
+import (
+	"errors"
+	"net"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mcvoid/di"
+	"github.com/mcvoid/di/diconfig"
+)
+
+type dbConfig struct {
+	DSN     string        `env:"TEST_DSN,required"`
+	Timeout time.Duration `env:"TEST_TIMEOUT,default=5s"`
+}
+
+type appConfig struct {
+	Port    int      `env:"TEST_PORT,default=8080"`
+	Debug   bool     `env:"TEST_DEBUG"`
+	Hosts   []string `env:"TEST_HOSTS,default=a,b"`
+	IP      net.IP   `env:"TEST_IP"`
+	Ratio   *float64 `env:"TEST_RATIO"`
+	Ignored string   `env:"-"`
+	DB      dbConfig
+}
+
+func TestLoadEnv(t *testing.T) {
+	t.Run("fields are set from the environment", func(t *testing.T) {
+		t.Setenv("TEST_PORT", "9000")
+		t.Setenv("TEST_DEBUG", "true")
+		t.Setenv("TEST_HOSTS", "x, y, z")
+		t.Setenv("TEST_IP", "10.0.0.1")
+		t.Setenv("TEST_RATIO", "0.5")
+		t.Setenv("TEST_DSN", "postgres://")
+		t.Setenv("TEST_TIMEOUT", "1m")
+
+		cfg := appConfig{}
+		if err := diconfig.LoadEnv(&cfg); err != nil {
+			t.Fatalf("expected %v got %v", nil, err)
+		}
+		ratio := 0.5
+		expected := appConfig{
+			Port:  9000,
+			Debug: true,
+			Hosts: []string{"x", "y", "z"},
+			IP:    net.ParseIP("10.0.0.1"),
+			Ratio: &ratio,
+			DB:    dbConfig{DSN: "postgres://", Timeout: time.Minute},
+		}
+		if !reflect.DeepEqual(cfg, expected) {
+			t.Errorf("expected %+v got %+v", expected, cfg)
+		}
+	})
+
+	t.Run("defaults are used for unset variables", func(t *testing.T) {
+		t.Setenv("TEST_DSN", "postgres://")
+		cfg := appConfig{Debug: true}
+		if err := diconfig.LoadEnv(&cfg); err != nil {
+			t.Fatalf("expected %v got %v", nil, err)
+		}
+		if cfg.Port != 8080 || cfg.DB.Timeout != 5*time.Second || !cfg.Debug {
+			t.Errorf("expected defaults got %+v", cfg)
+		}
+		if !reflect.DeepEqual(cfg.Hosts, []string{"a", "b"}) {
+			t.Errorf("expected %v got %v", []string{"a", "b"}, cfg.Hosts)
+		}
+	})
+
+	t.Run("every problem is reported", func(t *testing.T) {
+		t.Setenv("TEST_PORT", "eighty")
+		cfg := appConfig{}
+		err := diconfig.LoadEnv(&cfg)
+		if !errors.Is(err, diconfig.ErrMissing) || !errors.Is(err, diconfig.ErrInvalidValue) {
+			t.Errorf("expected %v and %v got %v", diconfig.ErrMissing, diconfig.ErrInvalidValue, err)
+		}
+		if err == nil || !strings.Contains(err.Error(), "TEST_DSN") || !strings.Contains(err.Error(), "TEST_PORT") {
+			t.Errorf("expected variables to be named got %v", err)
+		}
+	})
+
+	t.Run("target must be a pointer to a struct", func(t *testing.T) {
+		if err := diconfig.LoadEnv(appConfig{}); !errors.Is(err, diconfig.ErrNotStruct) {
+			t.Errorf("expected %v got %v", diconfig.ErrNotStruct, err)
+		}
+	})
+
+	t.Run("invalid tags are reported", func(t *testing.T) {
+		cfg := struct {
+			A string   `env:"TEST_A,optional"`
+			B chan int `env:"TEST_B,default=1"`
+		}{}
+		err := diconfig.LoadEnv(&cfg)
+		if !errors.Is(err, diconfig.ErrInvalidTag) || !strings.Contains(err.Error(), "TEST_B") {
+			t.Errorf("expected %v got %v", diconfig.ErrInvalidTag, err)
+		}
+	})
+}
+
+func TestEnv(t *testing.T) {
+	t.Run("configuration is injected", func(t *testing.T) {
+		t.Setenv("TEST_DSN", "postgres://")
+		ctx := di.New()
+		if err := diconfig.Env[appConfig](ctx); err != nil {
+			t.Fatalf("expected %v got %v", nil, err)
+		}
+		ctx.Inject(func(cfg appConfig) {
+			if cfg.DB.DSN != "postgres://" {
+				t.Errorf("expected %v got %v", "postgres://", cfg.DB.DSN)
+			}
+		})
+	})
+
+	t.Run("earlier failures aren't reported", func(t *testing.T) {
+		t.Setenv("TEST_DSN", "postgres://")
+		ctx := di.New(di.WithNilPolicy(di.NilError)).Add(nil)
+		if err := diconfig.Env[appConfig](ctx); err != nil {
+			t.Errorf("expected %v got %v", nil, err)
+		}
+		if !di.Has[appConfig](ctx) {
+			t.Errorf("expected %v to be registered", "appConfig")
+		}
+	})
+
+	t.Run("nothing is added on error", func(t *testing.T) {
+		ctx := di.New()
+		if err := diconfig.Env[appConfig](ctx); !errors.Is(err, diconfig.ErrMissing) {
+			t.Errorf("expected %v got %v", diconfig.ErrMissing, err)
+		}
+		if di.Has[appConfig](ctx) {
+			t.Errorf("expected %v to not be registered", "appConfig")
+		}
+	})
+}
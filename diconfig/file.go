@@ -0,0 +1,245 @@
+package diconfig
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+
+	"github.com/mcvoid/di"
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	// Returned, wrapped in a SectionError, when a section which isn't optional is missing from a configuration file
+	ErrMissingSection = errors.New("section is missing")
+	// Returned when a configuration file's extension isn't one File can read
+	ErrFormat = errors.New("unsupported file format")
+)
+
+// Validator is implemented by configuration structs which check their own values, such as that a port is in range.
+// Validate is called each time the configuration is loaded, so a bad value stops the file from being used.
+type Validator interface {
+	Validate() error
+}
+
+// SectionError is returned when a section of a configuration file can't be used: because it's missing, it can't be
+// decoded into its struct, or the struct's Validate method returns an error.
+type SectionError struct {
+	// The file the section was read from
+	Path string
+	// The name of the section, or "" for the whole file
+	Section string
+	// The reason the section can't be used
+	Err error
+}
+
+func (e *SectionError) Error() string {
+	if e.Section == "" {
+		return fmt.Sprintf("%s: %v", e.Path, e.Err)
+	}
+	return fmt.Sprintf("%s: section %q: %v", e.Path, e.Section, e.Err)
+}
+
+func (e *SectionError) Unwrap() error {
+	return e.Err
+}
+
+// Section binds part of a configuration file to a configuration struct. See Bind.
+type Section struct {
+	name     string
+	typ      reflect.Type
+	optional bool
+	// add registers a decoded struct, reporting only its own failure
+	add func(ctx *di.Context, cfg interface{}) error
+}
+
+// Bind returns a Section which loads the top-level key name of a configuration file into a T. An empty name loads the
+// whole file.
+func Bind[T any](name string) Section {
+	return Section{
+		name: name,
+		typ:  reflect.TypeOf((*T)(nil)).Elem(),
+		add: func(ctx *di.Context, cfg interface{}) error {
+			return di.AddAs[T](ctx, cfg.(T))
+		},
+	}
+}
+
+// Optional lets the section be missing from the file, in which case nothing is added to the Context for it.
+func (s Section) Optional() Section {
+	s.optional = true
+	return s
+}
+
+// File reads a JSON or YAML configuration file, as told by its extension (.json, .yaml or .yml), decodes each of the
+// sections into its struct, and adds them to the Context, so that parameters of those types get the configuration:
+//
+//	err := diconfig.File(ctx, "config.yaml",
+//		diconfig.Bind[DBConfig]("database"),
+//		diconfig.Bind[CacheConfig]("cache").Optional(),
+//	)
+//
+// Structs which implement Validator are validated once decoded. Every section which is missing, can't be decoded, or
+// is invalid is reported, as a SectionError, and if there are any, nothing is added. To change the configuration
+// once it is in use, see Reload and Watcher.
+func File(ctx *di.Context, path string, sections ...Section) error {
+	configs, err := load(path, sections)
+	if err != nil {
+		return err
+	}
+	// Err would also report earlier failures unrelated to the configuration
+	errs := []error{}
+	for _, c := range configs {
+		errs = append(errs, c.section.add(ctx, c.cfg))
+	}
+	return errors.Join(errs...)
+}
+
+// Reload is like File, but replaces the configuration with Context.Reload, so that providers which depend on it are
+// rebuilt with the new values. If the file can't be used, or the providers fail, the Context is left as it was.
+func Reload(ctx *di.Context, path string, sections ...Section) error {
+	configs, err := load(path, sections)
+	if err != nil {
+		return err
+	}
+	cfgs := make([]interface{}, len(configs))
+	for i, c := range configs {
+		cfgs[i] = c.cfg
+	}
+	return ctx.Reload(cfgs...)
+}
+
+// load reads a configuration file and decodes and validates its sections,
+// returning the configuration structs.
+func load(path string, sections []Section) ([]loaded, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var doc document
+	switch filepath.Ext(path) {
+	case ".json":
+		doc, err = jsonDocument(data)
+	case ".yaml", ".yml":
+		doc, err = yamlDocument(data)
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrFormat, path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	configs := []loaded{}
+	errs := []error{}
+	for _, s := range sections {
+		cfg := reflect.New(s.typ)
+		found, err := doc.decode(s.name, cfg.Interface())
+		if err == nil && !found && !s.optional {
+			err = ErrMissingSection
+		}
+		if err == nil && found {
+			err = validate(cfg.Interface())
+		}
+		if err != nil {
+			errs = append(errs, &SectionError{Path: path, Section: s.name, Err: err})
+			continue
+		}
+		if found {
+			configs = append(configs, loaded{s, cfg.Elem().Interface()})
+		}
+	}
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	return configs, nil
+}
+
+// loaded is a configuration struct decoded from its section.
+type loaded struct {
+	section Section
+	cfg     interface{}
+}
+
+// validate calls the Validate method of a configuration struct, given a
+// pointer to it, if it has one.
+func validate(cfg interface{}) error {
+	if v, ok := cfg.(Validator); ok {
+		return v.Validate()
+	}
+	return nil
+}
+
+// document is a parsed configuration file, which decodes its sections. A
+// file which isn't a map, such as a list, can still be decoded whole, but
+// decoding a named section from it fails.
+type document interface {
+	// decode decodes the named section into cfg, reporting whether the
+	// section is there.
+	decode(name string, cfg interface{}) (bool, error)
+}
+
+type jsonDoc struct {
+	whole    []byte
+	sections map[string]json.RawMessage
+	err      error
+}
+
+func jsonDocument(data []byte) (document, error) {
+	var raw json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+	doc := jsonDoc{whole: data}
+	doc.err = json.Unmarshal(data, &doc.sections)
+	return doc, nil
+}
+
+func (d jsonDoc) decode(name string, cfg interface{}) (bool, error) {
+	data := d.whole
+	if name != "" {
+		if d.err != nil {
+			return false, d.err
+		}
+		section, ok := d.sections[name]
+		if !ok || bytes.Equal(section, []byte("null")) {
+			return false, nil
+		}
+		data = section
+	}
+	return true, json.Unmarshal(data, cfg)
+}
+
+type yamlDoc struct {
+	whole    yaml.Node
+	sections map[string]yaml.Node
+	err      error
+}
+
+func yamlDocument(data []byte) (document, error) {
+	doc := yamlDoc{}
+	if err := yaml.Unmarshal(data, &doc.whole); err != nil {
+		return nil, err
+	}
+	doc.err = doc.whole.Decode(&doc.sections)
+	return doc, nil
+}
+
+func (d yamlDoc) decode(name string, cfg interface{}) (bool, error) {
+	node := d.whole
+	if name != "" {
+		if d.err != nil {
+			return false, d.err
+		}
+		section, ok := d.sections[name]
+		if !ok || section.Tag == "!!null" {
+			return false, nil
+		}
+		node = section
+	}
+	return true, node.Decode(cfg)
+}
@@ -0,0 +1,191 @@
+package diconfig_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mcvoid/di"
+	"github.com/mcvoid/di/diconfig"
+)
+
+type serverConfig struct {
+	Host string `json:"host" yaml:"host"`
+	Port int    `json:"port" yaml:"port"`
+}
+
+func (c *serverConfig) Validate() error {
+	if c.Port <= 0 {
+		return errors.New("port must be positive")
+	}
+	return nil
+}
+
+type cacheConfig struct {
+	Size int `json:"size" yaml:"size"`
+}
+
+// writeFile writes a configuration file to a temporary directory.
+func writeFile(t *testing.T, name, contents string) string {
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(contents), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestFile(t *testing.T) {
+	formats := map[string]string{
+		"config.json": `{"server": {"host": "localhost", "port": 8080}, "cache": {"size": 10}}`,
+		"config.yaml": "server:\n  host: localhost\n  port: 8080\ncache:\n  size: 10\n",
+	}
+	for name, contents := range formats {
+		t.Run("sections are injected from "+name, func(t *testing.T) {
+			path := writeFile(t, name, contents)
+			ctx := di.New()
+			err := diconfig.File(ctx, path, diconfig.Bind[serverConfig]("server"), diconfig.Bind[cacheConfig]("cache"))
+			if err != nil {
+				t.Fatalf("expected %v got %v", nil, err)
+			}
+			ctx.Inject(func(server serverConfig, cache cacheConfig) {
+				expected := serverConfig{Host: "localhost", Port: 8080}
+				if server != expected {
+					t.Errorf("expected %v got %v", expected, server)
+				}
+				if cache.Size != 10 {
+					t.Errorf("expected %v got %v", 10, cache.Size)
+				}
+			})
+		})
+	}
+
+	t.Run("whole file", func(t *testing.T) {
+		path := writeFile(t, "server.yml", "host: example.com\nport: 443\n")
+		ctx := di.New()
+		if err := diconfig.File(ctx, path, diconfig.Bind[serverConfig]("")); err != nil {
+			t.Fatalf("expected %v got %v", nil, err)
+		}
+		if cfg := di.MustResolve[serverConfig](ctx); cfg.Host != "example.com" {
+			t.Errorf("expected %v got %v", "example.com", cfg.Host)
+		}
+	})
+
+	t.Run("whole files which aren't maps", func(t *testing.T) {
+		for name, contents := range map[string]string{
+			"ports.json": `[80, 443]`,
+			"ports.yaml": "- 80\n- 443\n",
+		} {
+			path := writeFile(t, name, contents)
+			ctx := di.New()
+			if err := diconfig.File(ctx, path, diconfig.Bind[[]int]("")); err != nil {
+				t.Fatalf("expected %v got %v", nil, err)
+			}
+			if ports := di.MustResolve[[]int](ctx); len(ports) != 2 || ports[1] != 443 {
+				t.Errorf("expected %v got %v", []int{80, 443}, ports)
+			}
+
+			err := diconfig.File(di.New(), path, diconfig.Bind[serverConfig]("server"))
+			var sectionErr *diconfig.SectionError
+			if !errors.As(err, &sectionErr) {
+				t.Errorf("expected %T got %v", sectionErr, err)
+			}
+		}
+	})
+
+	t.Run("earlier failures aren't reported", func(t *testing.T) {
+		path := writeFile(t, "server.json", `{"port": 443}`)
+		ctx := di.New(di.WithNilPolicy(di.NilError)).Add(nil)
+		if err := diconfig.File(ctx, path, diconfig.Bind[serverConfig]("")); err != nil {
+			t.Errorf("expected %v got %v", nil, err)
+		}
+		if !di.Has[serverConfig](ctx) {
+			t.Errorf("expected %v to be registered", "serverConfig")
+		}
+	})
+
+	t.Run("missing sections are reported", func(t *testing.T) {
+		path := writeFile(t, "config.json", `{"cache": {"size": 10}}`)
+		ctx := di.New()
+		err := diconfig.File(ctx, path, diconfig.Bind[serverConfig]("server"), diconfig.Bind[cacheConfig]("cache"))
+		if !errors.Is(err, diconfig.ErrMissingSection) {
+			t.Errorf("expected %v got %v", diconfig.ErrMissingSection, err)
+		}
+		var sectionErr *diconfig.SectionError
+		if !errors.As(err, &sectionErr) || sectionErr.Section != "server" || sectionErr.Path != path {
+			t.Errorf("expected section %v got %v", "server", err)
+		}
+		if di.Has[cacheConfig](ctx) {
+			t.Errorf("expected nothing to be added")
+		}
+	})
+
+	t.Run("optional sections can be missing", func(t *testing.T) {
+		path := writeFile(t, "config.yaml", "server:\n  port: 80\n")
+		ctx := di.New()
+		err := diconfig.File(ctx, path, diconfig.Bind[serverConfig]("server"), diconfig.Bind[cacheConfig]("cache").Optional())
+		if err != nil {
+			t.Errorf("expected %v got %v", nil, err)
+		}
+		if !di.Has[serverConfig](ctx) || di.Has[cacheConfig](ctx) {
+			t.Errorf("expected only the server config got %v", ctx.Registrations())
+		}
+	})
+
+	t.Run("invalid configuration is reported", func(t *testing.T) {
+		path := writeFile(t, "config.json", `{"server": {"port": 80}}`)
+		ctx := di.New()
+		if err := diconfig.File(ctx, path, diconfig.Bind[serverConfig]("server")); err != nil {
+			t.Fatalf("expected %v got %v", nil, err)
+		}
+
+		// reloading a bad file keeps the last good configuration
+		os.WriteFile(path, []byte(`{"server": {"port": -1}}`), 0o600)
+		err := diconfig.File(ctx, path, diconfig.Bind[serverConfig]("server"))
+		var sectionErr *diconfig.SectionError
+		if !errors.As(err, &sectionErr) {
+			t.Errorf("expected %T got %v", sectionErr, err)
+		}
+		if cfg := di.MustResolve[serverConfig](ctx); cfg.Port != 80 {
+			t.Errorf("expected %v got %v", 80, cfg.Port)
+		}
+	})
+
+	t.Run("undecodable sections are reported", func(t *testing.T) {
+		path := writeFile(t, "config.json", `{"server": {"port": "eighty"}}`)
+		err := diconfig.File(di.New(), path, diconfig.Bind[serverConfig]("server"))
+		var sectionErr *diconfig.SectionError
+		if !errors.As(err, &sectionErr) {
+			t.Errorf("expected %T got %v", sectionErr, err)
+		}
+	})
+
+	t.Run("unknown formats are rejected", func(t *testing.T) {
+		path := writeFile(t, "config.toml", "")
+		if err := diconfig.File(di.New(), path); !errors.Is(err, diconfig.ErrFormat) {
+			t.Errorf("expected %v got %v", diconfig.ErrFormat, err)
+		}
+	})
+}
+
+func TestReload(t *testing.T) {
+	t.Run("providers are rebuilt with the new configuration", func(t *testing.T) {
+		path := writeFile(t, "config.json", `{"server": {"port": 80}}`)
+		ctx := di.New()
+		if err := diconfig.File(ctx, path, diconfig.Bind[serverConfig]("server")); err != nil {
+			t.Fatalf("expected %v got %v", nil, err)
+		}
+		ctx.Provide(func(cfg serverConfig) *int { return &cfg.Port })
+		if port := di.MustResolve[*int](ctx); *port != 80 {
+			t.Errorf("expected %v got %v", 80, *port)
+		}
+
+		os.WriteFile(path, []byte(`{"server": {"port": 8080}}`), 0o600)
+		if err := diconfig.Reload(ctx, path, diconfig.Bind[serverConfig]("server")); err != nil {
+			t.Fatalf("expected %v got %v", nil, err)
+		}
+		if port := di.MustResolve[*int](ctx); *port != 8080 {
+			t.Errorf("expected %v got %v", 8080, *port)
+		}
+	})
+}
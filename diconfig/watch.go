@@ -0,0 +1,89 @@
+package diconfig
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/mcvoid/di"
+)
+
+// Watcher reloads a configuration file with Reload when it changes, so that settings such as log levels and pool sizes
+// take effect without a restart:
+//
+//	w := &diconfig.Watcher{
+//		Path:     "config.yaml",
+//		Sections: []diconfig.Section{diconfig.Bind[LogConfig]("log")},
+//		Interval: 5 * time.Second,
+//		Signals:  []os.Signal{syscall.SIGHUP},
+//	}
+//	go w.Run(stdctx, ctx)
+type Watcher struct {
+	// The configuration file, and the sections to load from it
+	Path     string
+	Sections []Section
+	// How often to check whether the file's contents have changed; zero disables checking
+	Interval time.Duration
+	// Signals which make the file be reloaded whether it has changed or not, such as syscall.SIGHUP
+	Signals []os.Signal
+	// Called with the error each time the file can't be reloaded, such as when it has been saved half-edited; the
+	// configuration in use is kept either way
+	OnError func(error)
+}
+
+// Run reloads the file, then watches it until stdctx is done, and returns stdctx's error. The file would usually have
+// been loaded by File already, so that the application can stop if the configuration is bad at startup.
+func (w *Watcher) Run(stdctx context.Context, ctx *di.Context) error {
+	var signals chan os.Signal
+	if len(w.Signals) > 0 {
+		signals = make(chan os.Signal, 1)
+		signal.Notify(signals, w.Signals...)
+		defer signal.Stop(signals)
+	}
+	var tick <-chan time.Time
+	if w.Interval > 0 {
+		ticker := time.NewTicker(w.Interval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	// so that changes made before the first check aren't missed
+	last, _ := os.ReadFile(w.Path)
+	if err := Reload(ctx, w.Path, w.Sections...); err != nil {
+		w.report(err)
+	}
+	for {
+		var data []byte
+		select {
+		case <-stdctx.Done():
+			return stdctx.Err()
+		case <-signals:
+			// if the file can't be read, Reload reports it
+			data, _ = os.ReadFile(w.Path)
+		case <-tick:
+			var err error
+			if data, err = os.ReadFile(w.Path); err != nil {
+				w.report(err)
+				continue
+			}
+			if bytes.Equal(data, last) {
+				continue
+			}
+		}
+
+		// what was compared, so a change made since isn't taken as seen
+		last = data
+		if err := Reload(ctx, w.Path, w.Sections...); err != nil {
+			w.report(err)
+		}
+	}
+}
+
+// report passes an error to OnError, if it is set.
+func (w *Watcher) report(err error) {
+	if w.OnError != nil {
+		w.OnError(err)
+	}
+}
@@ -0,0 +1,54 @@
+package diconfig_test
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/mcvoid/di"
+	"github.com/mcvoid/di/diconfig"
+)
+
+func TestWatcher(t *testing.T) {
+	t.Run("changes to the file are reloaded", func(t *testing.T) {
+		path := writeFile(t, "config.yaml", "server:\n  port: 80\n")
+		sections := []diconfig.Section{diconfig.Bind[serverConfig]("server")}
+		ctx := di.New()
+		if err := diconfig.File(ctx, path, sections...); err != nil {
+			t.Fatalf("expected %v got %v", nil, err)
+		}
+
+		errs := make(chan error, 10)
+		w := &diconfig.Watcher{Path: path, Sections: sections, Interval: time.Millisecond, OnError: func(err error) { errs <- err }}
+		stdctx, cancel := context.WithCancel(context.Background())
+		done := make(chan error)
+		go func() { done <- w.Run(stdctx, ctx) }()
+
+		// an invalid edit is reported and ignored
+		os.WriteFile(path, []byte("server:\n  port: -1\n"), 0o600)
+		select {
+		case <-errs:
+		case <-time.After(5 * time.Second):
+			t.Errorf("expected an error to be reported")
+		}
+		if cfg := di.MustResolve[serverConfig](ctx); cfg.Port != 80 {
+			t.Errorf("expected %v got %v", 80, cfg.Port)
+		}
+
+		os.WriteFile(path, []byte("server:\n  port: 8080\n"), 0o600)
+		deadline := time.After(5 * time.Second)
+		for di.MustResolve[serverConfig](ctx).Port != 8080 {
+			select {
+			case <-deadline:
+				t.Fatalf("expected %v got %v", 8080, di.MustResolve[serverConfig](ctx).Port)
+			case <-time.After(time.Millisecond):
+			}
+		}
+
+		cancel()
+		if err := <-done; err != context.Canceled {
+			t.Errorf("expected %v got %v", context.Canceled, err)
+		}
+	})
+}
@@ -0,0 +1,40 @@
+// Package diotel traces the wiring of a di.Context with OpenTelemetry, so that slow startup caused by a single
+// constructor shows up in traces.
+//
+//	ctx := di.New(diotel.WithTracing(otel.Tracer("app")))
+//
+// Each provider call, and each dependency and hook run by Start, gets a span of its own.
+package diotel
+
+import (
+	"context"
+
+	"github.com/mcvoid/di"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// WithTracing makes a Context record spans with tracer.
+func WithTracing(tracer trace.Tracer) di.Option {
+	return di.WithTracer(Tracer(tracer))
+}
+
+// Tracer adapts an OpenTelemetry tracer to a di.Tracer. Spans which end in an error record it and have an error status.
+func Tracer(tracer trace.Tracer) di.Tracer {
+	return otelTracer{tracer}
+}
+
+type otelTracer struct {
+	tracer trace.Tracer
+}
+
+func (t otelTracer) Trace(ctx context.Context, name string) (context.Context, func(error)) {
+	ctx, span := t.tracer.Start(ctx, name)
+	return ctx, func(err error) {
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+	}
+}
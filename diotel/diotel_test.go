@@ -0,0 +1,67 @@
+package diotel_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/mcvoid/di"
+	"github.com/mcvoid/di/diotel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// a dependency which records being started
+type server struct {
+	err error
+}
+
+func (s *server) Start(ctx context.Context) error {
+	return s.err
+}
+
+func TestWithTracing(t *testing.T) {
+	t.Run("provider spans", func(t *testing.T) {
+		recorder := tracetest.NewSpanRecorder()
+		tracer := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)).Tracer("test")
+
+		ctx := di.New(diotel.WithTracing(tracer)).Add(os.Stdout)
+		ctx.Provide(func(f *os.File) *bytes.Buffer { return &bytes.Buffer{} })
+		ctx.Provide(func(b *bytes.Buffer) *server { return &server{} })
+		ctx.Inject(func(s *server) {})
+
+		spans := recorder.Ended()
+		if len(spans) != 2 {
+			t.Fatalf("expected %v got %v", 2, len(spans))
+		}
+		if spans[0].Name() != "provide *bytes.Buffer" || spans[1].Name() != "provide *diotel_test.server" {
+			t.Errorf("expected %v got %v", "provider spans", []string{spans[0].Name(), spans[1].Name()})
+		}
+		if spans[0].Parent().SpanID() != spans[1].SpanContext().SpanID() {
+			t.Errorf("expected nested provider span to be a child")
+		}
+	})
+
+	t.Run("start spans", func(t *testing.T) {
+		recorder := tracetest.NewSpanRecorder()
+		tracer := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)).Tracer("test")
+
+		fail := errors.New("failed")
+		ctx := di.New(diotel.WithTracing(tracer)).Add(&server{err: fail})
+		err := ctx.Start(context.Background())
+		if !errors.Is(err, fail) {
+			t.Errorf("expected %v got %v", fail, err)
+		}
+
+		spans := recorder.Ended()
+		if len(spans) != 1 {
+			t.Fatalf("expected %v got %v", 1, len(spans))
+		}
+		if spans[0].Name() != "start *diotel_test.server" || spans[0].Status().Code != codes.Error {
+			t.Errorf("expected %v got %v %v", "error span", spans[0].Name(), spans[0].Status())
+		}
+	})
+}
@@ -0,0 +1,67 @@
+package di
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strings"
+	"text/tabwriter"
+)
+
+// Dump writes a table of the dependencies registered in the Context to w, for troubleshooting. Each row gives the type
+// and name a dependency is registered under, the dynamic type of its value, whether it is a value or a provider, and a
+// provider's lifetime. A provider which hasn't been called yet has no value, shown as "-". Rows are sorted by type, so
+// the output is stable. Only registrations in the Context itself are included, not those of its parent, and no
+// providers are called.
+func (ctx *Context) Dump(w io.Writer) error {
+	deps := ctx.load().deps
+	keys := make([]key, 0, len(deps))
+	for k := range deps {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool { return nodeID(keys[i].typ, keys[i].name) < nodeID(keys[j].typ, keys[j].name) })
+
+	b := &strings.Builder{}
+	tw := tabwriter.NewWriter(b, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "TYPE\tVALUE\tORIGIN\tLIFETIME")
+	for _, k := range keys {
+		e := deps[k]
+		origin, lifetime := "value", "-"
+		val, ok := e.val, true
+		if e.prov != nil {
+			origin, lifetime = "provider", string(entryKind(e))
+			val, _, ok = e.prov.cached()
+			if ok && e.field != nil {
+				val = val.FieldByIndex(e.field)
+			}
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", nodeID(k.typ, k.name), dynamicType(val, ok), origin, lifetime)
+	}
+
+	tw.Flush()
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// String returns the table written by Dump.
+func (ctx *Context) String() string {
+	b := &strings.Builder{}
+	ctx.Dump(b)
+	return b.String()
+}
+
+// dynamicType describes the type of the value in val, or "-" if there isn't
+// one.
+func dynamicType(val reflect.Value, ok bool) string {
+	if !ok || !val.IsValid() {
+		return "-"
+	}
+	if val.Kind() == reflect.Interface {
+		if val.IsNil() {
+			return "nil"
+		}
+		val = val.Elem()
+	}
+	return val.Type().String()
+}
@@ -0,0 +1,48 @@
+package di_test
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/mcvoid/di"
+)
+
+func TestDump(t *testing.T) {
+	t.Run("table", func(t *testing.T) {
+		ctx := di.New().AddNamed("out", os.Stdout)
+		di.AddAs[io.Writer](ctx, &bytes.Buffer{}, di.InterfaceOnly())
+		ctx.Provide(func() fmt.Stringer { return &strings.Builder{} })
+		ctx.Provide(func() *strings.Reader { return nil }, di.Transient())
+		di.Resolve[fmt.Stringer](ctx)
+
+		b := &bytes.Buffer{}
+		err := ctx.Dump(b)
+		if err != nil {
+			t.Errorf("expected %v got %v", nil, err)
+		}
+
+		expected := `TYPE             VALUE             ORIGIN    LIFETIME
+*os.File "out"   *os.File          value     -
+*strings.Reader  -                 provider  transient
+fmt.Stringer     *strings.Builder  provider  singleton
+io.Writer        *bytes.Buffer     value     -
+`
+		if b.String() != expected {
+			t.Errorf("expected %v got %v", expected, b.String())
+		}
+		if ctx.String() != expected {
+			t.Errorf("expected %v got %v", expected, ctx.String())
+		}
+	})
+
+	t.Run("empty", func(t *testing.T) {
+		expected := "TYPE  VALUE  ORIGIN  LIFETIME\n"
+		if actual := di.New().String(); actual != expected {
+			t.Errorf("expected %v got %v", expected, actual)
+		}
+	})
+}
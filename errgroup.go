@@ -0,0 +1,59 @@
+package di
+
+import (
+	"context"
+	"sync"
+)
+
+// Group runs injected targets in goroutines which are started and torn down together, like errgroup.Group. A Group is
+// made with Context.Group.
+type Group struct {
+	ctx    *Context
+	stdctx context.Context
+	cancel context.CancelFunc
+
+	wg   sync.WaitGroup
+	once sync.Once
+	err  error
+}
+
+// Group returns a Group whose targets are injected from ctx and given a context.Context derived from stdctx, which is
+// canceled as soon as one of them fails, or once Wait returns:
+//
+//	g := ctx.Group(stdctx)
+//	g.Go(func(stdctx context.Context, srv *Server) error { return srv.Run(stdctx) })
+//	g.Go(func(stdctx context.Context, c *Consumer) error { return c.Consume(stdctx) })
+//	err := g.Wait()
+func (ctx *Context) Group(stdctx context.Context) *Group {
+	stdctx, cancel := context.WithCancel(stdctx)
+	return &Group{ctx: ctx, stdctx: stdctx, cancel: cancel}
+}
+
+// Go injects target in a new goroutine, as InjectContext does with the Group's context.Context. If target can't be
+// injected or returns an error, and it's the Group's first error, the Group's context.Context is canceled, and Wait
+// returns the error.
+func (g *Group) Go(target interface{}) {
+	g.wg.Add(1)
+	go func() {
+		defer g.wg.Done()
+		if err := g.ctx.InjectContext(g.stdctx, target); err != nil {
+			g.once.Do(func() {
+				g.err = err
+				g.cancel()
+			})
+		}
+	}()
+}
+
+// Wait waits for every target started with Go to return, then cancels the Group's context.Context, and returns the
+// first error, if any.
+func (g *Group) Wait() error {
+	g.wg.Wait()
+	g.cancel()
+	return g.err
+}
+
+// Context returns the context.Context the Group's targets are given.
+func (g *Group) Context() context.Context {
+	return g.stdctx
+}
@@ -0,0 +1,59 @@
+package di_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/mcvoid/di"
+)
+
+func TestErrGroup(t *testing.T) {
+	t.Run("targets are injected with the group's context", func(t *testing.T) {
+		ctx := di.New().Add(logConfig{Level: "info"})
+		g := ctx.Group(context.Background())
+		levels := make(chan string, 2)
+		for i := 0; i < 2; i++ {
+			g.Go(func(stdctx context.Context, cfg logConfig) {
+				if stdctx != g.Context() {
+					t.Errorf("expected %v got %v", g.Context(), stdctx)
+				}
+				levels <- cfg.Level
+			})
+		}
+		if err := g.Wait(); err != nil {
+			t.Errorf("expected %v got %v", nil, err)
+		}
+		close(levels)
+		for level := range levels {
+			if level != "info" {
+				t.Errorf("expected %v got %v", "info", level)
+			}
+		}
+		if g.Context().Err() == nil {
+			t.Errorf("expected the group's context to be canceled")
+		}
+	})
+
+	t.Run("the first error cancels the others", func(t *testing.T) {
+		g := di.New().Group(context.Background())
+		expected := errors.New("failed")
+		g.Go(func(stdctx context.Context) error {
+			<-stdctx.Done()
+			return stdctx.Err()
+		})
+		g.Go(func() error { return expected })
+		if err := g.Wait(); !errors.Is(err, expected) {
+			t.Errorf("expected %v got %v", expected, err)
+		}
+	})
+
+	t.Run("injection errors fail the group", func(t *testing.T) {
+		g := di.New(di.WithStrict()).Group(context.Background())
+		g.Go(func(*logger) {})
+		var missing *di.MissingError
+		if err := g.Wait(); !errors.As(err, &missing) {
+			t.Errorf("expected %v got %v", "a missing dependency", err)
+		}
+	})
+}
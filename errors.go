@@ -0,0 +1,135 @@
+package di
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// MissingError is returned by a strict Context when no dependency matches a parameter, field, or resolved type.
+// It wraps ErrMissing.
+type MissingError struct {
+	// The type which couldn't be resolved
+	Type reflect.Type
+	// The position of the parameter which couldn't be resolved, or -1 if it wasn't a parameter
+	Index int
+}
+
+func (e *MissingError) Error() string {
+	if e.Index < 0 {
+		return fmt.Sprintf("%v for %v", ErrMissing, e.Type)
+	}
+	return fmt.Sprintf("%v for parameter %d of type %v", ErrMissing, e.Index, e.Type)
+}
+
+func (e *MissingError) Unwrap() error {
+	return ErrMissing
+}
+
+// AmbiguousError is returned when more than one dependency could be injected for a parameter, field, or resolved type.
+// It wraps ErrAmbiguous.
+type AmbiguousError struct {
+	// The type which was requested
+	Type reflect.Type
+	// The name it was requested under, if any
+	Name string
+	// The types of the dependencies which match it, sorted by their names
+	Candidates []reflect.Type
+	// The position of the parameter being resolved, or -1 if it wasn't a parameter
+	Index int
+}
+
+func (e *AmbiguousError) Error() string {
+	msg := fmt.Sprintf("%v, bound types with possible match", ErrAmbiguous)
+	if e.Name != "" {
+		msg += fmt.Sprintf(" for name %q", e.Name)
+	}
+	msg += fmt.Sprintf(": %v", e.Candidates)
+	if e.Index >= 0 {
+		msg += fmt.Sprintf(" (parameter %d of type %v)", e.Index, e.Type)
+	}
+	return msg
+}
+
+func (e *AmbiguousError) Unwrap() error {
+	return ErrAmbiguous
+}
+
+// PanicError is returned by a Context created with WithRecover when an injected function, Bind method, or provider
+// panics. It wraps ErrPanic, and the panic value too if it is an error. Its message only gives the panic value, and is
+// wrapped in one naming what panicked; the stack trace is kept in Stack, to be logged where it's wanted.
+type PanicError struct {
+	// The value passed to panic
+	Value interface{}
+	// The stack trace of the goroutine when it panicked
+	Stack []byte
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("%v: %v", ErrPanic, e.Value)
+}
+
+func (e *PanicError) Unwrap() []error {
+	if err, ok := e.Value.(error); ok {
+		return []error{ErrPanic, err}
+	}
+	return []error{ErrPanic}
+}
+
+// CycleError is returned when providers depend on each other in a cycle, such as a provider for A needing a B, whose
+// provider needs an A. It wraps ErrCycle.
+type CycleError struct {
+	// The types of the providers in the cycle, in the order they need each other, starting and ending with the same type
+	Path []reflect.Type
+}
+
+func (e *CycleError) Error() string {
+	return fmt.Sprintf("%v: %v", ErrCycle, strings.Join(typeNames(e.Path), " -> "))
+}
+
+func (e *CycleError) Unwrap() error {
+	return ErrCycle
+}
+
+// CanceledError is returned by InjectContext and Start when their context.Context is canceled or past its deadline
+// before every provider they need has been called. It wraps the error of the provider or target which was stopped,
+// which wraps the context's error, such as context.Canceled.
+type CanceledError struct {
+	// The types of the providers which were called successfully before the context was done, in the order they returned
+	Built []reflect.Type
+	// The error which stopped the injection
+	Err error
+}
+
+func (e *CanceledError) Error() string {
+	if len(e.Built) == 0 {
+		return fmt.Sprintf("%v (no providers built)", e.Err)
+	}
+	return fmt.Sprintf("%v (providers built: %v)", e.Err, strings.Join(typeNames(e.Built), ", "))
+}
+
+func (e *CanceledError) Unwrap() error {
+	return e.Err
+}
+
+// BreakerError is returned instead of calling a provider registered with the Breaker option, while it is cooling down
+// after failing too many times in a row. It wraps ErrBreakerOpen and the provider's last error.
+type BreakerError struct {
+	// The type the provider is registered under
+	Type reflect.Type
+	// How many times in a row the provider failed
+	Failures int
+	// When the provider will next be called
+	Until time.Time
+	// The error from the provider's last failure
+	Last error
+}
+
+func (e *BreakerError) Error() string {
+	return fmt.Sprintf("%v after %d failures, last: %v", ErrBreakerOpen, e.Failures, e.Last)
+}
+
+func (e *BreakerError) Unwrap() []error {
+	return []error{ErrBreakerOpen, e.Last}
+}
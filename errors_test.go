@@ -0,0 +1,178 @@
+package di_test
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/mcvoid/di"
+)
+
+func TestStrict(t *testing.T) {
+	t.Run("missing parameter is an error", func(t *testing.T) {
+		ctx := di.New(di.WithStrict()).Add(os.Stdin)
+
+		err := ctx.Inject(func(f *os.File, b *bytes.Buffer) {
+			t.Errorf("expected func to not be called")
+		})
+		if !errors.Is(err, di.ErrMissing) {
+			t.Errorf("expected %v got %v", di.ErrMissing, err)
+		}
+		var missing *di.MissingError
+		if !errors.As(err, &missing) {
+			t.Fatalf("expected %T got %v", missing, err)
+		}
+		if missing.Index != 1 || missing.Type != reflect.TypeOf(&bytes.Buffer{}) {
+			t.Errorf("expected parameter %v of type %v got %v", 1, reflect.TypeOf(&bytes.Buffer{}), missing)
+		}
+	})
+
+	t.Run("missing interface", func(t *testing.T) {
+		ctx := di.New(di.WithStrict())
+
+		_, err := di.Resolve[io.Reader](ctx)
+		if !errors.Is(err, di.ErrMissing) {
+			t.Errorf("expected %v got %v", di.ErrMissing, err)
+		}
+	})
+
+	t.Run("missing field", func(t *testing.T) {
+		ctx := di.New(di.WithStrict())
+
+		var s struct {
+			R io.Reader
+			W io.Writer `di:"optional"`
+		}
+		err := ctx.Fill(&s)
+		if !errors.Is(err, di.ErrMissing) {
+			t.Errorf("expected %v got %v", di.ErrMissing, err)
+		}
+	})
+
+	t.Run("optional fields are still optional", func(t *testing.T) {
+		ctx := di.New(di.WithStrict())
+
+		var s struct {
+			W io.Writer `di:"optional"`
+		}
+		err := ctx.Fill(&s)
+		if err != nil {
+			t.Errorf("expected %v got %v", nil, err)
+		}
+	})
+
+	t.Run("everything resolved", func(t *testing.T) {
+		ctx := di.New(di.WithStrict()).Add(os.Stdin)
+
+		err := ctx.Inject(func(f *os.File, r io.Reader) {})
+		if err != nil {
+			t.Errorf("expected %v got %v", nil, err)
+		}
+	})
+}
+
+func TestAmbiguousError(t *testing.T) {
+	t.Run("parameter", func(t *testing.T) {
+		ctx := di.New().Add(os.Stdout, &bytes.Buffer{})
+
+		err := ctx.Inject(func(r io.Reader, w io.Writer) {
+			t.Errorf("expected func to not be called")
+		})
+		if !errors.Is(err, di.ErrAmbiguous) {
+			t.Errorf("expected %v got %v", di.ErrAmbiguous, err)
+		}
+		var amb *di.AmbiguousError
+		if !errors.As(err, &amb) {
+			t.Fatalf("expected %T got %v", amb, err)
+		}
+		if amb.Index != 0 || amb.Type != reflect.TypeOf((*io.Reader)(nil)).Elem() {
+			t.Errorf("expected parameter %v of type %v got %v", 0, "io.Reader", amb)
+		}
+		expected := []reflect.Type{reflect.TypeOf(&bytes.Buffer{}), reflect.TypeOf(os.Stdout)}
+		if !reflect.DeepEqual(amb.Candidates, expected) {
+			t.Errorf("expected %v got %v", expected, amb.Candidates)
+		}
+	})
+
+	t.Run("resolved type", func(t *testing.T) {
+		ctx := di.New().Add(os.Stdout, &bytes.Buffer{})
+
+		_, err := di.Resolve[io.Writer](ctx)
+		var amb *di.AmbiguousError
+		if !errors.As(err, &amb) {
+			t.Fatalf("expected %T got %v", amb, err)
+		}
+		if amb.Index != -1 {
+			t.Errorf("expected %v got %v", -1, amb.Index)
+		}
+	})
+
+	t.Run("named", func(t *testing.T) {
+		ctx := di.New().AddNamed("out", os.Stdout, &bytes.Buffer{})
+
+		_, err := di.Resolve[map[string]io.Writer](ctx)
+		var amb *di.AmbiguousError
+		if !errors.As(err, &amb) {
+			t.Fatalf("expected %T got %v", amb, err)
+		}
+		if amb.Name != "out" || len(amb.Candidates) != 2 {
+			t.Errorf("expected name %q with %v candidates got %v", "out", 2, amb)
+		}
+	})
+}
+
+type unregistered struct {
+	N int
+}
+
+func TestMissingConcreteTypes(t *testing.T) {
+	tests := []struct {
+		name string
+		fn   interface{}
+	}{
+		{"struct", func(u unregistered) {}},
+		{"pointer", func(u *unregistered) {}},
+		{"map", func(m map[int]string) {}},
+		{"string-keyed map", func(m map[string]unregistered) {}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name+" is zero", func(t *testing.T) {
+			ctx := di.New().Add(os.Stdout, &bytes.Buffer{})
+
+			err := ctx.Inject(test.fn)
+			if err != nil {
+				t.Errorf("expected %v got %v", nil, err)
+			}
+		})
+
+		t.Run(test.name+" is missing when strict", func(t *testing.T) {
+			ctx := di.New(di.WithStrict()).Add(os.Stdout, &bytes.Buffer{})
+
+			err := ctx.Inject(test.fn)
+			var missing *di.MissingError
+			if !errors.As(err, &missing) {
+				t.Fatalf("expected %T got %v", missing, err)
+			}
+			expected := reflect.TypeOf(test.fn).In(0)
+			if missing.Index != 0 || missing.Type != expected {
+				t.Errorf("expected parameter %v of type %v got %v", 0, expected, missing)
+			}
+		})
+	}
+
+	t.Run("resolved struct is zero", func(t *testing.T) {
+		ctx := di.New().Add(os.Stdout)
+
+		u, err := di.Resolve[unregistered](ctx)
+		if u != (unregistered{}) {
+			t.Errorf("expected %v got %v", unregistered{}, u)
+		}
+		if err != nil {
+			t.Errorf("expected %v got %v", nil, err)
+		}
+	})
+}
@@ -0,0 +1,119 @@
+package di
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+// subscriber is a function registered with Subscribe.
+type subscriber struct {
+	fn reflect.Value
+	// the type of the subscriber's event parameter
+	event reflect.Type
+	// a function type taking the subscriber's other parameters, whose
+	// arguments are resolved as if it were being injected
+	params reflect.Type
+}
+
+// newSubscriber makes a subscriber of fn, a function taking at least one
+// parameter.
+func newSubscriber(fn reflect.Value) *subscriber {
+	t := fn.Type()
+	in := make([]reflect.Type, t.NumIn()-1)
+	for i := range in {
+		in[i] = t.In(i + 1)
+	}
+	out := make([]reflect.Type, t.NumOut())
+	for i := range out {
+		out[i] = t.Out(i)
+	}
+	return &subscriber{fn: fn, event: t.In(0), params: reflect.FuncOf(in, out, t.IsVariadic())}
+}
+
+// Subscribe registers fn to be called with each event published to the Context with Publish which can be assigned to
+// its first parameter. Its other parameters are injected from the Context each time, so that domain events can fan out
+// to handlers without wiring up each handler's dependencies:
+//
+//	ctx.Subscribe(func(e OrderPlaced, mail *Mailer) error { return mail.Confirm(e.Order) })
+//	ctx.Subscribe(func(e OrderPlaced, stock *Inventory) { stock.Reserve(e.Order) })
+//	...
+//	err := di.Publish(ctx, OrderPlaced{Order: order})
+//
+// A subscriber whose first parameter is an interface is called with every event implementing it. fn may return an
+// error, which Publish returns.
+//
+// If fn isn't a function taking at least one parameter, an error wrapping ErrSubscriber is returned. Otherwise the
+// returned function unsubscribes it.
+func (ctx *Context) Subscribe(fn interface{}) (unsubscribe func(), err error) {
+	if fn == nil {
+		return nil, ErrNilInjectee
+	}
+	v := reflect.ValueOf(fn)
+	if v.Kind() != reflect.Func || v.Type().NumIn() < 1 || v.Type().IsVariadic() && v.Type().NumIn() == 1 {
+		return nil, fmt.Errorf("%v %w", v.Type(), ErrSubscriber)
+	}
+	s := newSubscriber(v)
+
+	ctx.lock.Lock()
+	defer ctx.lock.Unlock()
+
+	ctx.subscribers = append(ctx.subscribers, s)
+	return func() {
+		ctx.lock.Lock()
+		defer ctx.lock.Unlock()
+
+		// copied, since the subscribers may be being called
+		kept := []*subscriber{}
+		for _, other := range ctx.subscribers {
+			if other != s {
+				kept = append(kept, other)
+			}
+		}
+		ctx.subscribers = kept
+	}, nil
+}
+
+// Publish calls each subscriber registered in ctx with Subscribe which takes event, in the order they subscribed, in the
+// calling goroutine. Every subscriber is called even if some fail, and all the failures are returned joined together:
+// the reasons subscribers couldn't be injected, and the errors they returned. Subscribers in a parent or child Context
+// aren't called.
+func Publish[E any](ctx *Context, event E) error {
+	v := reflect.ValueOf(&event).Elem()
+	if v.Kind() == reflect.Interface && !v.IsNil() {
+		v = v.Elem()
+	}
+
+	ctx.lock.RLock()
+	subscribers := ctx.subscribers
+	ctx.lock.RUnlock()
+
+	errs := []error{}
+	for _, s := range subscribers {
+		if !v.Type().AssignableTo(s.event) {
+			continue
+		}
+		if err := s.call(ctx, newResolution(), v); err != nil {
+			errs = append(errs, fmt.Errorf("subscriber %w", err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// call injects the subscriber's other parameters as part of r, which it
+// releases, and calls it with event.
+func (s *subscriber) call(ctx *Context, r *resolution, event reflect.Value) error {
+	target := s.fn.Interface()
+	rest, err := resolveCall(ctx, r, s.params)
+	if err != nil {
+		return fmt.Errorf("injecting %s: %w", describe(target, ""), err)
+	}
+	in := getArgs(len(rest) + 1)
+	in[0] = as(s.event, event)
+	copy(in[1:], rest)
+	putArgs(rest)
+
+	_, err = callResolved(ctx, target, s.fn, "", in)
+	putArgs(in)
+	return err
+}
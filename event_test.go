@@ -0,0 +1,91 @@
+package di_test
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/mcvoid/di"
+)
+
+// an event published when the log level changes
+type levelChanged struct {
+	level string
+}
+
+func (e levelChanged) String() string {
+	return "level changed to " + e.level
+}
+
+func rejectLevel(e levelChanged) error {
+	return errors.New("rejected " + e.level)
+}
+
+func TestSubscribe(t *testing.T) {
+	t.Run("subscribers get the event and their dependencies", func(t *testing.T) {
+		ctx := di.New().Add(logConfig{Level: "info"})
+		got := []string{}
+		ctx.Subscribe(func(e levelChanged, cfg logConfig) {
+			got = append(got, cfg.Level+" to "+e.level)
+		})
+		ctx.Subscribe(func(e fmt.Stringer) {
+			got = append(got, e.String())
+		})
+		ctx.Subscribe(func(e logConfig) {
+			t.Errorf("expected subscriber to not be called")
+		})
+
+		if err := di.Publish(ctx, levelChanged{"debug"}); err != nil {
+			t.Fatalf("expected %v got %v", nil, err)
+		}
+		expected := "info to debug,level changed to debug"
+		if strings.Join(got, ",") != expected {
+			t.Errorf("expected %v got %v", expected, got)
+		}
+	})
+
+	t.Run("failures are joined", func(t *testing.T) {
+		ctx := di.New(di.WithStrict())
+		expected := errors.New("failed")
+		called := false
+		ctx.Subscribe(func(levelChanged) error { return expected })
+		ctx.Subscribe(func(levelChanged, *logger) {})
+		ctx.Subscribe(func(levelChanged) { called = true })
+
+		err := di.Publish(ctx, levelChanged{"debug"})
+		var missing *di.MissingError
+		if !errors.Is(err, expected) || !errors.As(err, &missing) {
+			t.Errorf("expected %v and a missing dependency got %v", expected, err)
+		}
+		if !called {
+			t.Errorf("expected every subscriber to be called")
+		}
+	})
+
+	t.Run("errors name the subscriber", func(t *testing.T) {
+		ctx := di.New()
+		ctx.Subscribe(rejectLevel)
+
+		err := di.Publish(ctx, levelChanged{"debug"})
+		expected := "di_test.rejectLevel returned an error: rejected debug"
+		if err == nil || !strings.Contains(err.Error(), expected) {
+			t.Errorf("expected %v got %v", expected, err)
+		}
+	})
+
+	t.Run("unsubscribed functions aren't called", func(t *testing.T) {
+		ctx := di.New()
+		unsubscribe, _ := ctx.Subscribe(func(levelChanged) { t.Errorf("expected subscriber to not be called") })
+		unsubscribe()
+		di.Publish(ctx, levelChanged{"debug"})
+	})
+
+	t.Run("subscribers must take an event", func(t *testing.T) {
+		for _, fn := range []interface{}{func() {}, func(...levelChanged) {}, "subscriber"} {
+			if _, err := di.New().Subscribe(fn); !errors.Is(err, di.ErrSubscriber) {
+				t.Errorf("expected %v got %v", di.ErrSubscriber, err)
+			}
+		}
+	})
+}
@@ -0,0 +1,167 @@
+package di
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// MatchKind is how a parameter is resolved, as reported by Explain.
+type MatchKind int
+
+const (
+	// MatchExact is a dependency registered as exactly the parameter's type
+	MatchExact MatchKind = iota
+	// MatchInterface is the only dependency implementing the parameter's interface type
+	MatchInterface
+	// MatchPreferred is the dependency chosen with Prefer out of several implementing the parameter's interface type
+	MatchPreferred
+	// MatchSpecific is the most specific of several dependencies implementing the parameter's interface type, chosen
+	// because of WithMostSpecific
+	MatchSpecific
+	// MatchGroup is a slice of every dependency implementing the parameter's element type
+	MatchGroup
+	// MatchMap is a map of every named dependency assignable to the parameter's element type
+	MatchMap
+	// MatchParams is a parameter object embedding In, whose fields are resolved separately
+	MatchParams
+	// MatchDefault is a default registered with SetDefault
+	MatchDefault
+	// MatchCallback is the value given by the callback set with WithOnMissing
+	MatchCallback
+	// MatchZero is the zero value, since nothing matched
+	MatchZero
+	// MatchMissing is a failure because nothing matched in a strict Context
+	MatchMissing
+	// MatchAmbiguous is a failure because several dependencies matched
+	MatchAmbiguous
+	// MatchFailed is a failure for any other reason, such as a provider's own dependencies failing to resolve
+	MatchFailed
+)
+
+var matchKindNames = []string{
+	MatchExact:     "exact match",
+	MatchInterface: "interface match",
+	MatchPreferred: "preferred match",
+	MatchSpecific:  "most specific match",
+	MatchGroup:     "group",
+	MatchMap:       "named map",
+	MatchParams:    "parameter object",
+	MatchDefault:   "default",
+	MatchCallback:  "missing dependency callback",
+	MatchZero:      "zero value",
+	MatchMissing:   "missing",
+	MatchAmbiguous: "ambiguous",
+	MatchFailed:    "failed",
+}
+
+func (k MatchKind) String() string {
+	if k < 0 || int(k) >= len(matchKindNames) {
+		return fmt.Sprintf("MatchKind(%d)", int(k))
+	}
+	return matchKindNames[k]
+}
+
+// Explanation is a report of how each parameter of a target would be resolved, returned by Explain.
+type Explanation struct {
+	// The name of the target: a function's name, or the type and method name of an object
+	Target string
+	// The resolution of each parameter, in order
+	Params []ParamExplanation
+}
+
+// ParamExplanation is how a single parameter would be resolved.
+type ParamExplanation struct {
+	// The position of the parameter
+	Index int
+	// The type of the parameter
+	Type reflect.Type
+	// How the parameter would be resolved
+	Kind MatchKind
+	// The type the matching dependency is registered under, if there is one
+	Match reflect.Type
+	// The name the matching dependency is registered under, if any
+	Name string
+	// Whether the matching dependency is constructed by a provider
+	Provided bool
+	// For an interface parameter, the types of all the dependencies which implement it
+	Candidates []reflect.Type
+	// Why the parameter can't be resolved, if it can't
+	Err error
+}
+
+func (e *Explanation) String() string {
+	b := &strings.Builder{}
+	fmt.Fprintf(b, "%s\n", e.Target)
+	for _, p := range e.Params {
+		fmt.Fprintf(b, "  %d %v: %v", p.Index, p.Type, p.Kind)
+		if p.Match != nil {
+			fmt.Fprintf(b, " %v", p.Match)
+		}
+		if p.Name != "" {
+			fmt.Fprintf(b, " named %q", p.Name)
+		}
+		if p.Provided {
+			fmt.Fprintf(b, " (provided)")
+		}
+		if len(p.Candidates) > 1 {
+			fmt.Fprintf(b, " of %v", p.Candidates)
+		}
+		if p.Err != nil {
+			fmt.Fprintf(b, ": %v", p.Err)
+		}
+		fmt.Fprintln(b)
+	}
+	return b.String()
+}
+
+// Explain reports how each parameter of target would be resolved by Inject: which rule would apply, which dependency
+// would be used, and, if it can't be resolved, why. Like Validate, it doesn't call target or any providers. An error is
+// only returned if target can't be injected at all, such as when it is nil.
+func (ctx *Context) Explain(target interface{}) (*Explanation, error) {
+	fn, err := injectable(ctx, target)
+	if err != nil {
+		return nil, err
+	}
+
+	t := fn.Type()
+	e := &Explanation{
+		Target: describe(target, ctx.bindMethodName()),
+		Params: make([]ParamExplanation, t.NumIn()),
+	}
+	for i := range e.Params {
+		p := &e.Params[i]
+		p.Index, p.Type = i, t.In(i)
+
+		r := &resolution{validate: true, explain: p}
+		if _, err := resolveParam(ctx, r, t, i); err != nil {
+			p.Err = paramError(i, p.Type, err)
+			switch err := err.(type) {
+			case *AmbiguousError:
+				p.Kind, p.Candidates = MatchAmbiguous, err.Candidates
+			case *MissingError:
+				p.Kind = MatchMissing
+			default:
+				p.Kind = MatchFailed
+			}
+		}
+	}
+	return e, nil
+}
+
+// note records how the parameter being explained is resolved. Dependencies
+// resolved for providers are not recorded. The candidates are copied, since
+// they may be in a reused buffer.
+func (r *resolution) note(kind MatchKind, match reflect.Type, e *entry, candidates []reflect.Type) {
+	if r.explain == nil || len(r.building) > 0 {
+		return
+	}
+	r.explain.Kind, r.explain.Match, r.explain.Candidates = kind, match, nil
+	if candidates != nil {
+		r.explain.Candidates = copyTypes(candidates)
+	}
+	r.explain.Name, r.explain.Provided = "", false
+	if e != nil {
+		r.explain.Name, r.explain.Provided = e.name, e.prov != nil
+	}
+}
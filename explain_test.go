@@ -0,0 +1,105 @@
+package di_test
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/mcvoid/di"
+)
+
+func TestExplain(t *testing.T) {
+	t.Run("each kind of match", func(t *testing.T) {
+		b := &bytes.Buffer{}
+		ctx := di.New().Add(os.Stdout, b)
+		ctx.Provide(func() *strings.Builder { return &strings.Builder{} })
+		di.Prefer[io.Writer](ctx, b)
+
+		e, err := ctx.Explain(func(f *os.File, r io.Reader, w io.Writer, s *strings.Builder, i int, all []io.Writer) {
+			t.Errorf("expected func to not be called")
+		})
+		if err != nil {
+			t.Fatalf("expected %v got %v", nil, err)
+		}
+
+		expected := []struct {
+			kind     di.MatchKind
+			match    reflect.Type
+			provided bool
+		}{
+			{di.MatchExact, reflect.TypeOf(os.Stdout), false},
+			{di.MatchAmbiguous, nil, false},
+			{di.MatchPreferred, reflect.TypeOf(b), false},
+			{di.MatchExact, reflect.TypeOf(&strings.Builder{}), true},
+			{di.MatchZero, nil, false},
+			{di.MatchGroup, nil, false},
+		}
+		if len(e.Params) != len(expected) {
+			t.Fatalf("expected %v got %v", len(expected), len(e.Params))
+		}
+		for i, p := range e.Params {
+			if p.Index != i || p.Kind != expected[i].kind || p.Match != expected[i].match || p.Provided != expected[i].provided {
+				t.Errorf("parameter %d: expected %v %v got %v %v", i, expected[i].kind, expected[i].match, p.Kind, p.Match)
+			}
+		}
+		if !errors.Is(e.Params[1].Err, di.ErrAmbiguous) || len(e.Params[1].Candidates) != 2 {
+			t.Errorf("expected %v with %v candidates got %v", di.ErrAmbiguous, 2, e.Params[1].Err)
+		}
+	})
+
+	t.Run("providers are not called", func(t *testing.T) {
+		ctx := di.New()
+		ctx.Provide(func(f *os.File) *bytes.Buffer {
+			t.Errorf("expected provider to not be called")
+			return nil
+		})
+
+		e, _ := ctx.Explain(func(b *bytes.Buffer) {})
+		if e.Params[0].Kind != di.MatchExact {
+			t.Errorf("expected %v got %v", di.MatchExact, e.Params[0].Kind)
+		}
+	})
+
+	t.Run("interface and missing", func(t *testing.T) {
+		ctx := di.New(di.WithStrict()).Add(&bytes.Buffer{})
+
+		e, _ := ctx.Explain(func(s fmt.Stringer, f *os.File) {})
+		if e.Params[0].Kind != di.MatchInterface || e.Params[0].Match != reflect.TypeOf(&bytes.Buffer{}) {
+			t.Errorf("expected %v got %v", di.MatchInterface, e.Params[0].Kind)
+		}
+		if e.Params[1].Kind != di.MatchMissing || !errors.Is(e.Params[1].Err, di.ErrMissing) {
+			t.Errorf("expected %v got %v", di.MatchMissing, e.Params[1].Kind)
+		}
+	})
+
+	t.Run("default", func(t *testing.T) {
+		ctx := di.New().SetDefault(os.Stdout)
+
+		e, _ := ctx.Explain(func(w io.Writer) {})
+		if e.Params[0].Kind != di.MatchDefault {
+			t.Errorf("expected %v got %v", di.MatchDefault, e.Params[0].Kind)
+		}
+	})
+
+	t.Run("string", func(t *testing.T) {
+		ctx := di.New().AddNamed("out", os.Stdout)
+
+		e, _ := ctx.Explain(func(f di.Named[*os.File, out]) {})
+		expected := `0 di.Named[*os.File,github.com/mcvoid/di_test.out]: exact match *os.File named "out"`
+		if !strings.Contains(e.String(), expected) {
+			t.Errorf("expected %v got %v", expected, e)
+		}
+	})
+
+	t.Run("nil", func(t *testing.T) {
+		_, err := di.New().Explain(nil)
+		if !errors.Is(err, di.ErrNilInjectee) {
+			t.Errorf("expected %v got %v", di.ErrNilInjectee, err)
+		}
+	})
+}
@@ -0,0 +1,114 @@
+package di
+
+import (
+	"errors"
+	"expvar"
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+// WithExpvar publishes counters for the Context's resolutions with package expvar under the given name, so they can be
+// watched at runtime at /debug/vars. The published map has three maps of counters, each keyed by type:
+//
+//   - "resolved" counts each time a type is resolved for a parameter or by Resolve.
+//   - "ambiguous" counts each time resolving a type fails because several dependencies match.
+//   - "zero" counts each time a type falls back to the zero value because nothing matches, which usually means an
+//     interface is silently going unimplemented.
+//
+// Contexts given the same name share the counters. If something other than a map is already published under the name,
+// nothing is published, and Err reports an error wrapping ErrExpvar.
+func WithExpvar(name string) Option {
+	return func(ctx *Context) {
+		counters, err := publishCounters(name)
+		if err != nil {
+			ctx.err = errors.Join(ctx.err, err)
+			return
+		}
+		ctx.counters = counters
+		ctx.observers = append(ctx.observers, counters)
+	}
+}
+
+// publishing guards looking up published vars and publishing new ones, so
+// Contexts created at the same time with the same name share one map.
+var publishing sync.Mutex
+
+// publishCounters returns the counters published under name, publishing them
+// if they haven't been.
+func publishCounters(name string) (*expvarCounters, error) {
+	publishing.Lock()
+	defer publishing.Unlock()
+
+	m, err := publishedMap(name)
+	if err != nil {
+		return nil, err
+	}
+	counters := &expvarCounters{}
+	if counters.resolved, err = subMap(m, name, "resolved"); err != nil {
+		return nil, err
+	}
+	if counters.ambiguous, err = subMap(m, name, "ambiguous"); err != nil {
+		return nil, err
+	}
+	if counters.zero, err = subMap(m, name, "zero"); err != nil {
+		return nil, err
+	}
+	return counters, nil
+}
+
+// publishedMap returns the map published under name, publishing it if it
+// hasn't been. The caller must hold publishing.
+func publishedMap(name string) (*expvar.Map, error) {
+	switch v := expvar.Get(name).(type) {
+	case nil:
+		return expvar.NewMap(name), nil
+	case *expvar.Map:
+		return v, nil
+	}
+	return nil, fmt.Errorf("%w: %s", ErrExpvar, name)
+}
+
+// subMap returns the map under key in m, the map published under name,
+// adding it if there isn't one. The caller must hold publishing.
+func subMap(m *expvar.Map, name, key string) (*expvar.Map, error) {
+	switch v := m.Get(key).(type) {
+	case nil:
+		sub := new(expvar.Map)
+		m.Set(key, sub)
+		return sub, nil
+	case *expvar.Map:
+		return v, nil
+	}
+	return nil, fmt.Errorf("%w: %s.%s", ErrExpvar, name, key)
+}
+
+// expvarCounters counts resolutions.
+type expvarCounters struct {
+	resolved  *expvar.Map
+	ambiguous *expvar.Map
+	zero      *expvar.Map
+}
+
+func (c *expvarCounters) BeforeResolve(t reflect.Type) {}
+
+func (c *expvarCounters) AfterResolve(t reflect.Type, val interface{}, err error) {
+	if errors.Is(err, ErrAmbiguous) {
+		c.ambiguous.Add(t.String(), 1)
+	}
+	if err == nil {
+		c.resolved.Add(t.String(), 1)
+	}
+}
+
+func (c *expvarCounters) BeforeCall(target interface{}) {}
+
+func (c *expvarCounters) AfterCall(target interface{}, err error) {}
+
+// countZero counts a type falling back to the zero value, if the Context
+// publishes counters.
+func countZero(ctx *Context, t reflect.Type) {
+	if ctx.counters != nil {
+		ctx.counters.zero.Add(t.String(), 1)
+	}
+}
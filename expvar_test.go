@@ -0,0 +1,69 @@
+package di_test
+
+import (
+	"bytes"
+	"errors"
+	"expvar"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/mcvoid/di"
+)
+
+// numbers the names published by each run, since expvar names are global
+var expvarRuns atomic.Int32
+
+func TestWithExpvar(t *testing.T) {
+	t.Run("resolutions are counted", func(t *testing.T) {
+		name := fmt.Sprintf("%s_%d", t.Name(), expvarRuns.Add(1))
+		ctx := di.New(di.WithExpvar(name)).Add(os.Stdout, &bytes.Buffer{})
+		ctx.Inject(func(f *os.File, b *bytes.Buffer) {})
+		ctx.Inject(func(f *os.File, r *bytes.Reader) {})
+		ctx.Inject(func(w io.Writer) {})
+
+		// a second Context shares the counters
+		di.Resolve[*os.File](di.New(di.WithExpvar(name)))
+
+		m := expvar.Get(name).(*expvar.Map)
+		expected := map[string]map[string]string{
+			"resolved":  {"*os.File": "3", "*bytes.Buffer": "1", "*bytes.Reader": "1"},
+			"ambiguous": {"io.Writer": "1"},
+			"zero":      {"*bytes.Reader": "1", "*os.File": "1"},
+		}
+		for name, counts := range expected {
+			sub := m.Get(name).(*expvar.Map)
+			for typ, count := range counts {
+				if v := sub.Get(typ); v == nil || v.String() != count {
+					t.Errorf("%v %v: expected %v got %v", name, typ, count, v)
+				}
+			}
+		}
+	})
+
+	t.Run("concurrent contexts share the counters", func(t *testing.T) {
+		name := fmt.Sprintf("%s_%d", t.Name(), expvarRuns.Add(1))
+		var wg sync.WaitGroup
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if err := di.New(di.WithExpvar(name)).Err(); err != nil {
+					t.Errorf("expected %v got %v", nil, err)
+				}
+			}()
+		}
+		wg.Wait()
+	})
+
+	t.Run("names used by other vars are reported", func(t *testing.T) {
+		name := fmt.Sprintf("%s_%d", t.Name(), expvarRuns.Add(1))
+		expvar.NewInt(name)
+		if err := di.New(di.WithExpvar(name)).Err(); !errors.Is(err, di.ErrExpvar) {
+			t.Errorf("expected %v got %v", di.ErrExpvar, err)
+		}
+	})
+}
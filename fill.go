@@ -0,0 +1,216 @@
+package di
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"unsafe"
+)
+
+const tagName = "di"
+
+// fieldOptions are the injection options given by a field's struct tag.
+type fieldOptions struct {
+	skip     bool
+	optional bool
+	name     string
+	secret   string
+}
+
+// parseTag parses the options in a 'di' struct tag.
+func parseTag(tag string) (fieldOptions, error) {
+	opts := fieldOptions{}
+	if tag == "" {
+		return opts, nil
+	}
+	if tag == "-" {
+		opts.skip = true
+		return opts, nil
+	}
+
+	for _, opt := range strings.Split(tag, ",") {
+		opt = strings.TrimSpace(opt)
+		switch {
+		case opt == "optional":
+			opts.optional = true
+		case strings.HasPrefix(opt, "name="):
+			opts.name = strings.TrimPrefix(opt, "name=")
+		case strings.HasPrefix(opt, "secret="):
+			opts.secret = strings.TrimPrefix(opt, "secret=")
+		default:
+			return opts, fmt.Errorf("%w: unknown option %q", ErrInvalidTag, opt)
+		}
+	}
+	return opts, nil
+}
+
+// FillOption configures a call to Fill.
+type FillOption func(*fillOptions)
+
+type fillOptions struct {
+	recursive  bool
+	unexported bool
+	reactive   bool
+}
+
+// Recursive makes Fill descend into struct-typed fields, including embedded structs, which don't match any dependency,
+// filling their fields in turn.
+func Recursive() FillOption {
+	return func(o *fillOptions) {
+		o.recursive = true
+	}
+}
+
+// AllowUnexported makes Fill set unexported fields as well as exported ones. Since reflection can't normally set
+// unexported fields, they are written through their address using package unsafe, bypassing the usual protection.
+// Only use it on structs you own.
+func AllowUnexported() FillOption {
+	return func(o *fillOptions) {
+		o.unexported = true
+	}
+}
+
+// Fill populates the exported fields of the struct pointed to by target directly from the Context, as an alternative to
+// writing a Bind method. Each field is resolved by the same rules Inject uses for parameters, with the field's type
+// taking the place of the parameter's type. Unexported fields are left untouched.
+//
+// Fields can be given options with a 'di' struct tag, separated by commas:
+//
+//   - `di:"-"` leaves the field untouched.
+//   - `di:"optional"` only sets the field if a dependency matches, and leaves it untouched instead of failing if the
+//     dependency can't be resolved.
+//   - `di:"name=replica"` resolves the field from the dependencies registered with that name, as if it were Named.
+//   - `di:"secret=db/password"` sets the field, a string or byte slice, to that secret from the registered
+//     SecretSource, as if it were a Secret.
+//
+// With the Recursive option, nested structs are filled too. With the AllowUnexported option, unexported fields are
+// filled too. With the Reactive option, the struct is filled again whenever its dependencies are replaced.
+//
+// If any fields can't be resolved, the errors for all of them are returned together and the struct is left unchanged.
+// Otherwise, if target implements AfterInjecter, its AfterInject method is called once the fields are set, and any
+// error it returns is returned.
+func (ctx *Context) Fill(target interface{}, opts ...FillOption) error {
+	val := reflect.ValueOf(target)
+	if target == nil || val.Kind() != reflect.Pointer || val.IsNil() || val.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("%w: %v", ErrNotStruct, target)
+	}
+
+	o := fillOptions{}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if err := ctx.fill(target, o); err != nil || !o.reactive {
+		return err
+	}
+
+	o.reactive = false
+	keys := fieldKeys(val.Elem().Type(), o)
+	ctx.remember(&binding{target: target, keys: keys, inject: func() error { return ctx.fill(target, o) }})
+	return nil
+}
+
+// fill fills the struct target points to, all at once, then calls its
+// AfterInject method.
+func (ctx *Context) fill(target interface{}, o fillOptions) error {
+	// resolve everything before setting anything
+	assignments, err := fillStruct(ctx, &resolution{}, reflect.ValueOf(target).Elem(), o, "")
+	if err != nil {
+		return err
+	}
+	for _, a := range assignments {
+		a.field.Set(a.val)
+	}
+	return afterInject(target)
+}
+
+// assignment is a resolved value waiting to be set on a field.
+type assignment struct {
+	field reflect.Value
+	val   reflect.Value
+}
+
+// fillStruct resolves the fields of the struct val, returning the values to
+// set, or the errors for every field which can't be resolved. path is the
+// name of the struct's own field, for error messages.
+func fillStruct(ctx *Context, r *resolution, val reflect.Value, o fillOptions, path string) ([]assignment, error) {
+	t := val.Type()
+	assignments := []assignment{}
+	errs := []error{}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name := path + field.Name
+		// the marker of a parameter object isn't a dependency
+		if field.Anonymous && field.Type == inType {
+			continue
+		}
+
+		// embedded structs may be unexported but still have exported fields
+		settable := field.IsExported() || o.unexported
+		embedded := o.recursive && field.Anonymous && field.Type.Kind() == reflect.Struct
+		if !settable && !embedded {
+			continue
+		}
+		opts, err := parseTag(field.Tag.Get(tagName))
+		if err != nil {
+			errs = append(errs, fmt.Errorf("field %v: %w", name, err))
+			continue
+		}
+		if opts.skip {
+			continue
+		}
+
+		var fieldVal reflect.Value
+		switch {
+		case !settable:
+			// an unexported embedded struct can't be set, only descended into
+		case opts.secret != "":
+			fieldVal, err = resolveSecret(ctx, r, field.Type, opts.secret)
+		case opts.name != "":
+			fieldVal, err = resolveNamed(ctx, r, field.Type, opts.name)
+		default:
+			fieldVal, err = resolve(ctx, r, field.Type)
+		}
+		if err != nil {
+			// leave optional fields alone rather than failing
+			if !opts.optional {
+				errs = append(errs, fmt.Errorf("field %v: %w", name, err))
+			}
+			continue
+		}
+
+		if !fieldVal.IsValid() && o.recursive && field.Type.Kind() == reflect.Struct {
+			nested, err := fillStruct(ctx, r, val.Field(i), o, name+".")
+			if err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			assignments = append(assignments, nested...)
+			continue
+		}
+		if !fieldVal.IsValid() {
+			if opts.optional {
+				continue
+			}
+			fieldVal, err = missing(ctx, r, field.Type, -1)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("field %v: %w", name, err))
+				continue
+			}
+		}
+		assignments = append(assignments, assignment{settableField(val.Field(i)), fieldVal})
+	}
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	return assignments, nil
+}
+
+// settableField makes an addressable field settable, even if it is
+// unexported.
+func settableField(field reflect.Value) reflect.Value {
+	if field.CanSet() {
+		return field
+	}
+	return reflect.NewAt(field.Type(), unsafe.Pointer(field.UnsafeAddr())).Elem()
+}
@@ -0,0 +1,227 @@
+package di_test
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/mcvoid/di"
+)
+
+type fillable struct {
+	File   *os.File
+	Reader io.Reader
+	Writer io.Writer
+	hidden *os.File
+}
+
+type tagged struct {
+	Skipped  *os.File  `di:"-"`
+	Optional io.Writer `di:"optional"`
+	Replica  io.Writer `di:"name=replica"`
+	Both     io.Writer `di:"optional, name=replica"`
+}
+
+type embedded struct {
+	Reader io.Reader
+}
+
+type nested struct {
+	embedded
+	Inner struct {
+		File *os.File
+	}
+	Config struct {
+		Name string `di:"-"`
+	}
+}
+
+func TestFill(t *testing.T) {
+	t.Run("fills exported fields", func(t *testing.T) {
+		ctx := di.New().Add(os.Stdin)
+
+		var s fillable
+		err := ctx.Fill(&s)
+		if err != nil {
+			t.Errorf("expected %v got %v", nil, err)
+		}
+		if s.File != os.Stdin {
+			t.Errorf("expected %v got %v", os.Stdin, s.File)
+		}
+		if s.Reader != os.Stdin {
+			t.Errorf("expected %v got %v", os.Stdin, s.Reader)
+		}
+		if s.hidden != nil {
+			t.Errorf("expected %v got %v", nil, s.hidden)
+		}
+	})
+
+	t.Run("error leaves the struct unchanged", func(t *testing.T) {
+		ctx := di.New().Add(os.Stdin, &bytes.Buffer{})
+
+		var s fillable
+		err := ctx.Fill(&s)
+		if !errors.Is(err, di.ErrAmbiguous) {
+			t.Errorf("expected %v got %v", di.ErrAmbiguous, err)
+		}
+		if s.File != nil {
+			t.Errorf("expected %v got %v", nil, s.File)
+		}
+	})
+
+	t.Run("skipped field", func(t *testing.T) {
+		ctx := di.New().Add(os.Stdin)
+
+		s := tagged{Skipped: os.Stdout}
+		ctx.Fill(&s)
+		if s.Skipped != os.Stdout {
+			t.Errorf("expected %v got %v", os.Stdout, s.Skipped)
+		}
+	})
+
+	t.Run("optional field isn't required", func(t *testing.T) {
+		ctx := di.New().Add(os.Stdin, &bytes.Buffer{})
+
+		s := tagged{Optional: os.Stderr}
+		err := ctx.Fill(&s)
+		if err != nil {
+			t.Errorf("expected %v got %v", nil, err)
+		}
+		if s.Optional != os.Stderr {
+			t.Errorf("expected %v got %v", os.Stderr, s.Optional)
+		}
+	})
+
+	t.Run("optional field is untouched when missing", func(t *testing.T) {
+		var ctx di.Context
+
+		s := tagged{Optional: os.Stderr}
+		ctx.Fill(&s)
+		if s.Optional != os.Stderr {
+			t.Errorf("expected %v got %v", os.Stderr, s.Optional)
+		}
+	})
+
+	t.Run("named field", func(t *testing.T) {
+		b := &bytes.Buffer{}
+		ctx := di.New().Add(os.Stdin).AddNamed("replica", b)
+
+		var s tagged
+		err := ctx.Fill(&s)
+		if err != nil {
+			t.Errorf("expected %v got %v", nil, err)
+		}
+		if s.Replica != b {
+			t.Errorf("expected %p got %p", b, s.Replica)
+		}
+		if s.Both != b {
+			t.Errorf("expected %p got %p", b, s.Both)
+		}
+		if s.Optional != os.Stdin {
+			t.Errorf("expected %v got %v", os.Stdin, s.Optional)
+		}
+	})
+
+	t.Run("invalid tag", func(t *testing.T) {
+		var s struct {
+			W io.Writer `di:"required"`
+		}
+		err := di.New().Fill(&s)
+		if !errors.Is(err, di.ErrInvalidTag) {
+			t.Errorf("expected %v got %v", di.ErrInvalidTag, err)
+		}
+	})
+
+	t.Run("recursive", func(t *testing.T) {
+		ctx := di.New().Add(os.Stdin)
+
+		s := nested{}
+		s.Config.Name = "config"
+		err := ctx.Fill(&s, di.Recursive())
+		if err != nil {
+			t.Errorf("expected %v got %v", nil, err)
+		}
+		if s.Reader != os.Stdin {
+			t.Errorf("expected %v got %v", os.Stdin, s.Reader)
+		}
+		if s.Inner.File != os.Stdin {
+			t.Errorf("expected %v got %v", os.Stdin, s.Inner.File)
+		}
+		if s.Config.Name != "config" {
+			t.Errorf("expected %v got %v", "config", s.Config.Name)
+		}
+	})
+
+	t.Run("not recursive by default", func(t *testing.T) {
+		ctx := di.New().Add(os.Stdin)
+
+		s := nested{}
+		ctx.Fill(&s)
+		if s.Reader != nil {
+			t.Errorf("expected %v got %v", nil, s.Reader)
+		}
+		if s.Inner.File != nil {
+			t.Errorf("expected %v got %v", nil, s.Inner.File)
+		}
+	})
+
+	t.Run("nested errors name the field", func(t *testing.T) {
+		ctx := di.New().Add(os.Stdin, &bytes.Buffer{})
+
+		var s struct {
+			Outer struct {
+				Writer io.Writer
+			}
+		}
+		err := ctx.Fill(&s, di.Recursive())
+		if !errors.Is(err, di.ErrAmbiguous) {
+			t.Errorf("expected %v got %v", di.ErrAmbiguous, err)
+		}
+		if err != nil && !strings.Contains(err.Error(), "Outer.Writer") {
+			t.Errorf("expected error to name %v got %v", "Outer.Writer", err)
+		}
+	})
+
+	t.Run("unexported fields", func(t *testing.T) {
+		ctx := di.New().Add(os.Stdin)
+
+		var s fillable
+		err := ctx.Fill(&s, di.AllowUnexported())
+		if err != nil {
+			t.Errorf("expected %v got %v", nil, err)
+		}
+		if s.hidden != os.Stdin {
+			t.Errorf("expected %v got %v", os.Stdin, s.hidden)
+		}
+	})
+
+	t.Run("unexported nested fields", func(t *testing.T) {
+		ctx := di.New().Add(os.Stdin)
+
+		var s struct {
+			inner struct {
+				file *os.File
+			}
+		}
+		err := ctx.Fill(&s, di.AllowUnexported(), di.Recursive())
+		if err != nil {
+			t.Errorf("expected %v got %v", nil, err)
+		}
+		if s.inner.file != os.Stdin {
+			t.Errorf("expected %v got %v", os.Stdin, s.inner.file)
+		}
+	})
+
+	t.Run("not a pointer to a struct", func(t *testing.T) {
+		ctx := di.New()
+		for _, target := range []interface{}{nil, fillable{}, (*fillable)(nil), new(int)} {
+			err := ctx.Fill(target)
+			if !errors.Is(err, di.ErrNotStruct) {
+				t.Errorf("expected %v got %v", di.ErrNotStruct, err)
+			}
+		}
+	})
+}
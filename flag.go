@@ -0,0 +1,96 @@
+package di
+
+import (
+	"context"
+	"fmt"
+)
+
+// FeatureFlags reports which feature flags are on. Registering an implementation, such as a client for a flag service,
+// lets registrations made with the WhenFlag option be chosen between as the flags change.
+type FeatureFlags interface {
+	// Enabled reports whether the flag is on. stdctx is the context given to InjectContext, or context.Background, so
+	// that flags can be targeted at a request.
+	Enabled(stdctx context.Context, flag string) bool
+}
+
+// StaticFlags is a FeatureFlags whose flags never change, for tests and simple configuration. Flags not in the map are
+// off.
+type StaticFlags map[string]bool
+
+// Enabled reports whether the flag is in the map and on.
+func (f StaticFlags) Enabled(_ context.Context, flag string) bool {
+	return f[flag]
+}
+
+var featureFlagsType = typeOf[FeatureFlags]()
+
+// WhenFlag puts a registration made with Provide or AddAs behind a feature flag. Rather than overwriting what is
+// already registered under the same type and name, it is kept alongside it, and each time the dependency is needed,
+// the FeatureFlags registered in the Context decides which one is used: the flagged one while the flag is on, and the
+// other one while it is off. This allows migrating to a new implementation gradually, without changing the code which
+// depends on it:
+//
+//	ctx.Add(di.StaticFlags{"new-billing": true})
+//	ctx.Provide(newLegacyBilling)
+//	ctx.Provide(newBilling, di.WhenFlag("new-billing"))
+//
+// The two can be registered in either order. Each keeps its own lifetime, so singletons are built at most once, the
+// first time they're chosen. If the flag is off and nothing else is registered, resolving the dependency fails with an
+// error wrapping ErrFlagOff. Registering under the same flag again overwrites the flagged registration, while
+// registering under another flag puts it in front, so several flags can be checked in turn.
+func WhenFlag(flag string) RegisterOption {
+	return func(e *entry) {
+		e.flag = flag
+	}
+}
+
+// pair combines a registration with one already made under the same key,
+// when either is behind a feature flag.
+func pair(existing, e *entry) *entry {
+	if e.flag == "" {
+		return withOff(existing, e)
+	}
+	paired := *e
+	paired.off = existing
+	if existing.flag == e.flag {
+		paired.off = existing.off
+	}
+	return &paired
+}
+
+// withOff returns existing, with e used when each of its flags is off. The
+// entries are copied rather than changed, since they may be being resolved.
+func withOff(existing, e *entry) *entry {
+	if existing == nil || existing.flag == "" {
+		return e
+	}
+	paired := *existing
+	paired.off = withOff(existing.off, e)
+	return &paired
+}
+
+// choose returns the entry to use for a registration behind a feature flag:
+// e if its flag is on, or else the one registered for when it is off. While
+// validating, both are checked, since the flag can change.
+func (e *entry) choose(ctx *Context, r *resolution) (*entry, error) {
+	if r.validate {
+		if e.off != nil {
+			if _, err := e.off.value(ctx, r); err != nil {
+				return nil, err
+			}
+		}
+		return e, nil
+	}
+
+	flags, err := resolveAside(ctx, r, featureFlagsType)
+	if err != nil {
+		return nil, fmt.Errorf("flag %q: %w", e.flag, err)
+	}
+	if flags.Interface().(FeatureFlags).Enabled(r.context(), e.flag) {
+		return e, nil
+	}
+	if e.off == nil {
+		return nil, fmt.Errorf("%w: %q", ErrFlagOff, e.flag)
+	}
+	return e.off, nil
+}
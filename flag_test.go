@@ -0,0 +1,129 @@
+package di_test
+
+import (
+	"errors"
+	"io"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/mcvoid/di"
+)
+
+func TestWhenFlag(t *testing.T) {
+	legacy := func() io.Writer { return os.Stdout }
+	replacement := func() io.Writer { return os.Stderr }
+
+	t.Run("flag chooses the implementation", func(t *testing.T) {
+		flags := di.StaticFlags{}
+		ctx := di.New().Add(flags)
+		ctx.Provide(legacy)
+		ctx.Provide(replacement, di.WhenFlag("new-writer"))
+
+		if w := di.MustResolve[io.Writer](ctx); w != os.Stdout {
+			t.Errorf("expected %v got %v", os.Stdout, w)
+		}
+		flags["new-writer"] = true
+		if w := di.MustResolve[io.Writer](ctx); w != os.Stderr {
+			t.Errorf("expected %v got %v", os.Stderr, w)
+		}
+		flags["new-writer"] = false
+		if w := di.MustResolve[io.Writer](ctx); w != os.Stdout {
+			t.Errorf("expected %v got %v", os.Stdout, w)
+		}
+	})
+
+	t.Run("order of registration doesn't matter", func(t *testing.T) {
+		ctx := di.New(di.WithNoOverwrite()).Add(di.StaticFlags{"new-writer": true})
+		if err := ctx.Provide(replacement, di.WhenFlag("new-writer")); err != nil {
+			t.Errorf("expected %v got %v", nil, err)
+		}
+		if err := ctx.Provide(legacy); err != nil {
+			t.Errorf("expected %v got %v", nil, err)
+		}
+		if w := di.MustResolve[io.Writer](ctx); w != os.Stderr {
+			t.Errorf("expected %v got %v", os.Stderr, w)
+		}
+	})
+
+	t.Run("several flags are checked in turn", func(t *testing.T) {
+		flags := di.StaticFlags{"a": true}
+		ctx := di.New().Add(flags)
+		ctx.Provide(legacy)
+		ctx.Provide(replacement, di.WhenFlag("a"))
+		di.AddAs[io.Writer](ctx, &strings.Builder{}, di.WhenFlag("b"), di.InterfaceOnly())
+
+		if w := di.MustResolve[io.Writer](ctx); w != os.Stderr {
+			t.Errorf("expected %v got %v", os.Stderr, w)
+		}
+		flags["b"] = true
+		if _, ok := di.MustResolve[io.Writer](ctx).(*strings.Builder); !ok {
+			t.Errorf("expected %v got %v", "*strings.Builder", di.MustResolve[io.Writer](ctx))
+		}
+		flags["a"], flags["b"] = false, false
+		if w := di.MustResolve[io.Writer](ctx); w != os.Stdout {
+			t.Errorf("expected %v got %v", os.Stdout, w)
+		}
+	})
+
+	t.Run("compiled functions follow the flag", func(t *testing.T) {
+		flags := di.StaticFlags{}
+		ctx := di.New().Add(flags)
+		ctx.Provide(legacy)
+		ctx.Provide(replacement, di.WhenFlag("new-writer"))
+
+		var got io.Writer
+		fn, err := ctx.Compile(func(w io.Writer) { got = w })
+		if err != nil {
+			t.Fatalf("expected %v got %v", nil, err)
+		}
+		fn()
+		if got != os.Stdout {
+			t.Errorf("expected %v got %v", os.Stdout, got)
+		}
+		flags["new-writer"] = true
+		fn()
+		if got != os.Stderr {
+			t.Errorf("expected %v got %v", os.Stderr, got)
+		}
+	})
+
+	t.Run("flag off with nothing else registered", func(t *testing.T) {
+		ctx := di.New().Add(di.StaticFlags{})
+		ctx.Provide(replacement, di.WhenFlag("new-writer"))
+		if _, err := di.Resolve[io.Writer](ctx); !errors.Is(err, di.ErrFlagOff) {
+			t.Errorf("expected %v got %v", di.ErrFlagOff, err)
+		}
+	})
+
+	t.Run("flags must be registered", func(t *testing.T) {
+		ctx := di.New()
+		ctx.Provide(legacy)
+		ctx.Provide(replacement, di.WhenFlag("new-writer"))
+		if _, err := di.Resolve[io.Writer](ctx); !errors.Is(err, di.ErrNotRegistered) {
+			t.Errorf("expected %v got %v", di.ErrNotRegistered, err)
+		}
+	})
+
+	t.Run("validation checks both implementations", func(t *testing.T) {
+		ctx := di.New(di.WithStrict()).Add(di.StaticFlags{})
+		ctx.Provide(func(*strings.Reader) io.Writer { return os.Stdout })
+		ctx.Provide(replacement, di.WhenFlag("new-writer"))
+		var missing *di.MissingError
+		if err := ctx.ValidateAll(); !errors.As(err, &missing) {
+			t.Errorf("expected %T got %v", missing, err)
+		}
+		if err := ctx.Validate(func(io.Writer) {}); !errors.As(err, &missing) {
+			t.Errorf("expected %T got %v", missing, err)
+		}
+	})
+
+	t.Run("registrations show the flag", func(t *testing.T) {
+		ctx := di.New()
+		ctx.Provide(replacement, di.WhenFlag("new-writer"))
+		regs := ctx.Registrations()
+		if len(regs) != 1 || regs[0].Flag != "new-writer" {
+			t.Errorf("expected flag %v got %v", "new-writer", regs)
+		}
+	})
+}
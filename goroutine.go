@@ -0,0 +1,119 @@
+package di
+
+import (
+	"context"
+	"errors"
+)
+
+// Go is like InjectAsync, but the Context keeps track of the goroutine, so that Wait can wait for it to return, and Stop
+// can stop it. This suits background workers, which can be started next to the wiring and stopped on shutdown:
+//
+//	ctx.Go(func(stdctx context.Context, q *Queue, log Logger) error { return q.Work(stdctx) })
+//	ctx.Go(func(stdctx context.Context, srv *Server) error { return srv.Run(stdctx) })
+//	...
+//	ctx.Stop(stdctx)
+//	err := ctx.Wait()
+//
+// Any parameter of type context.Context, whether of the target or of a provider called for it, is given a
+// context.Context which Stop cancels, as with InjectContext. Stop then waits for the targets to return, until its own
+// context.Context is done. A target which doesn't take a context.Context must return on its own.
+func (ctx *Context) Go(target interface{}) *Handle {
+	r := newResolution()
+	r.stdctx, r.passContext = ctx.goContext(), true
+	h := injectAsync(ctx, r, target)
+
+	ctx.lock.Lock()
+	defer ctx.lock.Unlock()
+
+	ctx.running = append(ctx.running, h)
+	// drop those which have returned, so they aren't kept forever
+	live := ctx.live[:0]
+	for _, other := range ctx.live {
+		select {
+		case <-other.Done():
+		default:
+			live = append(live, other)
+		}
+	}
+	ctx.live = append(live, h)
+	return h
+}
+
+// Wait waits for every target started with Go to return, including those started while it waits, and returns all
+// their errors joined together, along with the errors of any which couldn't be injected. Each target's error is only
+// returned by one call to Wait.
+func (ctx *Context) Wait() error {
+	errs := []error{}
+	for {
+		ctx.lock.Lock()
+		running := ctx.running
+		ctx.running = nil
+		ctx.lock.Unlock()
+
+		if len(running) == 0 {
+			return errors.Join(errs...)
+		}
+		for _, h := range running {
+			<-h.Done()
+			if err := h.Err(); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+}
+
+// goContext returns the context.Context given to goroutines started by Go,
+// hooking Stop the first time to cancel it.
+func (ctx *Context) goContext() context.Context {
+	ctx.lock.Lock()
+	if ctx.goctx == nil {
+		ctx.goctx, ctx.gocancel = context.WithCancel(context.Background())
+	}
+	stdctx, hook := ctx.goctx, !ctx.goHooked
+	ctx.goHooked = true
+	ctx.lock.Unlock()
+
+	if hook {
+		ctx.OnStop(ctx.stopGoroutines)
+	}
+	return stdctx
+}
+
+// stopGoroutines cancels the context.Context given to goroutines started by
+// Go, and waits for them to return. Goroutines started later get a new one.
+func (ctx *Context) stopGoroutines(stopctx context.Context) error {
+	ctx.lock.Lock()
+	cancel, live := ctx.gocancel, ctx.live
+	ctx.goctx, ctx.gocancel, ctx.live = nil, nil, nil
+	ctx.lock.Unlock()
+
+	if cancel != nil {
+		cancel()
+	}
+	for _, h := range live {
+		select {
+		case <-h.Done():
+		case <-stopctx.Done():
+			return stopctx.Err()
+		}
+	}
+	return nil
+}
+
+// background runs fn in a goroutine started with Go, with a context.Context
+// which is canceled once the returned function is called or the Context is
+// stopped. Both wait for fn to return.
+func (ctx *Context) background(fn func(stdctx context.Context) error) (stop func()) {
+	stdctx, cancel := context.WithCancel(ctx.goContext())
+	done := make(chan struct{})
+
+	ctx.Go(func() error {
+		defer close(done)
+		return fn(stdctx)
+	})
+
+	return func() {
+		cancel()
+		<-done
+	}
+}
@@ -0,0 +1,93 @@
+package di_test
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+
+	"github.com/mcvoid/di"
+)
+
+func TestGo(t *testing.T) {
+	t.Run("wait waits for every goroutine", func(t *testing.T) {
+		ctx := di.New().Add(logConfig{Level: "info"})
+		var done atomic.Int32
+		release := make(chan struct{})
+		for i := 0; i < 3; i++ {
+			ctx.Go(func(cfg logConfig) {
+				<-release
+				done.Add(1)
+			})
+		}
+		close(release)
+		if err := ctx.Wait(); err != nil {
+			t.Errorf("expected %v got %v", nil, err)
+		}
+		if done.Load() != 3 {
+			t.Errorf("expected %v got %v", 3, done.Load())
+		}
+	})
+
+	t.Run("goroutines started while waiting are waited for", func(t *testing.T) {
+		ctx := di.New()
+		var done atomic.Bool
+		ctx.Go(func() {
+			ctx.Go(func() { done.Store(true) })
+		})
+		ctx.Wait()
+		if !done.Load() {
+			t.Errorf("expected nested goroutine to be waited for")
+		}
+	})
+
+	t.Run("errors are joined", func(t *testing.T) {
+		ctx := di.New(di.WithStrict())
+		first, second := errors.New("first"), errors.New("second")
+		ctx.Go(func() error { return first })
+		ctx.Go(func() error { return second })
+		ctx.Go(func(*logger) {})
+
+		err := ctx.Wait()
+		var missing *di.MissingError
+		if !errors.Is(err, first) || !errors.Is(err, second) || !errors.As(err, &missing) {
+			t.Errorf("expected %v, %v, and a missing dependency got %v", first, second, err)
+		}
+		if err := ctx.Wait(); err != nil {
+			t.Errorf("expected %v got %v", nil, err)
+		}
+	})
+
+	t.Run("stop cancels the goroutines' context", func(t *testing.T) {
+		ctx := di.New()
+		var stopped atomic.Bool
+		started := make(chan struct{})
+		ctx.Go(func(stdctx context.Context) error {
+			close(started)
+			<-stdctx.Done()
+			stopped.Store(true)
+			return stdctx.Err()
+		})
+		<-started
+
+		if err := ctx.Stop(context.Background()); err != nil {
+			t.Errorf("expected %v got %v", nil, err)
+		}
+		if !stopped.Load() {
+			t.Errorf("expected stop to wait for the goroutine")
+		}
+		if err := ctx.Wait(); !errors.Is(err, context.Canceled) {
+			t.Errorf("expected %v got %v", context.Canceled, err)
+		}
+	})
+
+	t.Run("goroutines started after stop get a new context", func(t *testing.T) {
+		ctx := di.New()
+		ctx.Go(func(context.Context) {})
+		ctx.Stop(context.Background())
+		ctx.Go(func(stdctx context.Context) error { return stdctx.Err() })
+		if err := ctx.Wait(); err != nil {
+			t.Errorf("expected %v got %v", nil, err)
+		}
+	})
+}
@@ -0,0 +1,317 @@
+package di
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// nodeKind is what a node in the dependency graph stands for.
+type nodeKind string
+
+const (
+	// a value registered with Add
+	valueNode nodeKind = "value"
+	// a provider whose value is cached
+	singletonNode nodeKind = "singleton"
+	// a provider called every time
+	transientNode nodeKind = "transient"
+	// an interface which providers depend on, rather than a registration
+	interfaceNode nodeKind = "interface"
+	// a type which providers depend on but which isn't registered
+	missingNode nodeKind = "missing"
+)
+
+// graph is the wiring of a Context: its registrations, and which of them
+// each provider depends on.
+type graph struct {
+	nodes []graphNode
+	edges []graphEdge
+}
+
+type graphNode struct {
+	id   string
+	typ  reflect.Type
+	name string
+	kind nodeKind
+}
+
+// graphEdge is a dependency of a provider on a node, or, if implements is
+// set, a registration which can satisfy an interface node.
+type graphEdge struct {
+	from, to   string
+	implements bool
+}
+
+// nodeID identifies the node for a type and name.
+func nodeID(t reflect.Type, name string) string {
+	if name == "" {
+		return t.String()
+	}
+	return fmt.Sprintf("%v %q", t, name)
+}
+
+// buildGraph finds the dependency graph of the registrations in ctx itself,
+// without calling any providers. Nodes and edges are sorted so the graph is
+// the same each time.
+func buildGraph(ctx *Context) *graph {
+	deps := ctx.load().deps
+	g := &graph{}
+	nodes := map[string]bool{}
+	addNode := func(t reflect.Type, name string, kind nodeKind) string {
+		id := nodeID(t, name)
+		if !nodes[id] {
+			nodes[id] = true
+			g.nodes = append(g.nodes, graphNode{id, t, name, kind})
+		}
+		return id
+	}
+
+	keys := make([]key, 0, len(deps))
+	for k, e := range deps {
+		keys = append(keys, k)
+		addNode(k.typ, k.name, entryKind(e))
+	}
+	sort.Slice(keys, func(i, j int) bool { return nodeID(keys[i].typ, keys[i].name) < nodeID(keys[j].typ, keys[j].name) })
+
+	for _, k := range keys {
+		e := deps[k]
+		if e.prov == nil {
+			continue
+		}
+		from := nodeID(k.typ, k.name)
+		fnType := e.prov.fn.Type()
+		for i := 0; i < fnType.NumIn(); i++ {
+			for _, dep := range paramKeys(fnType.In(i)) {
+				if _, ok := deps[dep]; ok || dep.typ.Kind() != reflect.Interface {
+					kind := missingNode
+					if ok {
+						kind = entryKind(deps[dep])
+					}
+					g.edges = append(g.edges, graphEdge{from: from, to: addNode(dep.typ, dep.name, kind)})
+					continue
+				}
+
+				to := addNode(dep.typ, dep.name, interfaceNode)
+				g.edges = append(g.edges, graphEdge{from: from, to: to})
+				for _, other := range keys {
+					if other.name == dep.name && other.typ.Implements(dep.typ) {
+						g.edges = append(g.edges, graphEdge{from: to, to: nodeID(other.typ, other.name), implements: true})
+					}
+				}
+			}
+		}
+	}
+
+	sort.Slice(g.nodes, func(i, j int) bool { return g.nodes[i].id < g.nodes[j].id })
+	sort.SliceStable(g.edges, func(i, j int) bool {
+		if g.edges[i].from != g.edges[j].from {
+			return g.edges[i].from < g.edges[j].from
+		}
+		return g.edges[i].to < g.edges[j].to
+	})
+	// several parameters may have the same dependency
+	edges := g.edges[:0]
+	for i, edge := range g.edges {
+		if i == 0 || edge != g.edges[i-1] {
+			edges = append(edges, edge)
+		}
+	}
+	g.edges = edges
+	return g
+}
+
+// entryKind is the kind of node for a registration.
+func entryKind(e *entry) nodeKind {
+	switch {
+	case e.prov == nil:
+		return valueNode
+	case e.prov.lifetime == transient:
+		return transientNode
+	default:
+		return singletonNode
+	}
+}
+
+// paramKeys returns the registrations a parameter of type t would be
+// resolved from: the fields of a parameter object, the named dependency for
+// Named, or the element type of a group.
+func paramKeys(t reflect.Type) []key {
+	switch {
+	case isIn(t):
+		keys := []key{}
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			opts, err := parseTag(field.Tag.Get(tagName))
+			if !field.IsExported() || field.Anonymous && field.Type == inType || err != nil || opts.skip {
+				continue
+			}
+			if opts.name != "" {
+				keys = append(keys, key{field.Type, opts.name})
+				continue
+			}
+			keys = append(keys, paramKeys(field.Type)...)
+		}
+		return keys
+	case t.Implements(qualifiedType):
+		typ, name := reflect.Zero(t).Interface().(qualified).qualifier()
+		return []key{{typ, name}}
+	case t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Interface:
+		return []key{{typ: t.Elem()}}
+	}
+	return []key{{typ: t}}
+}
+
+// WriteDOT writes the dependency graph of the Context to w in the DOT language used by Graphviz, so the wiring of a
+// large application can be visualized:
+//
+//	ctx.WriteDOT(f)
+//	// then run: dot -Tsvg deps.dot > deps.svg
+//
+// Each registration is a node, with an edge from each provider to the registrations it depends on. An interface which
+// a provider depends on is a node of its own, with dashed edges to every registration which implements it. Types which
+// a provider depends on but which aren't registered are drawn in red. Only registrations in the Context itself are
+// included, not those of its parent, and no providers are called.
+func (ctx *Context) WriteDOT(w io.Writer) error {
+	g := buildGraph(ctx)
+
+	b := &strings.Builder{}
+	b.WriteString("digraph di {\n")
+	for _, n := range g.nodes {
+		fmt.Fprintf(b, "\t%q [%s];\n", n.id, dotAttributes(n))
+	}
+	for _, e := range g.edges {
+		if e.implements {
+			fmt.Fprintf(b, "\t%q -> %q [style=dashed];\n", e.from, e.to)
+		} else {
+			fmt.Fprintf(b, "\t%q -> %q;\n", e.from, e.to)
+		}
+	}
+	b.WriteString("}\n")
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// dotAttributes gives the DOT attributes to draw a node with.
+func dotAttributes(n graphNode) string {
+	switch n.kind {
+	case singletonNode:
+		return "shape=box, style=rounded"
+	case transientNode:
+		return `shape=box, style="rounded,dashed"`
+	case interfaceNode:
+		return "shape=ellipse"
+	case missingNode:
+		return "shape=box, color=red"
+	default:
+		return "shape=box"
+	}
+}
+
+// WriteMermaid writes the dependency graph of the Context to w as a Mermaid flowchart, which can be pasted into
+// Markdown documents which render Mermaid. The graph is the same as WriteDOT's: interfaces are hexagons with dotted
+// edges to their implementations, providers are rounded, and types which aren't registered are outlined in red.
+func (ctx *Context) WriteMermaid(w io.Writer) error {
+	g := buildGraph(ctx)
+
+	ids := make(map[string]string, len(g.nodes))
+	b := &strings.Builder{}
+	b.WriteString("flowchart LR\n")
+	for i, n := range g.nodes {
+		ids[n.id] = fmt.Sprintf("n%d", i)
+		left, right := mermaidShape(n)
+		// quotes can't be escaped with a backslash in a Mermaid label
+		label := strings.ReplaceAll(n.id, `"`, "#quot;")
+		fmt.Fprintf(b, "    %s%s\"%s\"%s\n", ids[n.id], left, label, right)
+	}
+	for _, e := range g.edges {
+		arrow := "-->"
+		if e.implements {
+			arrow = "-.->"
+		}
+		fmt.Fprintf(b, "    %s %s %s\n", ids[e.from], arrow, ids[e.to])
+	}
+	for _, n := range g.nodes {
+		if n.kind == missingNode {
+			fmt.Fprintf(b, "    style %s stroke:red\n", ids[n.id])
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// mermaidShape gives the brackets to draw a node with.
+func mermaidShape(n graphNode) (string, string) {
+	switch n.kind {
+	case singletonNode:
+		return "(", ")"
+	case transientNode:
+		return "([", "])"
+	case interfaceNode:
+		return "{{", "}}"
+	default:
+		return "[", "]"
+	}
+}
+
+// jsonGraph is the form of the graph written by WriteJSON.
+type jsonGraph struct {
+	Nodes []jsonNode `json:"nodes"`
+	Edges []jsonEdge `json:"edges"`
+}
+
+type jsonNode struct {
+	ID    string `json:"id"`
+	Type  string `json:"type"`
+	Name  string `json:"name,omitempty"`
+	Kind  string `json:"kind"`
+	Scope string `json:"scope,omitempty"`
+}
+
+type jsonEdge struct {
+	From       string `json:"from"`
+	To         string `json:"to"`
+	Implements bool   `json:"implements,omitempty"`
+}
+
+// WriteJSON writes the dependency graph of the Context to w as indented JSON, so tools can diff the wiring between
+// releases. The graph is the same as WriteDOT's. It has the form:
+//
+//	{
+//	  "nodes": [
+//	    {"id": "*sql.DB \"replica\"", "type": "*sql.DB", "name": "replica", "kind": "provider", "scope": "singleton"},
+//	    {"id": "io.Writer", "type": "io.Writer", "kind": "interface"}
+//	  ],
+//	  "edges": [
+//	    {"from": "*app.Server", "to": "io.Writer"},
+//	    {"from": "io.Writer", "to": "*os.File", "implements": true}
+//	  ]
+//	}
+//
+// A node's kind is "value" for a value registered with Add, "provider" for a provider, "interface" for an interface
+// which providers depend on, or "missing" for a type which providers depend on but which isn't registered. A provider's
+// scope is "singleton" or "transient". Nodes and edges are sorted, so the same wiring always gives the same output.
+func (ctx *Context) WriteJSON(w io.Writer) error {
+	g := buildGraph(ctx)
+
+	out := jsonGraph{Nodes: []jsonNode{}, Edges: []jsonEdge{}}
+	for _, n := range g.nodes {
+		node := jsonNode{ID: n.id, Type: n.typ.String(), Name: n.name, Kind: string(n.kind)}
+		if n.kind == singletonNode || n.kind == transientNode {
+			node.Kind, node.Scope = "provider", string(n.kind)
+		}
+		out.Nodes = append(out.Nodes, node)
+	}
+	for _, e := range g.edges {
+		out.Edges = append(out.Edges, jsonEdge{e.from, e.to, e.implements})
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(out)
+}
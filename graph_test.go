@@ -0,0 +1,169 @@
+package di_test
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/mcvoid/di"
+)
+
+type graphParams struct {
+	di.In
+
+	Out *os.File `di:"name=out"`
+	W   io.Writer
+}
+
+func newGraphContext() *di.Context {
+	ctx := di.New().Add(&bytes.Buffer{}).AddNamed("out", os.Stdout)
+	ctx.Provide(func(p graphParams) *strings.Builder { return &strings.Builder{} })
+	ctx.Provide(func(r io.Reader, s *strings.Reader) *bytes.Reader { return nil }, di.Transient())
+	return ctx
+}
+
+func TestWriteDOT(t *testing.T) {
+	t.Run("graph", func(t *testing.T) {
+		b := &bytes.Buffer{}
+		err := newGraphContext().WriteDOT(b)
+		if err != nil {
+			t.Errorf("expected %v got %v", nil, err)
+		}
+
+		expected := `digraph di {
+	"*bytes.Buffer" [shape=box];
+	"*bytes.Reader" [shape=box, style="rounded,dashed"];
+	"*os.File \"out\"" [shape=box];
+	"*strings.Builder" [shape=box, style=rounded];
+	"*strings.Reader" [shape=box, color=red];
+	"io.Reader" [shape=ellipse];
+	"io.Writer" [shape=ellipse];
+	"*bytes.Reader" -> "*strings.Reader";
+	"*bytes.Reader" -> "io.Reader";
+	"*strings.Builder" -> "*os.File \"out\"";
+	"*strings.Builder" -> "io.Writer";
+	"io.Reader" -> "*bytes.Buffer" [style=dashed];
+	"io.Reader" -> "*bytes.Reader" [style=dashed];
+	"io.Writer" -> "*bytes.Buffer" [style=dashed];
+	"io.Writer" -> "*strings.Builder" [style=dashed];
+}
+`
+		if b.String() != expected {
+			t.Errorf("expected %v got %v", expected, b.String())
+		}
+	})
+
+	t.Run("empty", func(t *testing.T) {
+		b := &bytes.Buffer{}
+		di.New().WriteDOT(b)
+
+		expected := "digraph di {\n}\n"
+		if b.String() != expected {
+			t.Errorf("expected %v got %v", expected, b.String())
+		}
+	})
+}
+
+func TestWriteMermaid(t *testing.T) {
+	t.Run("graph", func(t *testing.T) {
+		b := &bytes.Buffer{}
+		err := newGraphContext().WriteMermaid(b)
+		if err != nil {
+			t.Errorf("expected %v got %v", nil, err)
+		}
+
+		expected := `flowchart LR
+    n0["*bytes.Buffer"]
+    n1(["*bytes.Reader"])
+    n2["*os.File #quot;out#quot;"]
+    n3("*strings.Builder")
+    n4["*strings.Reader"]
+    n5{{"io.Reader"}}
+    n6{{"io.Writer"}}
+    n1 --> n4
+    n1 --> n5
+    n3 --> n2
+    n3 --> n6
+    n5 -.-> n0
+    n5 -.-> n1
+    n6 -.-> n0
+    n6 -.-> n3
+    style n4 stroke:red
+`
+		if b.String() != expected {
+			t.Errorf("expected %v got %v", expected, b.String())
+		}
+	})
+
+	t.Run("empty", func(t *testing.T) {
+		b := &bytes.Buffer{}
+		di.New().WriteMermaid(b)
+
+		expected := "flowchart LR\n"
+		if b.String() != expected {
+			t.Errorf("expected %v got %v", expected, b.String())
+		}
+	})
+}
+
+func TestWriteJSON(t *testing.T) {
+	t.Run("graph", func(t *testing.T) {
+		ctx := di.New().AddNamed("out", os.Stdout)
+		ctx.Provide(func(w io.Writer) *strings.Builder { return &strings.Builder{} }, di.Transient())
+
+		b := &bytes.Buffer{}
+		err := ctx.WriteJSON(b)
+		if err != nil {
+			t.Errorf("expected %v got %v", nil, err)
+		}
+
+		expected := `{
+  "nodes": [
+    {
+      "id": "*os.File \"out\"",
+      "type": "*os.File",
+      "name": "out",
+      "kind": "value"
+    },
+    {
+      "id": "*strings.Builder",
+      "type": "*strings.Builder",
+      "kind": "provider",
+      "scope": "transient"
+    },
+    {
+      "id": "io.Writer",
+      "type": "io.Writer",
+      "kind": "interface"
+    }
+  ],
+  "edges": [
+    {
+      "from": "*strings.Builder",
+      "to": "io.Writer"
+    },
+    {
+      "from": "io.Writer",
+      "to": "*strings.Builder",
+      "implements": true
+    }
+  ]
+}
+`
+		if b.String() != expected {
+			t.Errorf("expected %v got %v", expected, b.String())
+		}
+	})
+
+	t.Run("empty", func(t *testing.T) {
+		b := &bytes.Buffer{}
+		di.New().WriteJSON(b)
+
+		expected := "{\n  \"nodes\": [],\n  \"edges\": []\n}\n"
+		if b.String() != expected {
+			t.Errorf("expected %v got %v", expected, b.String())
+		}
+	})
+}
@@ -0,0 +1,21 @@
+package di
+
+// AfterInjecter is implemented by objects which need to do some initialization once their dependencies are in place.
+// AfterInject is called on:
+//
+//   - values built by a provider, once the provider returns
+//   - objects injected with Inject or InjectMethod, once their bind method returns
+//   - structs filled with Fill, once their fields are set
+//
+// If AfterInject returns an error, the injection fails with that error.
+type AfterInjecter interface {
+	AfterInject() error
+}
+
+// afterInject calls AfterInject on v if it implements AfterInjecter.
+func afterInject(v interface{}) error {
+	if a, ok := v.(AfterInjecter); ok {
+		return a.AfterInject()
+	}
+	return nil
+}
@@ -0,0 +1,84 @@
+package di_test
+
+import (
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/mcvoid/di"
+)
+
+// a test value which records when AfterInject is called
+type initializer struct {
+	File   *os.File
+	inited bool
+	err    error
+}
+
+func (i *initializer) Bind(f *os.File) {
+	i.File = f
+}
+
+func (i *initializer) AfterInject() error {
+	if i.File == nil {
+		return errors.New("expected dependencies to be injected first")
+	}
+	i.inited = true
+	return i.err
+}
+
+func TestAfterInject(t *testing.T) {
+	t.Run("called after bind", func(t *testing.T) {
+		ctx := di.New().Add(os.Stdin)
+
+		i := initializer{}
+		err := ctx.Inject(&i)
+		if err != nil {
+			t.Errorf("expected %v got %v", nil, err)
+		}
+		if !i.inited {
+			t.Errorf("expected AfterInject to be called")
+		}
+	})
+
+	t.Run("called after fill", func(t *testing.T) {
+		ctx := di.New().Add(os.Stdin)
+
+		i := initializer{}
+		err := ctx.Fill(&i)
+		if err != nil {
+			t.Errorf("expected %v got %v", nil, err)
+		}
+		if !i.inited {
+			t.Errorf("expected AfterInject to be called")
+		}
+	})
+
+	t.Run("called after provider", func(t *testing.T) {
+		ctx := di.New().Add(os.Stdin)
+		ctx.Provide(func(f *os.File) *initializer { return &initializer{File: f} })
+
+		i, err := di.Resolve[*initializer](ctx)
+		if err != nil {
+			t.Errorf("expected %v got %v", nil, err)
+		}
+		if !i.inited {
+			t.Errorf("expected AfterInject to be called")
+		}
+	})
+
+	t.Run("errors are returned", func(t *testing.T) {
+		errBoom := errors.New("boom")
+		ctx := di.New().Add(os.Stdin)
+		ctx.Provide(func(f *os.File) *initializer { return &initializer{File: f, err: errBoom} })
+
+		_, err := di.Resolve[*initializer](ctx)
+		if !errors.Is(err, errBoom) {
+			t.Errorf("expected %v got %v", errBoom, err)
+		}
+		err = ctx.Inject(&initializer{err: errBoom})
+		if !errors.Is(err, errBoom) {
+			t.Errorf("expected %v got %v", errBoom, err)
+		}
+	})
+}
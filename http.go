@@ -0,0 +1,184 @@
+package di
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"reflect"
+)
+
+var (
+	responseWriterType = reflect.TypeOf((*http.ResponseWriter)(nil)).Elem()
+	requestType        = reflect.TypeOf((*http.Request)(nil))
+)
+
+// requestKey is the context.Context key under which Middleware stores each
+// request's Context.
+type requestKey struct{}
+
+// Middleware returns net/http middleware which gives each request its own child of ctx, which handlers can inject from
+// alongside the application's dependencies:
+//
+//	http.ListenAndServe(":8080", di.Middleware(app)(mux))
+//	...
+//	func (h *handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+//		di.FromContext(r.Context()).Inject(func(r *http.Request, db *sql.DB) { ... })
+//	}
+//
+// The child is seeded with the *http.Request, the http.ResponseWriter, and the request's context.Context, and stored in
+// the request's context, where handlers can get it with FromContext. Once the handler returns, the child is closed as
+// with Close, so dependencies built for the request are released; failures to close are logged. As with Child,
+// providers registered in ctx don't see the request, so providers which depend on it must be registered in the child.
+func Middleware(ctx *Context) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			child := ctx.Child()
+			r = r.WithContext(context.WithValue(r.Context(), requestKey{}, child))
+			seed(child, w, r)
+			defer release(ctx, child, r)
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// FromContext returns the Context stored in stdctx by Middleware, or nil if there isn't one.
+func FromContext(stdctx context.Context) *Context {
+	ctx, _ := stdctx.Value(requestKey{}).(*Context)
+	return ctx
+}
+
+// Handler builds an http.Handler by injecting the Context into constructor, a function which takes the handler's
+// dependencies and returns it, optionally with an error, like a provider. The dependencies are resolved once, when
+// Handler is called, so a router can be declared as a list of constructors:
+//
+//	mux.Handle("/users", di.MustHandler(ctx, NewUsersHandler))
+//	mux.Handle("/orders", di.MustHandler(ctx, NewOrdersHandler))
+//
+// If constructor's first return value isn't an http.Handler, an error wrapping ErrResultType is returned without
+// calling it.
+func Handler(ctx *Context, constructor interface{}) (http.Handler, error) {
+	return Call[http.Handler](ctx, constructor)
+}
+
+// MustHandler is like Handler but panics if the handler can't be built.
+func MustHandler(ctx *Context, constructor interface{}) http.Handler {
+	h, err := Handler(ctx, constructor)
+	if err != nil {
+		panic(err)
+	}
+	return h
+}
+
+// HandlerFunc adapts fn, a function taking an http.ResponseWriter, an *http.Request, and then any dependencies, to an
+// http.HandlerFunc, so handlers don't need closures to get their dependencies:
+//
+//	func listUsers(w http.ResponseWriter, r *http.Request, store *Store, log Logger) { ... }
+//	...
+//	mux.HandleFunc("/users", di.MustHandlerFunc(ctx, listUsers))
+//
+// For each request, fn is injected from a child of ctx seeded with the request's http.ResponseWriter, *http.Request, and
+// context.Context, so its dependencies can include those too. If the request went through Middleware, the child is made
+// from the request's Context instead, so what was added to it for the request can be injected as well. If the
+// injection fails, or fn returns a non-nil error, the error is logged, and unless fn has already written a response, the
+// request gets a 500 Internal Server Error. As in Middleware, the child is stored in the request's context, and closed
+// once fn returns.
+//
+// If fn doesn't take an http.ResponseWriter and an *http.Request as its first two parameters, an error wrapping
+// ErrHandlerFunc is returned.
+func HandlerFunc(ctx *Context, fn interface{}) (http.HandlerFunc, error) {
+	if fn == nil {
+		return nil, ErrNilInjectee
+	}
+	t := reflect.TypeOf(fn)
+	if t.Kind() != reflect.Func || t.NumIn() < 2 || t.In(0) != responseWriterType || t.In(1) != requestType {
+		return nil, fmt.Errorf("%v %w", t, ErrHandlerFunc)
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		base := FromContext(r.Context())
+		if base == nil {
+			base = ctx
+		}
+		child := base.Child()
+		r = r.WithContext(context.WithValue(r.Context(), requestKey{}, child))
+		tw := &trackingWriter{ResponseWriter: w}
+		seed(child, tw, r)
+		defer release(ctx, child, r)
+		if err := child.Inject(fn); err != nil {
+			logDebug(ctx, "di: handler failed", slog.String("path", r.URL.Path), slog.String("error", err.Error()))
+			if !tw.wrote {
+				http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			}
+		}
+	}, nil
+}
+
+// MustHandlerFunc is like HandlerFunc but panics if fn can't be adapted.
+func MustHandlerFunc(ctx *Context, fn interface{}) http.HandlerFunc {
+	h, err := HandlerFunc(ctx, fn)
+	if err != nil {
+		panic(err)
+	}
+	return h
+}
+
+// seed adds a request's values to its child Context.
+func seed(child *Context, w http.ResponseWriter, r *http.Request) {
+	child.Add(r)
+	AddAs[http.ResponseWriter](child, w)
+	AddAs[context.Context](child, r.Context())
+}
+
+// release closes a request's child Context, logging any failure.
+func release(ctx, child *Context, r *http.Request) {
+	if err := child.Close(); err != nil {
+		logDebug(ctx, "di: closing request failed", slog.String("path", r.URL.Path), slog.String("error", err.Error()))
+	}
+}
+
+// trackingWriter records whether a handler has started its response. It
+// keeps the optional interfaces of the writer it wraps which handlers look
+// for, such as http.Hijacker for websockets.
+type trackingWriter struct {
+	http.ResponseWriter
+	wrote bool
+}
+
+func (w *trackingWriter) WriteHeader(code int) {
+	w.wrote = true
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *trackingWriter) Write(b []byte) (int, error) {
+	w.wrote = true
+	return w.ResponseWriter.Write(b)
+}
+
+// Flush flushes the underlying writer, if it can be.
+func (w *trackingWriter) Flush() {
+	w.wrote = true
+	http.NewResponseController(w.ResponseWriter).Flush()
+}
+
+// Hijack takes over the connection from the underlying writer, if it can be.
+func (w *trackingWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	w.wrote = true
+	return http.NewResponseController(w.ResponseWriter).Hijack()
+}
+
+// ReadFrom copies from r with the underlying writer's ReadFrom, if it has one.
+func (w *trackingWriter) ReadFrom(r io.Reader) (int64, error) {
+	w.wrote = true
+	if rf, ok := w.ResponseWriter.(io.ReaderFrom); ok {
+		return rf.ReadFrom(r)
+	}
+	return io.Copy(w.ResponseWriter, r)
+}
+
+// Unwrap returns the underlying writer, for http.ResponseController.
+func (w *trackingWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
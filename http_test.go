@@ -0,0 +1,243 @@
+package di_test
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/mcvoid/di"
+)
+
+// a request-scoped dependency which records being closed
+type requestConn struct {
+	closed bool
+}
+
+func (c *requestConn) Close() error {
+	c.closed = true
+	return nil
+}
+
+func TestMiddleware(t *testing.T) {
+	t.Run("requests get a child context", func(t *testing.T) {
+		app := di.New().Add(logConfig{Level: "info"})
+		handler := di.Middleware(app)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			err := di.FromContext(r.Context()).Inject(func(req *http.Request, rw http.ResponseWriter, stdctx context.Context, cfg logConfig) {
+				if req.Context() != stdctx {
+					t.Errorf("expected %v got %v", req.Context(), stdctx)
+				}
+				io.WriteString(rw, req.URL.Path+" "+cfg.Level)
+			})
+			if err != nil {
+				t.Errorf("expected %v got %v", nil, err)
+			}
+		}))
+
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest("GET", "/hello", nil))
+		if w.Body.String() != "/hello info" {
+			t.Errorf("expected %v got %v", "/hello info", w.Body.String())
+		}
+		if di.Has[*http.Request](app) {
+			t.Errorf("expected request to not be added to the application context")
+		}
+	})
+
+	t.Run("the child is closed after the handler returns", func(t *testing.T) {
+		conn := &requestConn{}
+		handler := di.Middleware(di.New())(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			di.FromContext(r.Context()).Add(conn)
+			if conn.closed {
+				t.Errorf("expected conn to be open")
+			}
+		}))
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+		if !conn.closed {
+			t.Errorf("expected conn to be closed")
+		}
+	})
+
+	t.Run("contexts without a child", func(t *testing.T) {
+		if ctx := di.FromContext(context.Background()); ctx != nil {
+			t.Errorf("expected %v got %v", nil, ctx)
+		}
+	})
+}
+
+// a handler which greets at the configured level
+type greeter struct {
+	level string
+}
+
+func (g *greeter) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	io.WriteString(w, g.level)
+}
+
+func TestHandler(t *testing.T) {
+	t.Run("constructors are injected once", func(t *testing.T) {
+		ctx := di.New().Add(logConfig{Level: "info"})
+		calls := 0
+		h, err := di.Handler(ctx, func(cfg logConfig) *greeter {
+			calls++
+			return &greeter{cfg.Level}
+		})
+		if err != nil {
+			t.Fatalf("expected %v got %v", nil, err)
+		}
+
+		for i := 0; i < 2; i++ {
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+			if w.Body.String() != "info" {
+				t.Errorf("expected %v got %v", "info", w.Body.String())
+			}
+		}
+		if calls != 1 {
+			t.Errorf("expected %v got %v", 1, calls)
+		}
+	})
+
+	t.Run("constructor errors are returned", func(t *testing.T) {
+		expected := errors.New("no route")
+		_, err := di.Handler(di.New(), func() (http.Handler, error) { return nil, expected })
+		if !errors.Is(err, expected) {
+			t.Errorf("expected %v got %v", expected, err)
+		}
+	})
+
+	t.Run("constructors must return a handler", func(t *testing.T) {
+		_, err := di.Handler(di.New(), func() string { return "" })
+		if !errors.Is(err, di.ErrResultType) {
+			t.Errorf("expected %v got %v", di.ErrResultType, err)
+		}
+	})
+
+	t.Run("must handler panics", func(t *testing.T) {
+		defer func() {
+			if recover() == nil {
+				t.Errorf("expected panic")
+			}
+		}()
+		di.MustHandler(di.New(), func() string { return "" })
+	})
+}
+
+// a writer which can be hijacked, like the server's own
+type hijackRecorder struct {
+	*httptest.ResponseRecorder
+	hijacked bool
+}
+
+func (w *hijackRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	w.hijacked = true
+	return nil, nil, nil
+}
+
+func TestHandlerFunc(t *testing.T) {
+	t.Run("requests and dependencies are injected", func(t *testing.T) {
+		ctx := di.New().Add(logConfig{Level: "info"})
+		h, err := di.HandlerFunc(ctx, func(w http.ResponseWriter, r *http.Request, cfg logConfig, stdctx context.Context) {
+			if stdctx != r.Context() {
+				t.Errorf("expected %v got %v", r.Context(), stdctx)
+			}
+			io.WriteString(w, r.URL.Path+" "+cfg.Level)
+		})
+		if err != nil {
+			t.Fatalf("expected %v got %v", nil, err)
+		}
+
+		w := httptest.NewRecorder()
+		h(w, httptest.NewRequest("GET", "/hello", nil))
+		if w.Body.String() != "/hello info" {
+			t.Errorf("expected %v got %v", "/hello info", w.Body.String())
+		}
+	})
+
+	t.Run("the middleware's context is used", func(t *testing.T) {
+		h := di.MustHandlerFunc(di.New(), func(w http.ResponseWriter, r *http.Request, cfg logConfig) {
+			io.WriteString(w, cfg.Level)
+		})
+		app := di.New().Add(logConfig{Level: "debug"})
+		w := httptest.NewRecorder()
+		di.Middleware(app)(h).ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+		if w.Body.String() != "debug" {
+			t.Errorf("expected %v got %v", "debug", w.Body.String())
+		}
+	})
+
+	t.Run("errors are internal server errors", func(t *testing.T) {
+		h := di.MustHandlerFunc(di.New(), func(w http.ResponseWriter, r *http.Request) error {
+			return errors.New("failed")
+		})
+		w := httptest.NewRecorder()
+		h(w, httptest.NewRequest("GET", "/", nil))
+		if w.Code != http.StatusInternalServerError {
+			t.Errorf("expected %v got %v", http.StatusInternalServerError, w.Code)
+		}
+	})
+
+	t.Run("errors after writing keep the response", func(t *testing.T) {
+		h := di.MustHandlerFunc(di.New(), func(w http.ResponseWriter, r *http.Request) error {
+			w.WriteHeader(http.StatusAccepted)
+			io.WriteString(w, "partial")
+			return errors.New("failed")
+		})
+		w := httptest.NewRecorder()
+		h(w, httptest.NewRequest("GET", "/", nil))
+		if w.Code != http.StatusAccepted || w.Body.String() != "partial" {
+			t.Errorf("expected %v %v got %v %v", http.StatusAccepted, "partial", w.Code, w.Body.String())
+		}
+	})
+
+	t.Run("writers can be hijacked", func(t *testing.T) {
+		h := di.MustHandlerFunc(di.New(), func(w http.ResponseWriter, r *http.Request) error {
+			hj, ok := w.(http.Hijacker)
+			if !ok {
+				t.Fatalf("expected %v got %T", "http.Hijacker", w)
+			}
+			if _, _, err := hj.Hijack(); err != nil {
+				t.Errorf("expected %v got %v", nil, err)
+			}
+			return errors.New("failed after hijacking")
+		})
+		w := &hijackRecorder{ResponseRecorder: httptest.NewRecorder()}
+		h(w, httptest.NewRequest("GET", "/", nil))
+		if !w.hijacked {
+			t.Errorf("expected the connection to be hijacked")
+		}
+		if w.Body.Len() != 0 {
+			t.Errorf("expected %v got %v", "", w.Body.String())
+		}
+	})
+
+	t.Run("the request's context is closed", func(t *testing.T) {
+		conn := &requestConn{}
+		h := di.MustHandlerFunc(di.New(), func(w http.ResponseWriter, r *http.Request) {
+			di.FromContext(r.Context()).Add(conn)
+		})
+		h(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+		if !conn.closed {
+			t.Errorf("expected conn to be closed")
+		}
+	})
+
+	t.Run("handlers must take a writer and request first", func(t *testing.T) {
+		for _, fn := range []interface{}{
+			func(r *http.Request, w http.ResponseWriter) {},
+			func(w http.ResponseWriter) {},
+			"handler",
+		} {
+			if _, err := di.HandlerFunc(di.New(), fn); !errors.Is(err, di.ErrHandlerFunc) {
+				t.Errorf("expected %v got %v", di.ErrHandlerFunc, err)
+			}
+		}
+		if _, err := di.HandlerFunc(di.New(), nil); !errors.Is(err, di.ErrNilInjectee) {
+			t.Errorf("expected %v got %v", di.ErrNilInjectee, err)
+		}
+	})
+}
@@ -0,0 +1,58 @@
+package di
+
+import "reflect"
+
+// The index maps each interface type which has been resolved in a Context to
+// the keys of the dependencies registered there which implement it, so that
+// resolving an interface is a map lookup rather than a call to Implements for
+// every dependency. An interface is indexed the first time it is resolved,
+// since the interfaces parameters will ask for can't be known in advance, and
+// each new snapshot's index is updated from the last as dependencies are
+// registered and removed.
+
+// implementers returns the keys of the dependencies registered in the
+// snapshot whose types implement the interface type iface.
+func implementers(s *state, iface reflect.Type) []key {
+	if keys, ok := s.index.Load(iface); ok {
+		return keys.([]key)
+	}
+
+	keys := []key{}
+	for k := range s.deps {
+		if k.typ.Implements(iface) {
+			keys = append(keys, k)
+		}
+	}
+	if s != empty {
+		s.index.Store(iface, keys)
+	}
+	return keys
+}
+
+// reindex updates the keys implementing iface from an older snapshot for the
+// new snapshot s, given the keys registered in between. Keys which are no
+// longer registered are dropped.
+func reindex(s *state, iface reflect.Type, keys []key, added []key) []key {
+	updated := make([]key, 0, len(keys)+len(added))
+	for _, k := range keys {
+		if _, ok := s.deps[k]; ok {
+			updated = append(updated, k)
+		}
+	}
+	for _, k := range added {
+		if _, ok := s.deps[k]; ok && k.typ.Implements(iface) && !containsKey(updated, k) {
+			updated = append(updated, k)
+		}
+	}
+	return updated
+}
+
+// containsKey reports whether k is in keys.
+func containsKey(keys []key, k key) bool {
+	for _, other := range keys {
+		if other == k {
+			return true
+		}
+	}
+	return false
+}
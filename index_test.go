@@ -0,0 +1,71 @@
+package di_test
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/mcvoid/di"
+)
+
+func TestInterfaceIndex(t *testing.T) {
+	t.Run("registrations after an interface is resolved are found", func(t *testing.T) {
+		ctx := di.New().Add(&bytes.Buffer{})
+		if _, err := di.Resolve[io.Reader](ctx); err != nil {
+			t.Errorf("expected %v got %v", nil, err)
+		}
+
+		ctx.Add(strings.NewReader(""))
+		if _, err := di.Resolve[io.Reader](ctx); !errors.Is(err, di.ErrAmbiguous) {
+			t.Errorf("expected %v got %v", di.ErrAmbiguous, err)
+		}
+		if readers := di.MustResolve[[]io.Reader](ctx); len(readers) != 2 {
+			t.Errorf("expected %v got %v", 2, len(readers))
+		}
+	})
+
+	t.Run("removed registrations are not found", func(t *testing.T) {
+		for _, test := range []struct {
+			name   string
+			remove func(ctx *di.Context)
+		}{
+			{"Remove", func(ctx *di.Context) { ctx.Remove(&bytes.Buffer{}) }},
+			{"RemoveType", func(ctx *di.Context) { di.Remove[*bytes.Buffer](ctx) }},
+		} {
+			t.Run(test.name, func(t *testing.T) {
+				ctx := di.New().Add(&bytes.Buffer{})
+				if !di.Has[io.Writer](ctx) {
+					t.Errorf("expected writer")
+				}
+
+				test.remove(ctx)
+				if di.Has[io.Writer](ctx) {
+					t.Errorf("expected no writer")
+				}
+				if writers := di.MustResolve[[]io.Writer](ctx); writers != nil {
+					t.Errorf("expected %v got %v", nil, writers)
+				}
+			})
+		}
+	})
+
+	t.Run("rolled back registrations are not found", func(t *testing.T) {
+		ctx := di.New()
+		cp := ctx.Checkpoint()
+		ctx.Add(&bytes.Buffer{})
+		if !di.Has[io.Writer](ctx) {
+			t.Errorf("expected writer")
+		}
+
+		ctx.Rollback(cp)
+		if di.Has[io.Writer](ctx) {
+			t.Errorf("expected no writer")
+		}
+		ctx.Add(&strings.Builder{})
+		if !di.Has[io.Writer](ctx) {
+			t.Errorf("expected writer")
+		}
+	})
+}
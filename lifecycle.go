@@ -0,0 +1,276 @@
+package di
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"runtime"
+	"sort"
+	"sync"
+)
+
+// Starter is implemented by dependencies which need to be started before use, such as servers and workers.
+type Starter interface {
+	Start(ctx context.Context) error
+}
+
+// Stopper is implemented by dependencies which need to be stopped when the program shuts down.
+type Stopper interface {
+	Stop(ctx context.Context) error
+}
+
+// Hook is a function run when a Context is started or stopped.
+type Hook func(ctx context.Context) error
+
+// OnStart registers a hook to be run by Start, after every dependency has been started. Hooks are run in the order they
+// were registered.
+func (ctx *Context) OnStart(hook Hook) *Context {
+	ctx.lock.Lock()
+	defer ctx.lock.Unlock()
+
+	ctx.onStart = append(ctx.onStart, hook)
+	return ctx
+}
+
+// OnStop registers a hook to be run by Stop, before any dependency is stopped. Hooks are run in the reverse of the order
+// they were registered.
+func (ctx *Context) OnStop(hook Hook) *Context {
+	ctx.lock.Lock()
+	defer ctx.lock.Unlock()
+
+	ctx.onStop = append(ctx.onStop, hook)
+	return ctx
+}
+
+// Start builds every dependency registered in the Context, calling singleton providers which haven't been called yet,
+// then starts each dependency which implements Starter, and finally runs the hooks registered with OnStart.
+//
+// Dependencies are started in dependency order: values added to the Context are started in the order they were added,
+// and values built by providers are started after the dependencies they were built from. Transient providers are not
+// called, and dependencies in a parent Context are not started. If the Context was created with WithParallelStart,
+// providers which don't depend on each other are called concurrently.
+//
+// Providers which take a context.Context are given stdctx, as with InjectContext. If stdctx is canceled or past its
+// deadline while dependencies are being built, no more providers are called, and Start returns a CanceledError listing
+// the providers which were.
+//
+// Start stops at the first error and returns it. Whatever was started before the error can be stopped with Stop.
+func (ctx *Context) Start(stdctx context.Context) error {
+	ctx.lock.RLock()
+	hooks := append([]Hook{}, ctx.onStart...)
+	ctx.lock.RUnlock()
+
+	p := &progress{}
+	if err := buildParallel(ctx, stdctx, p); err != nil {
+		return p.canceled(stdctx, err)
+	}
+	deps, err := built(ctx, &resolution{stdctx: stdctx, passContext: true, progress: p})
+	if err != nil {
+		return p.canceled(stdctx, err)
+	}
+
+	for _, dep := range deps {
+		if s, ok := dep.Interface().(Starter); ok {
+			spanctx, end := trace(ctx, stdctx, "start "+dep.Type().String())
+			err := s.Start(spanctx)
+			end(err)
+			if err != nil {
+				return fmt.Errorf("starting %v: %w", dep.Type(), err)
+			}
+		}
+	}
+	for _, hook := range hooks {
+		spanctx, end := trace(ctx, stdctx, "start hook "+describe(hook, ""))
+		err := hook(spanctx)
+		end(err)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Stop runs the hooks registered with OnStop in reverse order, then stops each dependency which implements Stopper in
+// the reverse of the order Start would start them. Only dependencies which have been built are stopped; no providers
+// are called.
+//
+// Every hook and dependency is stopped even if some fail, and all the failures are returned joined together.
+func (ctx *Context) Stop(stdctx context.Context) error {
+	ctx.lock.RLock()
+	hooks := append([]Hook{}, ctx.onStop...)
+	ctx.lock.RUnlock()
+	deps, _ := built(ctx, nil)
+
+	errs := []error{}
+	for i := len(hooks) - 1; i >= 0; i-- {
+		if err := hooks[i](stdctx); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	for i := len(deps) - 1; i >= 0; i-- {
+		if s, ok := deps[i].Interface().(Stopper); ok {
+			if err := s.Stop(stdctx); err != nil {
+				errs = append(errs, fmt.Errorf("stopping %v: %w", deps[i].Type(), err))
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Close closes each dependency which implements io.Closer, in the reverse of the order they were built, so that a
+// dependency is closed after everything built from it. Only dependencies which have been built are closed; no providers
+// are called. Dependencies in a parent Context are not closed.
+//
+// Every dependency is closed even if some fail, and all the failures are returned joined together.
+func (ctx *Context) Close() error {
+	deps, _ := built(ctx, nil)
+
+	errs := []error{}
+	for i := len(deps) - 1; i >= 0; i-- {
+		if c, ok := deps[i].Interface().(io.Closer); ok {
+			if err := c.Close(); err != nil {
+				errs = append(errs, fmt.Errorf("closing %v: %w", deps[i].Type(), err))
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// built returns the values of the Context's own dependencies in the order
+// they were built. If r is not nil, singleton providers which haven't been
+// called yet are called first as part of r; otherwise they are skipped.
+func built(ctx *Context, r *resolution) ([]reflect.Value, error) {
+	entries := ownEntries(ctx)
+
+	type builtValue struct {
+		val   reflect.Value
+		order uint64
+		seq   uint64
+	}
+	vals := []builtValue{}
+	for _, e := range entries {
+		if e.prov == nil {
+			vals = append(vals, builtValue{e.val, e.seq, e.seq})
+			continue
+		}
+		if e.prov.lifetime == transient {
+			continue
+		}
+		if r != nil {
+			if _, err := e.value(ctx, r); err != nil {
+				return nil, err
+			}
+		}
+		val, order, ok := e.prov.cached()
+		if !ok {
+			continue
+		}
+		if e.field != nil {
+			val = val.FieldByIndex(e.field)
+		}
+		vals = append(vals, builtValue{val, order, e.seq})
+	}
+
+	sort.Slice(vals, func(i, j int) bool {
+		if vals[i].order != vals[j].order {
+			return vals[i].order < vals[j].order
+		}
+		return vals[i].seq < vals[j].seq
+	})
+	// the same value may be registered under more than one type
+	seen := map[interface{}]bool{}
+	out := make([]reflect.Value, 0, len(vals))
+	for _, v := range vals {
+		if v.val.Kind() == reflect.Interface && v.val.IsNil() {
+			continue
+		}
+		dep := v.val.Interface()
+		if reflect.TypeOf(dep).Comparable() {
+			if seen[dep] {
+				continue
+			}
+			seen[dep] = true
+		}
+		out = append(out, v.val)
+	}
+	return out, nil
+}
+
+// ownEntries returns the Context's own registrations in the order they were
+// made, so providers are built predictably.
+func ownEntries(ctx *Context) []*entry {
+	deps := ctx.load().deps
+	entries := make([]*entry, 0, len(deps))
+	for _, e := range deps {
+		// a dependency registered with AddAs is under more than one key
+		if !containsEntry(entries, e) {
+			entries = append(entries, e)
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].seq < entries[j].seq })
+	return entries
+}
+
+// buildParallel calls the singleton providers of the Context which haven't
+// been called yet from several goroutines, if it was created with
+// WithParallelStart. A provider needing a value another goroutine is building
+// waits for it, so providers which don't depend on each other are built
+// concurrently. Once one fails, no more are called, and the context given to
+// those still running is canceled.
+//
+// The providers are validated first, since goroutines building a cycle of
+// providers would wait for each other forever. If any can't be resolved,
+// nothing is built, leaving built to report the error.
+func buildParallel(ctx *Context, stdctx context.Context, p *progress) error {
+	workers := ctx.startWorkers
+	if workers < 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	if workers < 2 {
+		return nil
+	}
+
+	pending := []*entry{}
+	for _, e := range ownEntries(ctx) {
+		if e.prov == nil || e.prov.lifetime == transient {
+			continue
+		}
+		if _, _, ok := e.prov.cached(); ok {
+			continue
+		}
+		if _, err := e.value(ctx, &resolution{validate: true, passContext: true}); err != nil {
+			return nil
+		}
+		pending = append(pending, e)
+	}
+
+	// the cause is the first error, or stdctx's if it was canceled first
+	buildctx, cancel := context.WithCancelCause(stdctx)
+	defer cancel(nil)
+	var wg sync.WaitGroup
+	slots := make(chan struct{}, workers)
+	for _, e := range pending {
+		wg.Add(1)
+		go func(e *entry) {
+			defer wg.Done()
+			slots <- struct{}{}
+			defer func() { <-slots }()
+
+			if buildctx.Err() != nil {
+				return
+			}
+			r := &resolution{stdctx: buildctx, passContext: true, progress: p}
+			if _, err := e.value(ctx, r); err != nil {
+				cancel(err)
+			}
+		}(e)
+	}
+	wg.Wait()
+
+	if buildctx.Err() != nil {
+		return context.Cause(buildctx)
+	}
+	return nil
+}
@@ -0,0 +1,306 @@
+package di_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"reflect"
+	"runtime"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/mcvoid/di"
+)
+
+// records the order components are started and stopped in
+type recorder struct {
+	events []string
+}
+
+type component struct {
+	name string
+	rec  *recorder
+	err  error
+}
+
+func (c *component) Start(ctx context.Context) error {
+	c.rec.events = append(c.rec.events, "start "+c.name)
+	return c.err
+}
+
+func (c *component) Stop(ctx context.Context) error {
+	c.rec.events = append(c.rec.events, "stop "+c.name)
+	return c.err
+}
+
+type database struct{ *component }
+type cache struct{ *component }
+type frontend struct{ *component }
+
+func (c *component) Close() error {
+	c.rec.events = append(c.rec.events, "close "+c.name)
+	return c.err
+}
+
+func TestLifecycle(t *testing.T) {
+	t.Run("starts in dependency order and stops in reverse", func(t *testing.T) {
+		rec := &recorder{}
+		ctx := di.New().Add(rec)
+		ctx.Provide(func(db database, c cache) frontend { return frontend{&component{"frontend", rec, nil}} })
+		ctx.Provide(func(db database) cache { return cache{&component{"cache", rec, nil}} })
+		ctx.Provide(func() database { return database{&component{"database", rec, nil}} })
+		ctx.OnStart(func(context.Context) error {
+			rec.events = append(rec.events, "start hook")
+			return nil
+		})
+		ctx.OnStop(func(context.Context) error {
+			rec.events = append(rec.events, "stop hook")
+			return nil
+		})
+
+		err := ctx.Start(context.Background())
+		if err != nil {
+			t.Errorf("expected %v got %v", nil, err)
+		}
+		err = ctx.Stop(context.Background())
+		if err != nil {
+			t.Errorf("expected %v got %v", nil, err)
+		}
+
+		expected := []string{
+			"start database", "start cache", "start frontend", "start hook",
+			"stop hook", "stop frontend", "stop cache", "stop database",
+		}
+		if !reflect.DeepEqual(rec.events, expected) {
+			t.Errorf("expected %v got %v", expected, rec.events)
+		}
+	})
+
+	t.Run("start stops at the first error", func(t *testing.T) {
+		errBoom := errors.New("boom")
+		rec := &recorder{}
+		ctx := di.New()
+		ctx.Provide(func(db database) cache { return cache{&component{"cache", rec, nil}} })
+		ctx.Provide(func() database { return database{&component{"database", rec, errBoom}} })
+
+		err := ctx.Start(context.Background())
+		if !errors.Is(err, errBoom) {
+			t.Errorf("expected %v got %v", errBoom, err)
+		}
+		expected := []string{"start database"}
+		if !reflect.DeepEqual(rec.events, expected) {
+			t.Errorf("expected %v got %v", expected, rec.events)
+		}
+	})
+
+	t.Run("stop continues after errors", func(t *testing.T) {
+		errBoom := errors.New("boom")
+		rec := &recorder{}
+		ctx := di.New().Add(
+			database{&component{"database", rec, errBoom}},
+			cache{&component{"cache", rec, errBoom}},
+		)
+
+		err := ctx.Stop(context.Background())
+		if !errors.Is(err, errBoom) {
+			t.Errorf("expected %v got %v", errBoom, err)
+		}
+		expected := []string{"stop cache", "stop database"}
+		if !reflect.DeepEqual(rec.events, expected) {
+			t.Errorf("expected %v got %v", expected, rec.events)
+		}
+	})
+
+	t.Run("start stops when canceled", func(t *testing.T) {
+		stdctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		rec := &recorder{}
+		ctx := di.New()
+		ctx.Provide(func() *canceler { return &canceler{cancel} })
+		ctx.Provide(func(*canceler) cache {
+			t.Errorf("expected provider to not be called")
+			return cache{&component{"cache", rec, nil}}
+		})
+
+		err := ctx.Start(stdctx)
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("expected %v got %v", context.Canceled, err)
+		}
+		var canceled *di.CanceledError
+		if !errors.As(err, &canceled) {
+			t.Fatalf("expected %T got %v", canceled, err)
+		}
+		expected := []reflect.Type{reflect.TypeOf(&canceler{})}
+		if !reflect.DeepEqual(canceled.Built, expected) {
+			t.Errorf("expected %v got %v", expected, canceled.Built)
+		}
+		if len(rec.events) != 0 {
+			t.Errorf("expected nothing started got %v", rec.events)
+		}
+	})
+
+	t.Run("stop doesn't build providers", func(t *testing.T) {
+		ctx := di.New()
+		ctx.Provide(func() database {
+			t.Errorf("expected provider to not be called")
+			return database{}
+		})
+
+		err := ctx.Stop(context.Background())
+		if err != nil {
+			t.Errorf("expected %v got %v", nil, err)
+		}
+	})
+}
+
+func TestWithParallelStart(t *testing.T) {
+	t.Run("independent providers are built concurrently", func(t *testing.T) {
+		arrived := make(chan struct{}, 2)
+		wait := func() error {
+			arrived <- struct{}{}
+			deadline := time.After(5 * time.Second)
+			for len(arrived) < 2 {
+				select {
+				case <-deadline:
+					return errors.New("providers weren't built concurrently")
+				default:
+					runtime.Gosched()
+				}
+			}
+			return nil
+		}
+		ctx := di.New(di.WithParallelStart(2))
+		ctx.Provide(func() (*bytes.Buffer, error) { return &bytes.Buffer{}, wait() })
+		ctx.Provide(func() (*strings.Builder, error) { return &strings.Builder{}, wait() })
+
+		err := ctx.Start(context.Background())
+		if err != nil {
+			t.Errorf("expected %v got %v", nil, err)
+		}
+	})
+
+	t.Run("starts in dependency order", func(t *testing.T) {
+		rec := &recorder{}
+		var built atomic.Int32
+		ctx := di.New(di.WithParallelStart(0))
+		ctx.Provide(func(db database, c cache) frontend {
+			built.Add(1)
+			return frontend{&component{"frontend", rec, nil}}
+		})
+		ctx.Provide(func(db database) cache {
+			built.Add(1)
+			return cache{&component{"cache", rec, nil}}
+		})
+		ctx.Provide(func() database {
+			built.Add(1)
+			return database{&component{"database", rec, nil}}
+		})
+
+		err := ctx.Start(context.Background())
+		if err != nil {
+			t.Errorf("expected %v got %v", nil, err)
+		}
+		if built.Load() != 3 {
+			t.Errorf("expected %v got %v", 3, built.Load())
+		}
+		expected := []string{"start database", "start cache", "start frontend"}
+		if !reflect.DeepEqual(rec.events, expected) {
+			t.Errorf("expected %v got %v", expected, rec.events)
+		}
+	})
+
+	t.Run("cycles are reported", func(t *testing.T) {
+		ctx := di.New(di.WithParallelStart(4))
+		ctx.Provide(func(*strings.Builder) *bytes.Buffer { return &bytes.Buffer{} })
+		ctx.Provide(func(*bytes.Buffer) *strings.Builder { return &strings.Builder{} })
+
+		err := ctx.Start(context.Background())
+		var cycle *di.CycleError
+		if !errors.As(err, &cycle) {
+			t.Errorf("expected %T got %v", cycle, err)
+		}
+	})
+
+	t.Run("provider errors are returned", func(t *testing.T) {
+		errBoom := errors.New("boom")
+		ctx := di.New(di.WithParallelStart(4))
+		ctx.Provide(func() *bytes.Buffer { return &bytes.Buffer{} })
+		ctx.Provide(func(*bytes.Buffer) (*strings.Builder, error) { return nil, errBoom })
+		ctx.Provide(func() *strings.Reader { return strings.NewReader("") })
+
+		err := ctx.Start(context.Background())
+		if !errors.Is(err, errBoom) {
+			t.Errorf("expected %v got %v", errBoom, err)
+		}
+	})
+
+	t.Run("a failure cancels the providers still running", func(t *testing.T) {
+		errBoom := errors.New("boom")
+		started := make(chan struct{})
+		stopped := make(chan error, 1)
+		ctx := di.New(di.WithParallelStart(2))
+		ctx.Provide(func(stdctx context.Context) *bytes.Buffer {
+			close(started)
+			<-stdctx.Done()
+			stopped <- stdctx.Err()
+			return &bytes.Buffer{}
+		})
+		ctx.Provide(func() (*strings.Builder, error) {
+			<-started
+			return nil, errBoom
+		})
+
+		err := ctx.Start(context.Background())
+		if !errors.Is(err, errBoom) {
+			t.Errorf("expected %v got %v", errBoom, err)
+		}
+		if err := <-stopped; err != context.Canceled {
+			t.Errorf("expected %v got %v", context.Canceled, err)
+		}
+	})
+}
+
+func TestClose(t *testing.T) {
+	t.Run("closes in reverse order", func(t *testing.T) {
+		rec := &recorder{}
+		ctx := di.New().Add(database{&component{"database", rec, nil}})
+		ctx.Provide(func(db database) cache { return cache{&component{"cache", rec, nil}} })
+		di.Resolve[cache](ctx)
+
+		err := ctx.Close()
+		if err != nil {
+			t.Errorf("expected %v got %v", nil, err)
+		}
+		expected := []string{"close cache", "close database"}
+		if !reflect.DeepEqual(rec.events, expected) {
+			t.Errorf("expected %v got %v", expected, rec.events)
+		}
+	})
+
+	t.Run("aggregates errors", func(t *testing.T) {
+		errA, errB := errors.New("a"), errors.New("b")
+		rec := &recorder{}
+		ctx := di.New().Add(database{&component{"database", rec, errA}}, cache{&component{"cache", rec, errB}})
+
+		err := ctx.Close()
+		if !errors.Is(err, errA) || !errors.Is(err, errB) {
+			t.Errorf("expected both errors got %v", err)
+		}
+		if len(rec.events) != 2 {
+			t.Errorf("expected %v got %v", 2, len(rec.events))
+		}
+	})
+
+	t.Run("unbuilt providers aren't closed", func(t *testing.T) {
+		rec := &recorder{}
+		ctx := di.New()
+		ctx.Provide(func() cache { return cache{&component{"cache", rec, nil}} })
+
+		ctx.Close()
+		if len(rec.events) != 0 {
+			t.Errorf("expected %v got %v", 0, len(rec.events))
+		}
+	})
+}
@@ -0,0 +1,208 @@
+package di
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+)
+
+// Module is a named, reusable set of registrations, so that each part of an application, such as a team's package, can
+// ship its own wiring for applications to combine with Use:
+//
+//	var Storage = di.Module{
+//		Name: "storage",
+//		Register: func(ctx *di.Context) error {
+//			ctx.Add(defaultConfig)
+//			return ctx.Provide(openDB)
+//		},
+//	}
+type Module struct {
+	// The name of the module, which identifies it in errors and in Registrations
+	Name string
+	// If set, the module is only used in a Context where one of these profiles is active; see WithProfiles
+	Profiles []string
+	// Makes the module's registrations. It is given a Context of its own, with the same options as the one the module is
+	// used in, and may use anything which registers dependencies: Add, Provide, SetDefault, Prefer, OnStart, OnStop, and
+	// Use to include other modules. The module's Context falls back to the one it's used in, and to the modules used
+	// before it, so that conditions given with If see what they have already registered.
+	Register func(ctx *Context) error
+}
+
+// Use registers the dependencies of each module in the Context, checking that no two of them register a dependency under
+// the same type and name. If any do, or a module registers one the Context already has, an error wrapping ErrConflict is
+// returned for each conflict, naming the modules involved, and nothing is registered. An error from a module's Register
+// function, or reported by Err of the Context it was given, is returned the same way. Registrations made with WhenFlag
+// don't conflict, but are kept alongside the others as they would be in a single Context.
+//
+// Each named module is only used once: a module whose name was already used in the Context, or is listed twice, is
+// skipped. So a module which several others include with Use, such as a shared logging module, doesn't conflict with
+// itself. Modules without a name are always used. Registrations made by a module are marked with its name in
+// Registrations.
+func (ctx *Context) Use(modules ...Module) error {
+	ctx.lock.RLock()
+	opts := ctx.options
+	used := map[string]bool{}
+	for _, name := range ctx.modules {
+		used[name] = true
+	}
+	ctx.lock.RUnlock()
+
+	// each module registers into a Context of its own, so they can be
+	// checked against each other before anything is registered
+	built := []*Context{}
+	errs := []error{}
+	// each module's Context falls back to the one before it, and the first
+	// to ctx, so conditions see what's registered
+	parent := ctx
+	for _, m := range modules {
+		if m.Name != "" && used[m.Name] || !opts.active(m.Profiles) {
+			continue
+		}
+		used[m.Name] = true
+
+		scratch := &Context{parent: parent, options: opts}
+		parent = scratch
+		scratch.module = m.Name
+		// registrations are logged once they are made in ctx
+		scratch.logger = nil
+		if m.Register != nil {
+			if err := m.Register(scratch); err != nil {
+				errs = append(errs, fmt.Errorf("module %q: %w", m.Name, err))
+			}
+		}
+		if err := scratch.Err(); err != nil {
+			errs = append(errs, fmt.Errorf("module %q: %w", m.Name, err))
+		}
+		built = append(built, scratch)
+	}
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+
+	ctx.lock.Lock()
+	defer ctx.commit()
+
+	if ctx.frozen {
+		return fmt.Errorf("%w: cannot use modules", ErrFrozen)
+	}
+
+	deps := map[key]*entry{}
+	for _, scratch := range built {
+		for _, k := range sortedKeys(scratch.deps) {
+			e := scratch.deps[k]
+			if existing, ok := deps[k]; ok {
+				switch {
+				case sameModule(existing, e):
+				case flagged(existing, e):
+					deps[k] = pair(existing, e)
+				default:
+					errs = append(errs, conflict(k, existing, e))
+				}
+				continue
+			}
+			if existing, ok := ctx.deps[k]; ok && sameModule(existing, e) {
+				continue
+			}
+			// registering pairs those behind a feature flag with ctx's own
+			if existing, ok := ctx.deps[k]; ok && !flagged(existing, e) {
+				errs = append(errs, conflict(k, existing, e))
+				continue
+			}
+			deps[k] = e
+		}
+	}
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+
+	// check everything before changing anything, so the modules are used in
+	// full or not at all
+	defaults := ctx.defaults
+	for _, scratch := range built {
+		if scratch.defaults == nil {
+			continue
+		}
+		if defaults == ctx.defaults {
+			defaults = &Context{}
+			if ctx.defaults != nil {
+				defaults = ctx.defaults.Clone()
+			}
+		}
+		if err := defaults.Merge(scratch.defaults); err != nil {
+			return fmt.Errorf("module %q defaults: %w", scratch.module, err)
+		}
+	}
+	keys := sortedKeys(deps)
+	for _, k := range keys {
+		if err := admit(ctx, k, deps[k]); err != nil {
+			return err
+		}
+	}
+
+	for _, k := range keys {
+		register(ctx, k, deps[k])
+	}
+	ctx.defaults = defaults
+	for _, scratch := range built {
+		ctx.adopt(scratch)
+	}
+	return nil
+}
+
+// adopt takes everything but the dependencies and defaults from a Context a
+// module was registered in: its hooks, preferences, and the modules it used.
+// The caller must hold ctx.lock.
+func (ctx *Context) adopt(scratch *Context) {
+	ctx.onStart = append(ctx.onStart, scratch.onStart...)
+	ctx.onStop = append(ctx.onStop, scratch.onStop...)
+	ctx.onReload = append(ctx.onReload, scratch.onReload...)
+	for iface, t := range scratch.prefs {
+		if ctx.prefs == nil {
+			ctx.prefs = map[reflect.Type]reflect.Type{}
+		}
+		ctx.prefs[iface] = t
+	}
+	if scratch.module != "" {
+		ctx.modules = append(ctx.modules, scratch.module)
+	}
+	ctx.modules = append(ctx.modules, scratch.modules...)
+	ctx.changed()
+}
+
+// sameModule reports whether two registrations are the same one, made by a
+// module which more than one other includes.
+func sameModule(existing, e *entry) bool {
+	return existing == e || existing.module != "" && existing.module == e.module
+}
+
+// flagged reports whether either of two registrations is behind a feature
+// flag, so they are kept alongside each other rather than conflicting.
+func flagged(existing, e *entry) bool {
+	return existing.flag != "" || e.flag != ""
+}
+
+// conflict is the error for two modules, or a module and the Context, making
+// the same registration.
+func conflict(k key, existing, e *entry) error {
+	return fmt.Errorf("%w: %s is registered by %s and %s", ErrConflict, nodeID(k.typ, k.name), owner(existing), owner(e))
+}
+
+// owner describes what made a registration, for conflict errors.
+func owner(e *entry) string {
+	if e.module == "" {
+		return "the context"
+	}
+	return fmt.Sprintf("module %q", e.module)
+}
+
+// sortedKeys returns the keys of deps in a stable order, so errors and
+// registrations don't depend on map iteration.
+func sortedKeys(deps map[key]*entry) []key {
+	keys := make([]key, 0, len(deps))
+	for k := range deps {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool { return nodeID(keys[i].typ, keys[i].name) < nodeID(keys[j].typ, keys[j].name) })
+	return keys
+}
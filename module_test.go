@@ -0,0 +1,228 @@
+package di_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/mcvoid/di"
+)
+
+func TestUse(t *testing.T) {
+	t.Run("registers each module's dependencies", func(t *testing.T) {
+		ctx := di.New()
+		err := ctx.Use(
+			di.Module{Name: "files", Register: func(ctx *di.Context) error {
+				ctx.Add(os.Stdout)
+				return nil
+			}},
+			di.Module{Name: "buffers", Register: func(ctx *di.Context) error {
+				return ctx.Provide(func(f *os.File) *bytes.Buffer { return &bytes.Buffer{} })
+			}},
+		)
+		if err != nil {
+			t.Errorf("expected %v got %v", nil, err)
+		}
+		if _, err := di.Resolve[*bytes.Buffer](ctx); err != nil {
+			t.Errorf("expected %v got %v", nil, err)
+		}
+		if !di.Has[*os.File](ctx) {
+			t.Errorf("expected %v to be registered", "*os.File")
+		}
+	})
+
+	t.Run("conflicts between modules are reported", func(t *testing.T) {
+		register := func(ctx *di.Context) error {
+			ctx.Add(os.Stdout, &strings.Builder{})
+			return nil
+		}
+		ctx := di.New()
+		err := ctx.Use(di.Module{Name: "a", Register: register}, di.Module{Name: "b", Register: register})
+		if !errors.Is(err, di.ErrConflict) {
+			t.Errorf("expected %v got %v", di.ErrConflict, err)
+		}
+		expected := `*os.File is registered by module "a" and module "b"`
+		if err == nil || !strings.Contains(err.Error(), expected) {
+			t.Errorf("expected %v got %v", expected, err)
+		}
+		if !strings.Contains(err.Error(), "*strings.Builder") {
+			t.Errorf("expected every conflict to be reported got %v", err)
+		}
+		if len(ctx.Registrations()) != 0 {
+			t.Errorf("expected nothing registered got %v", ctx.Registrations())
+		}
+	})
+
+	t.Run("conflicts with the context are reported", func(t *testing.T) {
+		ctx := di.New().Add(os.Stdout)
+		err := ctx.Use(di.Module{Name: "a", Register: func(ctx *di.Context) error {
+			ctx.Add(os.Stdin)
+			return nil
+		}})
+		expected := `*os.File is registered by the context and module "a"`
+		if err == nil || !strings.Contains(err.Error(), expected) {
+			t.Errorf("expected %v got %v", expected, err)
+		}
+		if f := di.MustResolve[*os.File](ctx); f != os.Stdout {
+			t.Errorf("expected %v got %v", os.Stdout, f)
+		}
+	})
+
+	t.Run("nothing is used if defaults conflict", func(t *testing.T) {
+		ctx := di.New()
+		err := ctx.Use(
+			di.Module{Name: "a", Register: func(ctx *di.Context) error {
+				ctx.Add(os.Stdout)
+				ctx.SetDefault(&bytes.Buffer{})
+				return nil
+			}},
+			di.Module{Name: "b", Register: func(ctx *di.Context) error {
+				ctx.SetDefault(&bytes.Buffer{})
+				return nil
+			}},
+		)
+		if !errors.Is(err, di.ErrConflict) {
+			t.Errorf("expected %v got %v", di.ErrConflict, err)
+		}
+		if di.Has[*os.File](ctx) || len(ctx.Registrations()) != 0 {
+			t.Errorf("expected nothing registered got %v", ctx.Registrations())
+		}
+	})
+
+	t.Run("flagged registrations are kept alongside the context's", func(t *testing.T) {
+		flags := di.StaticFlags{}
+		ctx := di.New().Add(flags)
+		ctx.Provide(func() io.Writer { return os.Stdout })
+		err := ctx.Use(di.Module{Name: "a", Register: func(ctx *di.Context) error {
+			return ctx.Provide(func() io.Writer { return os.Stderr }, di.WhenFlag("new-writer"))
+		}})
+		if err != nil {
+			t.Errorf("expected %v got %v", nil, err)
+		}
+		if w := di.MustResolve[io.Writer](ctx); w != os.Stdout {
+			t.Errorf("expected %v got %v", os.Stdout, w)
+		}
+		flags["new-writer"] = true
+		if w := di.MustResolve[io.Writer](ctx); w != os.Stderr {
+			t.Errorf("expected %v got %v", os.Stderr, w)
+		}
+	})
+
+	t.Run("modules are only used once", func(t *testing.T) {
+		calls := 0
+		m := di.Module{Name: "files", Register: func(ctx *di.Context) error {
+			calls++
+			ctx.Add(os.Stdout)
+			return nil
+		}}
+		ctx := di.New()
+		if err := ctx.Use(m, m); err != nil {
+			t.Errorf("expected %v got %v", nil, err)
+		}
+		if err := ctx.Use(m); err != nil {
+			t.Errorf("expected %v got %v", nil, err)
+		}
+		if calls != 1 {
+			t.Errorf("expected %v got %v", 1, calls)
+		}
+	})
+
+	t.Run("modules without a name are all used", func(t *testing.T) {
+		ctx := di.New()
+		err := ctx.Use(
+			di.Module{Register: func(ctx *di.Context) error {
+				ctx.Add(os.Stdout)
+				return nil
+			}},
+			di.Module{Register: func(ctx *di.Context) error {
+				ctx.Add(&strings.Builder{})
+				return nil
+			}},
+		)
+		if err != nil {
+			t.Errorf("expected %v got %v", nil, err)
+		}
+		if !di.Has[*os.File](ctx) || !di.Has[*strings.Builder](ctx) {
+			t.Errorf("expected both modules' dependencies got %v", ctx.Registrations())
+		}
+	})
+
+	t.Run("modules can share a module", func(t *testing.T) {
+		shared := di.Module{Name: "shared", Register: func(ctx *di.Context) error {
+			ctx.Add(os.Stdout)
+			return nil
+		}}
+		ctx := di.New()
+		err := ctx.Use(
+			di.Module{Name: "a", Register: func(ctx *di.Context) error { return ctx.Use(shared) }},
+			di.Module{Name: "b", Register: func(ctx *di.Context) error { return ctx.Use(shared) }},
+		)
+		if err != nil {
+			t.Errorf("expected %v got %v", nil, err)
+		}
+		if err := ctx.Use(shared); err != nil {
+			t.Errorf("expected %v got %v", nil, err)
+		}
+		regs := ctx.Registrations()
+		if len(regs) != 1 || regs[0].Module != "shared" {
+			t.Errorf("expected one registration from %v got %v", "shared", regs)
+		}
+	})
+
+	t.Run("errors from modules are returned", func(t *testing.T) {
+		errBoom := errors.New("boom")
+		ctx := di.New(di.WithNilPolicy(di.NilError))
+		err := ctx.Use(
+			di.Module{Name: "a", Register: func(ctx *di.Context) error {
+				ctx.Add(os.Stdout)
+				return errBoom
+			}},
+			di.Module{Name: "b", Register: func(ctx *di.Context) error {
+				ctx.Add(nil)
+				return nil
+			}},
+		)
+		if !errors.Is(err, errBoom) || !errors.Is(err, di.ErrNilDependency) {
+			t.Errorf("expected %v and %v got %v", errBoom, di.ErrNilDependency, err)
+		}
+		if !strings.Contains(err.Error(), `module "a"`) {
+			t.Errorf("expected module to be named got %v", err)
+		}
+		if di.Has[*os.File](ctx) {
+			t.Errorf("expected nothing registered")
+		}
+	})
+
+	t.Run("hooks, preferences and defaults are kept", func(t *testing.T) {
+		started := false
+		ctx := di.New()
+		err := ctx.Use(di.Module{Name: "a", Register: func(ctx *di.Context) error {
+			ctx.Add(os.Stdout, &bytes.Buffer{})
+			ctx.SetDefault(strings.NewReader(""))
+			ctx.OnStart(func(context.Context) error {
+				started = true
+				return nil
+			})
+			return di.Prefer[io.Writer](ctx, os.Stdout)
+		}})
+		if err != nil {
+			t.Errorf("expected %v got %v", nil, err)
+		}
+		if w := di.MustResolve[io.Writer](ctx); w != os.Stdout {
+			t.Errorf("expected %v got %v", os.Stdout, w)
+		}
+		if r := di.MustResolve[*strings.Reader](ctx); r == nil {
+			t.Errorf("expected default got %v", r)
+		}
+		if err := ctx.Start(context.Background()); err != nil {
+			t.Errorf("expected %v got %v", nil, err)
+		}
+		if !started {
+			t.Errorf("expected %v got %v", true, started)
+		}
+	})
+}
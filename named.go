@@ -0,0 +1,127 @@
+package di
+
+import (
+	"errors"
+	"reflect"
+	"sort"
+)
+
+// Qualifier gives a dependency name at the type level, so it can be used as a type argument to Named.
+// It is typically implemented by an empty struct:
+//
+//	type replica struct{}
+//
+//	func (replica) Qualifier() string { return "replica" }
+type Qualifier interface {
+	Qualifier() string
+}
+
+// Named is a parameter type which requests the dependency of type T registered under the name given by Q, rather than
+// the unnamed dependency of that type. The resolved dependency is stored in Value.
+//
+//	ctx.AddNamed("replica", replicaDB)
+//	ctx.Inject(func(db di.Named[*sql.DB, replica]) {
+//		db.Value.Query(...)
+//	})
+type Named[T any, Q Qualifier] struct {
+	Value T
+}
+
+func (Named[T, Q]) qualifier() (reflect.Type, string) {
+	var q Q
+	return typeOf[T](), q.Qualifier()
+}
+
+// qualified is implemented by every instantiation of Named.
+type qualified interface {
+	qualifier() (reflect.Type, string)
+}
+
+var qualifiedType = reflect.TypeOf((*qualified)(nil)).Elem()
+
+// AddNamed registers dependencies under a name. Named dependencies are indexed by type and name, so several
+// dependencies of the same type can coexist as long as their names differ. They are only injected into parameters
+// which ask for that name using Named, never into ordinary parameters.
+//
+// Otherwise, AddNamed behaves like Add.
+func (ctx *Context) AddNamed(name string, deps ...interface{}) *Context {
+	ctx.lock.Lock()
+	defer ctx.commit()
+
+	for i, dep := range deps {
+		if dep == nil {
+			nilDependency(ctx, i)
+			continue
+		}
+
+		v := reflect.ValueOf(dep)
+		if err := register(ctx, key{v.Type(), name}, &entry{name: name, val: v}); err != nil {
+			ctx.err = errors.Join(ctx.err, err)
+		}
+	}
+
+	return ctx
+}
+
+// resolveQualified resolves a Named parameter and wraps the value in it.
+func resolveQualified(ctx *Context, r *resolution, argType reflect.Type) (reflect.Value, error) {
+	t, name := reflect.Zero(argType).Interface().(qualified).qualifier()
+	val, err := resolveNamed(ctx, r, t, name)
+	if err != nil || !val.IsValid() {
+		return val, err
+	}
+
+	wrapped := reflect.New(argType).Elem()
+	wrapped.Field(0).Set(val)
+	return wrapped, nil
+}
+
+// resolveMap collects every named dependency assignable to the element type
+// of mapType into a map keyed by name, returning an invalid Value if there are
+// none. Dependencies in ancestor contexts are included unless shadowed.
+func resolveMap(ctx *Context, r *resolution, mapType reflect.Type) (reflect.Value, error) {
+	elemType := mapType.Elem()
+	found := map[string]reflect.Type{}
+	vals := map[string]reflect.Value{}
+	for c := ctx; c != nil; c = c.parent {
+		level := map[string]reflect.Type{}
+		entries := map[string]*entry{}
+		for k, e := range c.load().deps {
+			if k.name == "" || !k.typ.AssignableTo(elemType) {
+				continue
+			}
+			// names already found in a descendant shadow this one
+			if _, ok := found[k.name]; ok {
+				continue
+			}
+			// a dependency registered with AddAs is under more than one key
+			if entries[k.name] == e {
+				continue
+			}
+			if other, ok := level[k.name]; ok {
+				candidateTypes := []reflect.Type{other, k.typ}
+				sort.Slice(candidateTypes, func(i, j int) bool { return candidateTypes[i].String() < candidateTypes[j].String() })
+				return reflect.Value{}, &AmbiguousError{Type: elemType, Name: k.name, Candidates: candidateTypes, Index: -1}
+			}
+			val, err := e.value(c, r)
+			if err != nil {
+				return reflect.Value{}, err
+			}
+			level[k.name] = k.typ
+			entries[k.name] = e
+			vals[k.name] = val
+		}
+		for name, t := range level {
+			found[name] = t
+		}
+	}
+
+	if len(vals) == 0 {
+		return reflect.Value{}, nil
+	}
+	m := reflect.MakeMapWithSize(mapType, len(vals))
+	for name, val := range vals {
+		m.SetMapIndex(reflect.ValueOf(name).Convert(mapType.Key()), val)
+	}
+	return m, nil
+}
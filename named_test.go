@@ -0,0 +1,151 @@
+package di_test
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/mcvoid/di"
+)
+
+type primary struct{}
+
+func (primary) Qualifier() string { return "primary" }
+
+type replica struct{}
+
+func (replica) Qualifier() string { return "replica" }
+
+func TestNamed(t *testing.T) {
+	t.Run("same type with different names", func(t *testing.T) {
+		p, r := &bytes.Buffer{}, &bytes.Buffer{}
+		ctx := di.New().AddNamed("primary", p).AddNamed("replica", r)
+
+		wasCalled := false
+		err := ctx.Inject(func(a di.Named[*bytes.Buffer, primary], b di.Named[*bytes.Buffer, replica]) {
+			wasCalled = true
+			if a.Value != p {
+				t.Errorf("expected %p got %p", p, a.Value)
+			}
+			if b.Value != r {
+				t.Errorf("expected %p got %p", r, b.Value)
+			}
+		})
+		if !wasCalled {
+			t.Errorf("expected func to be called")
+		}
+		if err != nil {
+			t.Errorf("expected %v got %v", nil, err)
+		}
+	})
+
+	t.Run("named interface match", func(t *testing.T) {
+		r := &bytes.Buffer{}
+		ctx := di.New().AddNamed("replica", r)
+
+		err := ctx.Inject(func(w di.Named[io.Writer, replica]) {
+			if w.Value != r {
+				t.Errorf("expected %p got %p", r, w.Value)
+			}
+		})
+		if err != nil {
+			t.Errorf("expected %v got %v", nil, err)
+		}
+	})
+
+	t.Run("named dependencies don't match unnamed parameters", func(t *testing.T) {
+		ctx := di.New().AddNamed("replica", &bytes.Buffer{})
+
+		err := ctx.Inject(func(w io.Writer) {
+			if w != nil {
+				t.Errorf("expected %v got %v", nil, w)
+			}
+		})
+		if err != nil {
+			t.Errorf("expected %v got %v", nil, err)
+		}
+	})
+
+	t.Run("unnamed dependencies don't match named parameters", func(t *testing.T) {
+		ctx := di.New().Add(&bytes.Buffer{})
+
+		err := ctx.Inject(func(w di.Named[io.Writer, replica]) {
+			if w.Value != nil {
+				t.Errorf("expected %v got %v", nil, w.Value)
+			}
+		})
+		if err != nil {
+			t.Errorf("expected %v got %v", nil, err)
+		}
+	})
+
+	t.Run("named provider", func(t *testing.T) {
+		r := &bytes.Buffer{}
+		ctx := di.New()
+		ctx.Provide(func() *bytes.Buffer { return r }, di.Name("replica"))
+
+		err := ctx.Inject(func(b di.Named[*bytes.Buffer, replica]) {
+			if b.Value != r {
+				t.Errorf("expected %p got %p", r, b.Value)
+			}
+		})
+		if err != nil {
+			t.Errorf("expected %v got %v", nil, err)
+		}
+	})
+}
+
+func TestNamedMap(t *testing.T) {
+	t.Run("map gets every named dependency", func(t *testing.T) {
+		p, r := &bytes.Buffer{}, &bytes.Buffer{}
+		ctx := di.New().Add(&bytes.Buffer{}).AddNamed("primary", p).AddNamed("replica", r)
+
+		wasCalled := false
+		err := ctx.Inject(func(ws map[string]io.Writer) {
+			wasCalled = true
+			if len(ws) != 2 || ws["primary"] != p || ws["replica"] != r {
+				t.Errorf("expected %v got %v", map[string]io.Writer{"primary": p, "replica": r}, ws)
+			}
+		})
+		if !wasCalled {
+			t.Errorf("expected func to be called")
+		}
+		if err != nil {
+			t.Errorf("expected %v got %v", nil, err)
+		}
+	})
+
+	t.Run("only assignable dependencies", func(t *testing.T) {
+		ctx := di.New().AddNamed("a", &bytes.Buffer{}).AddNamed("b", "not a writer")
+
+		ctx.Inject(func(ws map[string]io.Writer) {
+			if len(ws) != 1 {
+				t.Errorf("expected %v got %v", 1, len(ws))
+			}
+		})
+	})
+
+	t.Run("same name with different types", func(t *testing.T) {
+		ctx := di.New().AddNamed("a", &bytes.Buffer{}, &strings.Builder{})
+
+		err := ctx.Inject(func(ws map[string]io.Writer) {
+			t.Errorf("expected func to not be called")
+		})
+		if !errors.Is(err, di.ErrAmbiguous) {
+			t.Errorf("expected %v got %v", di.ErrAmbiguous, err)
+		}
+	})
+
+	t.Run("child shadows parent names", func(t *testing.T) {
+		p, c := &bytes.Buffer{}, &strings.Builder{}
+		ctx := di.New().AddNamed("a", p).AddNamed("b", p).Child().AddNamed("a", c)
+
+		ctx.Inject(func(ws map[string]io.Writer) {
+			if len(ws) != 2 || ws["a"] != c || ws["b"] != p {
+				t.Errorf("expected %v got %v", map[string]io.Writer{"a": c, "b": p}, ws)
+			}
+		})
+	})
+}
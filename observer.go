@@ -0,0 +1,54 @@
+package di
+
+import "reflect"
+
+// Observer watches a Context inject dependencies, so that cross-cutting concerns such as logging and metrics can be
+// added without changing the code which calls Inject. Observers are registered with WithObserver.
+//
+// Injections don't lock the Context, so observer methods may be called from several goroutines at once, and must be safe
+// for concurrent use.
+type Observer interface {
+	// BeforeResolve is called before a parameter of an injected function or provider, or a type passed to Resolve,
+	// is resolved.
+	BeforeResolve(t reflect.Type)
+	// AfterResolve is called after resolving, with the value which will be injected, or the error if there isn't one.
+	AfterResolve(t reflect.Type, val interface{}, err error)
+	// BeforeCall is called before calling an injected target or provider. For an object, target is the object
+	// rather than its bind method.
+	BeforeCall(target interface{})
+	// AfterCall is called after calling an injected target or provider, with the error it returned or the panic
+	// recovered from it, if any.
+	AfterCall(target interface{}, err error)
+}
+
+// WithObserver adds an Observer to be told about every injection in the Context. It can be given more than once to
+// add several observers, which are called in the order they were added. Validate and Explain are not observed.
+func WithObserver(o Observer) Option {
+	return func(ctx *Context) {
+		ctx.observers = append(ctx.observers, o)
+	}
+}
+
+// beforeResolve tells the observers that t is about to be resolved.
+func beforeResolve(ctx *Context, r *resolution, t reflect.Type) {
+	if r.validate {
+		return
+	}
+	for _, o := range ctx.observers {
+		o.BeforeResolve(t)
+	}
+}
+
+// afterResolve tells the observers what t resolved to.
+func afterResolve(ctx *Context, r *resolution, t reflect.Type, val reflect.Value, err error) {
+	if r.validate || len(ctx.observers) == 0 {
+		return
+	}
+	var v interface{}
+	if err == nil && val.IsValid() && val.CanInterface() {
+		v = val.Interface()
+	}
+	for _, o := range ctx.observers {
+		o.AfterResolve(t, v, err)
+	}
+}
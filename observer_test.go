@@ -0,0 +1,108 @@
+package di_test
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/mcvoid/di"
+)
+
+// an observer which records what it sees
+type recordingObserver struct {
+	events []string
+}
+
+func (o *recordingObserver) BeforeResolve(t reflect.Type) {
+	o.events = append(o.events, fmt.Sprintf("before resolve %v", t))
+}
+
+func (o *recordingObserver) AfterResolve(t reflect.Type, val interface{}, err error) {
+	o.events = append(o.events, fmt.Sprintf("after resolve %v %T %v", t, val, err))
+}
+
+func (o *recordingObserver) BeforeCall(target interface{}) {
+	o.events = append(o.events, fmt.Sprintf("before call %T", target))
+}
+
+func (o *recordingObserver) AfterCall(target interface{}, err error) {
+	o.events = append(o.events, fmt.Sprintf("after call %T %v", target, err))
+}
+
+func TestWithObserver(t *testing.T) {
+	t.Run("injection", func(t *testing.T) {
+		o := &recordingObserver{}
+		ctx := di.New(di.WithObserver(o)).Add(os.Stdout)
+		ctx.Provide(func(f *os.File) *bytes.Buffer { return &bytes.Buffer{} })
+
+		err := ctx.Inject(func(b *bytes.Buffer, r io.Reader) {
+			t.Errorf("expected func to not be called")
+		})
+		if !errors.Is(err, di.ErrAmbiguous) {
+			t.Errorf("expected %v got %v", di.ErrAmbiguous, err)
+		}
+
+		expected := []string{
+			"before resolve *bytes.Buffer",
+			"before resolve *os.File",
+			"after resolve *os.File *os.File <nil>",
+			"before call func(*os.File) *bytes.Buffer",
+			"after call func(*os.File) *bytes.Buffer <nil>",
+			"after resolve *bytes.Buffer *bytes.Buffer <nil>",
+			"before resolve io.Reader",
+			"after resolve io.Reader <nil> more than one dependency implements the interface, bound types with possible match: [*bytes.Buffer *os.File]",
+		}
+		if !reflect.DeepEqual(o.events, expected) {
+			t.Errorf("expected %v got %v", expected, o.events)
+		}
+	})
+
+	t.Run("call", func(t *testing.T) {
+		o := &recordingObserver{}
+		ctx := di.New(di.WithObserver(o))
+
+		b := &failingBinder{}
+		ctx.Inject(b)
+
+		expected := []string{
+			"before resolve *os.File",
+			"after resolve *os.File *os.File <nil>",
+			"before call *di_test.failingBinder",
+			"after call *di_test.failingBinder missing file",
+		}
+		if !reflect.DeepEqual(o.events, expected) {
+			t.Errorf("expected %v got %v", expected, o.events)
+		}
+	})
+
+	t.Run("resolve", func(t *testing.T) {
+		o := &recordingObserver{}
+		ctx := di.New(di.WithObserver(o), di.WithObserver(o)).Add(os.Stdout)
+
+		di.Resolve[*os.File](ctx)
+
+		expected := []string{
+			"before resolve *os.File",
+			"before resolve *os.File",
+			"after resolve *os.File *os.File <nil>",
+			"after resolve *os.File *os.File <nil>",
+		}
+		if !reflect.DeepEqual(o.events, expected) {
+			t.Errorf("expected %v got %v", expected, o.events)
+		}
+	})
+
+	t.Run("validate is not observed", func(t *testing.T) {
+		o := &recordingObserver{}
+		ctx := di.New(di.WithObserver(o)).Add(os.Stdout)
+
+		ctx.Validate(func(f *os.File) {})
+		if len(o.events) != 0 {
+			t.Errorf("expected %v got %v", 0, o.events)
+		}
+	})
+}
@@ -0,0 +1,145 @@
+package di
+
+import (
+	"log/slog"
+	"reflect"
+)
+
+// Option configures a Context created with New.
+type Option func(*Context)
+
+// options are the settings configured by Options, which are inherited by
+// clones and children.
+type options struct {
+	onOverwrite func(t reflect.Type)
+	onMissing   func(t reflect.Type) (interface{}, error)
+	bindMethod  string
+	strict      bool
+	specific    bool
+	noOverwrite bool
+	nilPolicy   NilPolicy
+	recover     bool
+	observers   []Observer
+	logger      *slog.Logger
+	tracer      Tracer
+	counters    *expvarCounters
+	// how many providers Start builds at once, or -1 for GOMAXPROCS
+	startWorkers int
+	// the profiles set by WithProfiles
+	profiles []string
+}
+
+// bindMethodName returns the name of the method Inject calls on objects.
+func (o *options) bindMethodName() string {
+	if o.bindMethod == "" {
+		return methodName
+	}
+	return o.bindMethod
+}
+
+// WithOverwriteHook sets a function to be called whenever a registration overwrites an existing registration of the same
+// type, such as when Add is called twice with values of the same type. The hook is called once the registration has been
+// made and the Context unlocked, so it may use the Context.
+func WithOverwriteHook(fn func(t reflect.Type)) Option {
+	return func(ctx *Context) {
+		ctx.onOverwrite = fn
+	}
+}
+
+// WithBindMethod sets the name of the method which Inject calls on objects that aren't functions, instead of Bind. This
+// is useful when Bind already means something else to the types being injected.
+func WithBindMethod(name string) Option {
+	return func(ctx *Context) {
+		ctx.bindMethod = name
+	}
+}
+
+// WithOnMissing sets a function to be called whenever a parameter, field, or Resolve call has no matching dependency,
+// instead of using the zero value. The function is given the type which couldn't be resolved, and returns either a value
+// of that type to use, nil to use the zero value after all, or an error to make the injection fail. This allows defaults
+// to be made on demand, such as a no-op implementation of an interface.
+//
+// The function may be called from several goroutines at once, when injections run concurrently. It is not called for
+// optional fields.
+func WithOnMissing(fn func(t reflect.Type) (interface{}, error)) Option {
+	return func(ctx *Context) {
+		ctx.onMissing = fn
+	}
+}
+
+// WithStrict makes a missing dependency an error. Normally a parameter, field, or Resolve call with no matching
+// dependency gets the zero value, which can hide wiring mistakes until a nil pointer is used. In a strict Context, a
+// MissingError is returned instead. A callback set with WithOnMissing still gets a chance to supply a value first, and
+// optional fields are still left alone.
+func WithStrict() Option {
+	return func(ctx *Context) {
+		ctx.strict = true
+	}
+}
+
+// WithMostSpecific makes ambiguous interface parameters resolve to the most specific candidate instead of failing. When
+// several dependencies implement the requested interface, and one of them has every method the others have plus more, it
+// is injected. If no single candidate is more specific than all the others, ErrAmbiguous is still returned. A
+// preference set with Prefer takes priority.
+func WithMostSpecific() Option {
+	return func(ctx *Context) {
+		ctx.specific = true
+	}
+}
+
+// WithNoOverwrite makes registering a dependency under a type and name which are already registered an error wrapping
+// ErrDuplicate, instead of overwriting the existing registration. This catches copy-paste mistakes in large bootstrap
+// code. Replace still overwrites, since that is its purpose, and children can still shadow their parent's dependencies.
+func WithNoOverwrite() Option {
+	return func(ctx *Context) {
+		ctx.noOverwrite = true
+	}
+}
+
+// NilPolicy decides what Add and AddNamed do with nil dependencies.
+type NilPolicy int
+
+const (
+	// NilSkip skips nil dependencies and adds the rest. This is the default.
+	NilSkip NilPolicy = iota
+	// NilError skips nil dependencies and reports each one's position in an error wrapping ErrNilDependency, returned
+	// by Err.
+	NilError
+	// NilPanic panics with an error wrapping ErrNilDependency which gives the position of the nil dependency.
+	NilPanic
+)
+
+// WithNilPolicy sets what Add and AddNamed do when given a nil dependency, which is usually a mistake such as an
+// uninitialized variable. Only untyped nils count: a nil pointer of a specific type is registered under that type.
+func WithNilPolicy(policy NilPolicy) Option {
+	return func(ctx *Context) {
+		ctx.nilPolicy = policy
+	}
+}
+
+// WithRecover makes a panic in an injected function, Bind method, or provider be recovered and returned as a PanicError
+// carrying the panic value and stack trace, instead of unwinding through the caller of Inject. This keeps one bad
+// handler from taking down a server.
+func WithRecover() Option {
+	return func(ctx *Context) {
+		ctx.recover = true
+	}
+}
+
+// WithParallelStart makes Start build independent dependencies concurrently, calling up to n singleton providers at a
+// time, so that a service with many slow constructors, such as database connections and clients, doesn't build them one
+// after another. If n is less than 1, up to runtime.GOMAXPROCS providers are called at a time.
+//
+// Providers which depend on each other are still called in dependency order, and each is still called only once: a
+// provider whose dependency is being built by another provider waits for it. Dependencies are started in the order they
+// finished being built, which may differ from run to run for dependencies which don't depend on each other. If the
+// providers can't all be resolved, such as because of a cycle, Start builds them one at a time and reports the error as
+// usual.
+func WithParallelStart(n int) Option {
+	return func(ctx *Context) {
+		ctx.startWorkers = n
+		if n < 1 {
+			ctx.startWorkers = -1
+		}
+	}
+}
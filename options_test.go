@@ -0,0 +1,367 @@
+package di_test
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/mcvoid/di"
+)
+
+// a test value with a custom bind method
+type customBinder struct {
+	f *os.File
+}
+
+func (b *customBinder) InjectDeps(f *os.File) {
+	b.f = f
+}
+
+func (b *customBinder) Bind(f *os.File) {
+	panic("expected Bind to not be called")
+}
+
+func TestWithBindMethod(t *testing.T) {
+	t.Run("calls the configured method", func(t *testing.T) {
+		ctx := di.New(di.WithBindMethod("InjectDeps")).Add(os.Stdin)
+
+		b := customBinder{}
+		err := ctx.Inject(&b)
+		if err != nil {
+			t.Errorf("expected %v got %v", nil, err)
+		}
+		if b.f != os.Stdin {
+			t.Errorf("expected %v got %v", os.Stdin, b.f)
+		}
+	})
+
+	t.Run("bind is no longer used", func(t *testing.T) {
+		ctx := di.New(di.WithBindMethod("InjectDeps")).Add(os.Stdin)
+
+		b := testBinder{t: t}
+		err := ctx.Inject(&b)
+		if !errors.Is(err, di.ErrNotInjectable) {
+			t.Errorf("expected %v got %v", di.ErrNotInjectable, err)
+		}
+	})
+
+	t.Run("each method name is looked up for the type", func(t *testing.T) {
+		custom := di.New(di.WithBindMethod("InjectDeps")).Add(os.Stdin)
+		plain := di.New().Add(os.Stdin)
+
+		for i := 0; i < 3; i++ {
+			b := testBinder{t: t}
+			if err := plain.Inject(&b); err != nil {
+				t.Errorf("expected %v got %v", nil, err)
+			}
+			if !b.wasCalled {
+				t.Errorf("expected %v got %v", true, b.wasCalled)
+			}
+			if err := custom.Inject(&testBinder{t: t}); !errors.Is(err, di.ErrNotInjectable) {
+				t.Errorf("expected %v got %v", di.ErrNotInjectable, err)
+			}
+		}
+	})
+
+	t.Run("children inherit the method", func(t *testing.T) {
+		ctx := di.New(di.WithBindMethod("InjectDeps")).Child().Add(os.Stdin)
+
+		b := customBinder{}
+		ctx.Inject(&b)
+		if b.f != os.Stdin {
+			t.Errorf("expected %v got %v", os.Stdin, b.f)
+		}
+	})
+}
+
+type noopStringer struct{}
+
+func (noopStringer) String() string { return "" }
+
+func TestWithOnMissing(t *testing.T) {
+	t.Run("callback supplies missing values", func(t *testing.T) {
+		var requested []reflect.Type
+		ctx := di.New(di.WithOnMissing(func(t reflect.Type) (interface{}, error) {
+			requested = append(requested, t)
+			if t == reflect.TypeOf((*fmt.Stringer)(nil)).Elem() {
+				return noopStringer{}, nil
+			}
+			return nil, nil
+		})).Add(os.Stdin)
+
+		wasCalled := false
+		err := ctx.Inject(func(f *os.File, s fmt.Stringer, b *bytes.Buffer) {
+			wasCalled = true
+			if _, ok := s.(noopStringer); !ok {
+				t.Errorf("expected %T got %T", noopStringer{}, s)
+			}
+			if b != nil {
+				t.Errorf("expected %v got %v", nil, b)
+			}
+		})
+		if !wasCalled {
+			t.Errorf("expected func to be called")
+		}
+		if err != nil {
+			t.Errorf("expected %v got %v", nil, err)
+		}
+		if len(requested) != 2 {
+			t.Errorf("expected %v got %v", 1, requested)
+		}
+	})
+
+	t.Run("callback errors fail the injection", func(t *testing.T) {
+		errBoom := errors.New("boom")
+		ctx := di.New(di.WithOnMissing(func(t reflect.Type) (interface{}, error) {
+			return nil, errBoom
+		}))
+
+		_, err := di.Resolve[io.Writer](ctx)
+		if !errors.Is(err, errBoom) {
+			t.Errorf("expected %v got %v", errBoom, err)
+		}
+	})
+
+	t.Run("callback returns the wrong type", func(t *testing.T) {
+		ctx := di.New(di.WithOnMissing(func(t reflect.Type) (interface{}, error) {
+			return 42, nil
+		}))
+
+		var s struct{ W io.Writer }
+		err := ctx.Fill(&s)
+		if !errors.Is(err, di.ErrResultType) {
+			t.Errorf("expected %v got %v", di.ErrResultType, err)
+		}
+	})
+}
+
+type plainStringer struct{}
+
+func (plainStringer) String() string { return "plain" }
+
+type richStringer struct{}
+
+func (richStringer) String() string   { return "rich" }
+func (richStringer) GoString() string { return "rich" }
+
+type otherStringer struct{}
+
+func (otherStringer) String() string         { return "other" }
+func (otherStringer) Format(fmt.State, rune) {}
+
+func TestWithMostSpecific(t *testing.T) {
+	t.Run("most specific candidate wins", func(t *testing.T) {
+		ctx := di.New(di.WithMostSpecific()).Add(plainStringer{}, richStringer{})
+
+		s, err := di.Resolve[fmt.Stringer](ctx)
+		if s != (richStringer{}) {
+			t.Errorf("expected %v got %v", richStringer{}, s)
+		}
+		if err != nil {
+			t.Errorf("expected %v got %v", nil, err)
+		}
+	})
+
+	t.Run("no candidate is most specific", func(t *testing.T) {
+		ctx := di.New(di.WithMostSpecific()).Add(plainStringer{}, richStringer{}, otherStringer{})
+
+		_, err := di.Resolve[fmt.Stringer](ctx)
+		if !errors.Is(err, di.ErrAmbiguous) {
+			t.Errorf("expected %v got %v", di.ErrAmbiguous, err)
+		}
+	})
+
+	t.Run("preference takes priority", func(t *testing.T) {
+		ctx := di.New(di.WithMostSpecific()).Add(plainStringer{}, richStringer{})
+		di.Prefer[fmt.Stringer](ctx, plainStringer{})
+
+		s, _ := di.Resolve[fmt.Stringer](ctx)
+		if s != (plainStringer{}) {
+			t.Errorf("expected %v got %v", plainStringer{}, s)
+		}
+	})
+
+	t.Run("off by default", func(t *testing.T) {
+		ctx := di.New().Add(plainStringer{}, richStringer{})
+
+		_, err := di.Resolve[fmt.Stringer](ctx)
+		if !errors.Is(err, di.ErrAmbiguous) {
+			t.Errorf("expected %v got %v", di.ErrAmbiguous, err)
+		}
+	})
+}
+
+func TestWithNoOverwrite(t *testing.T) {
+	t.Run("add of the same type fails", func(t *testing.T) {
+		ctx := di.New(di.WithNoOverwrite()).Add(os.Stdout).Add(os.Stderr)
+
+		if err := ctx.Err(); !errors.Is(err, di.ErrDuplicate) {
+			t.Errorf("expected %v got %v", di.ErrDuplicate, err)
+		}
+		f, _ := di.Resolve[*os.File](ctx)
+		if f != os.Stdout {
+			t.Errorf("expected %v got %v", os.Stdout, f)
+		}
+	})
+
+	t.Run("provide of the same type fails", func(t *testing.T) {
+		ctx := di.New(di.WithNoOverwrite()).Add(os.Stdout)
+
+		err := ctx.Provide(func() *os.File { return os.Stderr })
+		if !errors.Is(err, di.ErrDuplicate) {
+			t.Errorf("expected %v got %v", di.ErrDuplicate, err)
+		}
+	})
+
+	t.Run("different names are allowed", func(t *testing.T) {
+		ctx := di.New(di.WithNoOverwrite()).Add(os.Stdout).AddNamed("err", os.Stderr)
+
+		if err := ctx.Err(); err != nil {
+			t.Errorf("expected %v got %v", nil, err)
+		}
+	})
+
+	t.Run("replace is allowed", func(t *testing.T) {
+		ctx := di.New(di.WithNoOverwrite()).Add(os.Stdout)
+
+		if err := ctx.Replace(os.Stderr); err != nil {
+			t.Errorf("expected %v got %v", nil, err)
+		}
+	})
+
+	t.Run("child can shadow", func(t *testing.T) {
+		ctx := di.New(di.WithNoOverwrite()).Add(os.Stdout)
+		child := ctx.Child().Add(os.Stderr)
+
+		if err := child.Err(); err != nil {
+			t.Errorf("expected %v got %v", nil, err)
+		}
+	})
+}
+
+func TestWithNilPolicy(t *testing.T) {
+	t.Run("skip adds the rest", func(t *testing.T) {
+		ctx := di.New().Add(nil, os.Stdout).AddNamed("out", nil, os.Stdout)
+
+		if err := ctx.Err(); err != nil {
+			t.Errorf("expected %v got %v", nil, err)
+		}
+		if !di.Has[*os.File](ctx) {
+			t.Errorf("expected %v got %v", true, false)
+		}
+		if !ctx.Contains(reflect.TypeOf(di.Named[*os.File, out]{})) {
+			t.Errorf("expected %v got %v", true, false)
+		}
+	})
+
+	t.Run("error reports the position", func(t *testing.T) {
+		ctx := di.New(di.WithNilPolicy(di.NilError)).Add(os.Stdout, nil, &bytes.Buffer{})
+
+		err := ctx.Err()
+		if !errors.Is(err, di.ErrNilDependency) {
+			t.Errorf("expected %v got %v", di.ErrNilDependency, err)
+		}
+		if err == nil || !strings.Contains(err.Error(), "argument 1") {
+			t.Errorf("expected %v got %v", "argument 1", err)
+		}
+		if !di.Has[*bytes.Buffer](ctx) {
+			t.Errorf("expected %v got %v", true, false)
+		}
+	})
+
+	t.Run("typed nil is registered", func(t *testing.T) {
+		var b *bytes.Buffer
+		ctx := di.New(di.WithNilPolicy(di.NilError)).Add(b)
+
+		if err := ctx.Err(); err != nil {
+			t.Errorf("expected %v got %v", nil, err)
+		}
+		if !di.Has[*bytes.Buffer](ctx) {
+			t.Errorf("expected %v got %v", true, false)
+		}
+	})
+
+	t.Run("panic", func(t *testing.T) {
+		ctx := di.New(di.WithNilPolicy(di.NilPanic))
+		defer func() {
+			err, _ := recover().(error)
+			if !errors.Is(err, di.ErrNilDependency) {
+				t.Errorf("expected %v got %v", di.ErrNilDependency, err)
+			}
+			// the lock was released
+			ctx.Add(os.Stdout)
+		}()
+
+		ctx.Add(nil)
+		t.Errorf("expected panic")
+	})
+}
+
+type out struct{}
+
+func (out) Qualifier() string { return "out" }
+
+func TestWithRecover(t *testing.T) {
+	t.Run("function panic", func(t *testing.T) {
+		ctx := di.New(di.WithRecover())
+
+		err := ctx.Inject(func() { panic("boom") })
+		if !errors.Is(err, di.ErrPanic) {
+			t.Errorf("expected %v got %v", di.ErrPanic, err)
+		}
+		var p *di.PanicError
+		if !errors.As(err, &p) {
+			t.Fatalf("expected %T got %v", p, err)
+		}
+		if p.Value != "boom" || len(p.Stack) == 0 {
+			t.Errorf("expected %v with stack got %v", "boom", p.Value)
+		}
+		if strings.Contains(err.Error(), "\n") {
+			t.Errorf("expected the stack to not be in the message got %q", err.Error())
+		}
+
+		// the lock was released
+		ctx.Add(os.Stdout)
+	})
+
+	t.Run("error panic value", func(t *testing.T) {
+		ctx := di.New(di.WithRecover())
+
+		err := ctx.Inject(func() { panic(io.EOF) })
+		if !errors.Is(err, io.EOF) {
+			t.Errorf("expected %v got %v", io.EOF, err)
+		}
+	})
+
+	t.Run("provider panic", func(t *testing.T) {
+		ctx := di.New(di.WithRecover())
+		ctx.Provide(func() *bytes.Buffer { panic("boom") })
+
+		err := ctx.Inject(func(b *bytes.Buffer) {
+			t.Errorf("expected func to not be called")
+		})
+		if !errors.Is(err, di.ErrPanic) {
+			t.Errorf("expected %v got %v", di.ErrPanic, err)
+		}
+		expected := "provider for *bytes.Buffer panicked: boom"
+		if err == nil || !strings.Contains(err.Error(), expected) || strings.Contains(err.Error(), "\n") {
+			t.Errorf("expected %v got %q", expected, err)
+		}
+	})
+
+	t.Run("not recovered by default", func(t *testing.T) {
+		ctx := di.New()
+		defer func() {
+			if r := recover(); r != "boom" {
+				t.Errorf("expected %v got %v", "boom", r)
+			}
+		}()
+
+		ctx.Inject(func() { panic("boom") })
+	})
+}
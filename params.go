@@ -0,0 +1,120 @@
+package di
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// In is a marker which, when embedded in a struct, makes that struct a parameter object. When an injected function
+// or provider has a parameter of such a struct type, a new struct is created and each of its fields is resolved from
+// the Context as if the struct were passed to Fill, 'di' tags included. This keeps functions with many dependencies
+// readable:
+//
+//	type serverParams struct {
+//		di.In
+//
+//		Logger  Logger
+//		Users   *UserRepo
+//		Replica *sql.DB `di:"name=replica"`
+//		Metrics Metrics `di:"optional"`
+//	}
+//
+//	ctx.Inject(func(p serverParams) { ... })
+type In struct{}
+
+// Out is a marker which, when embedded in a struct, makes that struct a result object. When a provider returns such a
+// struct, each of its exported fields is registered as a separate dependency, so one constructor can provide several
+// related components at once. The provider is still only called once for all of them unless it is Transient. Options
+// given to Provide, such as Final and WhenFlag, apply to every field. Fields can be given a name with a
+// `di:"name=..."` tag, or skipped with `di:"-"`.
+//
+//	type storage struct {
+//		di.Out
+//
+//		Users   *UserRepo
+//		Orders  *OrderRepo
+//		Replica *sql.DB `di:"name=replica"`
+//	}
+//
+//	ctx.Provide(func(cfg Config) (storage, error) { ... })
+type Out struct{}
+
+var (
+	inType  = reflect.TypeOf(In{})
+	outType = reflect.TypeOf(Out{})
+)
+
+// isIn reports whether t is a struct which embeds In.
+func isIn(t reflect.Type) bool {
+	return embeds(t, inType)
+}
+
+// isOut reports whether t is a struct which embeds Out.
+func isOut(t reflect.Type) bool {
+	return embeds(t, outType)
+}
+
+// embeds reports whether t is a struct which embeds the marker type.
+func embeds(t reflect.Type, marker reflect.Type) bool {
+	if t.Kind() != reflect.Struct {
+		return false
+	}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.Anonymous && field.Type == marker {
+			return true
+		}
+	}
+	return false
+}
+
+// resolveIn builds a parameter object and fills its fields.
+func resolveIn(ctx *Context, r *resolution, argType reflect.Type) (reflect.Value, error) {
+	val := reflect.New(argType).Elem()
+	assignments, err := fillStruct(ctx, r, val, fillOptions{}, argType.Name()+".")
+	if err != nil {
+		return reflect.Value{}, err
+	}
+	for _, a := range assignments {
+		a.field.Set(a.val)
+	}
+	return val, nil
+}
+
+// registerOut registers each field of a provider's result object as its own
+// dependency, sharing the provider between them.
+// The caller must hold ctx.lock.
+func registerOut(ctx *Context, e *entry, t reflect.Type) error {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() || field.Anonymous && field.Type == outType {
+			continue
+		}
+		opts, err := parseTag(field.Tag.Get(tagName))
+		if err != nil {
+			return fmt.Errorf("field %v: %w", field.Name, err)
+		}
+		if opts.skip {
+			continue
+		}
+
+		name := e.name
+		if opts.name != "" {
+			name = opts.name
+		}
+		// every option but the name applies to each field
+		fieldEntry := &entry{
+			name:       name,
+			prov:       e.prov,
+			field:      field.Index,
+			final:      e.final,
+			profiles:   e.profiles,
+			conditions: e.conditions,
+			flag:       e.flag,
+		}
+		if err := register(ctx, key{field.Type, name}, fieldEntry); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,182 @@
+package di_test
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/mcvoid/di"
+)
+
+type params struct {
+	di.In
+
+	File     *os.File
+	Reader   io.Reader
+	Replica  *bytes.Buffer `di:"name=replica"`
+	Optional fmt.Stringer  `di:"optional"`
+	hidden   *os.File
+}
+
+type widget struct{}
+
+func TestIn(t *testing.T) {
+	t.Run("fields are resolved", func(t *testing.T) {
+		b := &bytes.Buffer{}
+		ctx := di.New().Add(os.Stdin).AddNamed("replica", b)
+
+		wasCalled := false
+		err := ctx.Inject(func(p params) {
+			wasCalled = true
+			if p.File != os.Stdin {
+				t.Errorf("expected %v got %v", os.Stdin, p.File)
+			}
+			if p.Replica != b {
+				t.Errorf("expected %p got %p", b, p.Replica)
+			}
+			if p.Optional != nil {
+				t.Errorf("expected %v got %v", nil, p.Optional)
+			}
+			if p.hidden != nil {
+				t.Errorf("expected %v got %v", nil, p.hidden)
+			}
+		})
+		if !wasCalled {
+			t.Errorf("expected func to be called")
+		}
+		if err != nil {
+			t.Errorf("expected %v got %v", nil, err)
+		}
+	})
+
+	t.Run("the marker isn't resolved", func(t *testing.T) {
+		ctx := di.New(di.WithStrict()).Add(os.Stdin)
+
+		err := ctx.Inject(func(p struct {
+			di.In
+			File *os.File
+		}) {
+			if p.File != os.Stdin {
+				t.Errorf("expected %v got %v", os.Stdin, p.File)
+			}
+		})
+		if err != nil {
+			t.Errorf("expected %v got %v", nil, err)
+		}
+	})
+
+	t.Run("field errors abort injection", func(t *testing.T) {
+		ctx := di.New().Add(os.Stdin, &bytes.Buffer{})
+
+		err := ctx.Inject(func(p struct {
+			di.In
+			Writer io.Writer
+		}) {
+			t.Errorf("expected func to not be called")
+		})
+		if !errors.Is(err, di.ErrAmbiguous) {
+			t.Errorf("expected %v got %v", di.ErrAmbiguous, err)
+		}
+	})
+
+	t.Run("providers can take parameter objects", func(t *testing.T) {
+		ctx := di.New().Add(os.Stdin)
+		ctx.Provide(func(p params) *widget {
+			if p.File != os.Stdin {
+				t.Errorf("expected %v got %v", os.Stdin, p.File)
+			}
+			return &widget{}
+		})
+
+		_, err := di.Resolve[*widget](ctx)
+		if err != nil {
+			t.Errorf("expected %v got %v", nil, err)
+		}
+	})
+}
+
+type results struct {
+	di.Out
+
+	Buffer  *bytes.Buffer
+	Reader  *bytes.Reader
+	Replica *bytes.Buffer `di:"name=replica"`
+	Skipped *os.File      `di:"-"`
+}
+
+func TestOut(t *testing.T) {
+	t.Run("fields are registered separately", func(t *testing.T) {
+		b, r, rb := &bytes.Buffer{}, &bytes.Reader{}, &bytes.Buffer{}
+		calls := 0
+		ctx := di.New()
+		err := ctx.Provide(func() results {
+			calls++
+			return results{Buffer: b, Reader: r, Replica: rb, Skipped: os.Stdin}
+		})
+		if err != nil {
+			t.Errorf("expected %v got %v", nil, err)
+		}
+
+		wasCalled := false
+		err = ctx.Inject(func(buf *bytes.Buffer, rd *bytes.Reader, rep di.Named[*bytes.Buffer, replica]) {
+			wasCalled = true
+			if buf != b {
+				t.Errorf("expected %p got %p", b, buf)
+			}
+			if rd != r {
+				t.Errorf("expected %p got %p", r, rd)
+			}
+			if rep.Value != rb {
+				t.Errorf("expected %p got %p", rb, rep.Value)
+			}
+		})
+		if !wasCalled {
+			t.Errorf("expected func to be called")
+		}
+		if err != nil {
+			t.Errorf("expected %v got %v", nil, err)
+		}
+		if calls != 1 {
+			t.Errorf("expected %v got %v", 1, calls)
+		}
+		if di.Has[*os.File](ctx) || di.Has[results](ctx) {
+			t.Errorf("expected only the fields to be registered")
+		}
+	})
+
+	t.Run("provider errors", func(t *testing.T) {
+		errBoom := errors.New("boom")
+		ctx := di.New()
+		ctx.Provide(func() (results, error) { return results{}, errBoom })
+
+		_, err := di.Resolve[*bytes.Reader](ctx)
+		if !errors.Is(err, errBoom) {
+			t.Errorf("expected %v got %v", errBoom, err)
+		}
+	})
+
+	t.Run("options apply to every field", func(t *testing.T) {
+		ctx := di.New().Add(di.StaticFlags{})
+		ctx.Provide(func() results { return results{} }, di.Final(), di.WhenFlag("results"))
+
+		for _, r := range ctx.Registrations() {
+			if r.Type == reflect.TypeOf(di.StaticFlags{}) {
+				continue
+			}
+			if !r.Final || r.Flag != "results" {
+				t.Errorf("expected %v to be final and behind a flag got %+v", r.Type, r)
+			}
+		}
+		if _, err := di.Resolve[*bytes.Reader](ctx); !errors.Is(err, di.ErrFlagOff) {
+			t.Errorf("expected %v got %v", di.ErrFlagOff, err)
+		}
+		ctx.Add(&bytes.Reader{})
+		if !errors.Is(ctx.Err(), di.ErrFinal) {
+			t.Errorf("expected %v got %v", di.ErrFinal, ctx.Err())
+		}
+	})
+}
@@ -0,0 +1,86 @@
+package di
+
+import (
+	"reflect"
+	"sync"
+)
+
+// pooledArgs is the most arguments a pooled argument slice holds. Functions
+// with more parameters get a new slice for every call.
+const pooledArgs = 8
+
+// argPool holds argument slices for calls, so injecting doesn't allocate one
+// every time.
+var argPool = sync.Pool{
+	New: func() interface{} { return new([pooledArgs]reflect.Value) },
+}
+
+// getArgs returns a slice for n arguments.
+func getArgs(n int) []reflect.Value {
+	if n > pooledArgs {
+		return make([]reflect.Value, n)
+	}
+	return argPool.Get().(*[pooledArgs]reflect.Value)[:n]
+}
+
+// putArgs returns a slice from getArgs to the pool once the call it was for
+// is done. The values are cleared so the pool doesn't keep them alive.
+func putArgs(in []reflect.Value) {
+	if cap(in) != pooledArgs {
+		return
+	}
+	buf := (*[pooledArgs]reflect.Value)(in[:pooledArgs])
+	*buf = [pooledArgs]reflect.Value{}
+	argPool.Put(buf)
+}
+
+// resolutionPool holds resolutions for top-level injections.
+var resolutionPool = sync.Pool{
+	New: func() interface{} { return &resolution{} },
+}
+
+// newResolution returns an empty resolution for a top-level injection.
+func newResolution() *resolution {
+	return resolutionPool.Get().(*resolution)
+}
+
+// release returns a resolution from newResolution to the pool once the
+// injection is done.
+func (r *resolution) release() {
+	clear(r.building)
+	*r = resolution{building: r.building[:0]}
+	resolutionPool.Put(r)
+}
+
+// candidates are the dependencies found for an interface, in buffers reused
+// between resolutions.
+type candidates struct {
+	keys    []key
+	entries []*entry
+	types   []reflect.Type
+}
+
+// candidatePool holds candidate buffers for resolveNamed.
+var candidatePool = sync.Pool{
+	New: func() interface{} { return &candidates{} },
+}
+
+// getCandidates returns empty candidate buffers.
+func getCandidates() *candidates {
+	return candidatePool.Get().(*candidates)
+}
+
+// put returns the buffers to the pool. The types must have been copied if
+// they are kept, such as in an error.
+func (c *candidates) put() {
+	clear(c.keys)
+	clear(c.entries)
+	clear(c.types)
+	c.keys, c.entries, c.types = c.keys[:0], c.entries[:0], c.types[:0]
+	candidatePool.Put(c)
+}
+
+// copyTypes copies candidate types out of their buffer.
+func copyTypes(types []reflect.Type) []reflect.Type {
+	return append([]reflect.Type(nil), types...)
+}
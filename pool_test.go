@@ -0,0 +1,128 @@
+package di_test
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/mcvoid/di"
+)
+
+func TestPooling(t *testing.T) {
+	t.Run("concurrent injections get their own arguments", func(t *testing.T) {
+		a, b := &strings.Builder{}, &strings.Builder{}
+		ctxA, ctxB := di.New().Add(a), di.New().Add(b)
+
+		var wg sync.WaitGroup
+		for i := 0; i < 100; i++ {
+			ctx, want := ctxA, a
+			if i%2 == 1 {
+				ctx, want = ctxB, b
+			}
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				ctx.Inject(func(w io.Writer, sb *strings.Builder) {
+					if w != want || sb != want {
+						t.Errorf("expected %v got %v and %v", want, w, sb)
+					}
+				})
+			}()
+		}
+		wg.Wait()
+	})
+
+	t.Run("functions with many parameters are injected", func(t *testing.T) {
+		sb := &strings.Builder{}
+		ctx := di.New().Add(sb, strings.NewReader(""))
+		err := ctx.Inject(func(a, b, c, d, e io.Writer, f, g, h, i, j io.Reader) {
+			for _, w := range []io.Writer{a, b, c, d, e} {
+				if w != sb {
+					t.Errorf("expected %v got %v", sb, w)
+				}
+			}
+			for _, r := range []io.Reader{f, g, h, i, j} {
+				if r == nil {
+					t.Errorf("expected reader got %v", r)
+				}
+			}
+		})
+		if err != nil {
+			t.Errorf("expected %v got %v", nil, err)
+		}
+	})
+
+	t.Run("arguments are released after a failed injection", func(t *testing.T) {
+		ctx := di.New(di.WithStrict()).Add(os.Stdout)
+		for i := 0; i < 3; i++ {
+			if err := ctx.Inject(func(io.Writer, *bytes.Buffer) {}); !errors.Is(err, di.ErrMissing) {
+				t.Errorf("expected %v got %v", di.ErrMissing, err)
+			}
+			if err := ctx.Inject(func(w io.Writer) {
+				if w != os.Stdout {
+					t.Errorf("expected %v got %v", os.Stdout, w)
+				}
+			}); err != nil {
+				t.Errorf("expected %v got %v", nil, err)
+			}
+		}
+	})
+
+	t.Run("ambiguous candidates are kept", func(t *testing.T) {
+		ctx := di.New().Add(os.Stdout, &bytes.Buffer{})
+		_, err := di.Resolve[io.Writer](ctx)
+		var ambiguous *di.AmbiguousError
+		if !errors.As(err, &ambiguous) {
+			t.Fatalf("expected %T got %v", ambiguous, err)
+		}
+		want := []reflect.Type{reflect.TypeOf(&bytes.Buffer{}), reflect.TypeOf(os.Stdout)}
+
+		other := di.New().Add(&strings.Builder{}, io.Discard)
+		for i := 0; i < 10; i++ {
+			di.Resolve[io.Writer](other)
+		}
+		if !reflect.DeepEqual(ambiguous.Candidates, want) {
+			t.Errorf("expected %v got %v", want, ambiguous.Candidates)
+		}
+	})
+
+	t.Run("explained candidates are kept", func(t *testing.T) {
+		ctx := di.New().Add(os.Stdout)
+		e, err := ctx.Explain(func(io.Writer) {})
+		if err != nil {
+			t.Fatalf("expected %v got %v", nil, err)
+		}
+		want := []reflect.Type{reflect.TypeOf(os.Stdout)}
+
+		other := di.New().Add(&strings.Builder{}, io.Discard)
+		for i := 0; i < 10; i++ {
+			other.Explain(func(io.Writer) {})
+		}
+		if got := e.Params[0].Candidates; !reflect.DeepEqual(got, want) {
+			t.Errorf("expected %v got %v", want, got)
+		}
+	})
+}
+
+func BenchmarkInject(b *testing.B) {
+	ctx := di.New().Add(&strings.Builder{}, strings.NewReader(""))
+
+	b.Run("Inject", func(b *testing.B) {
+		b.ReportAllocs()
+		target := func(w io.Writer, r io.Reader) {}
+		for i := 0; i < b.N; i++ {
+			ctx.Inject(target)
+		}
+	})
+	b.Run("Resolve", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			di.Resolve[io.Reader](ctx)
+		}
+	})
+}
@@ -0,0 +1,46 @@
+package di
+
+// Profile limits a registration made with Provide or AddAs to the given profiles, such as "dev", "test", or "prod". The
+// registration is only made if the Context was created with WithProfiles activating at least one of them; otherwise
+// Provide or AddAs does nothing. This lets the same wiring, such as a Module, swap fake implementations for real ones
+// without if/else code:
+//
+//	ctx.Provide(newFakeMailer, di.Profile("dev", "test"))
+//	ctx.Provide(newSMTPMailer, di.Profile("prod"))
+//
+// Registrations without a profile are always made.
+func Profile(names ...string) RegisterOption {
+	return func(e *entry) {
+		e.profiles = append(e.profiles, names...)
+	}
+}
+
+// WithProfiles activates profiles in a Context, so that registrations made with the Profile option for any of them
+// take effect. Modules with Profiles are likewise only used by Use if one of theirs is active. Children and clones have
+// the same profiles active.
+func WithProfiles(names ...string) Option {
+	return func(ctx *Context) {
+		ctx.profiles = append(ctx.profiles, names...)
+	}
+}
+
+// Profiles returns the profiles active in the Context.
+func (ctx *Context) Profiles() []string {
+	return append([]string{}, ctx.profiles...)
+}
+
+// active reports whether a registration or module limited to the given
+// profiles should be made. One with no profiles always is.
+func (o *options) active(profiles []string) bool {
+	if len(profiles) == 0 {
+		return true
+	}
+	for _, name := range profiles {
+		for _, active := range o.profiles {
+			if name == active {
+				return true
+			}
+		}
+	}
+	return false
+}
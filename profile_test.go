@@ -0,0 +1,89 @@
+package di_test
+
+import (
+	"io"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/mcvoid/di"
+)
+
+func TestProfiles(t *testing.T) {
+	wire := func(ctx *di.Context) {
+		ctx.Provide(func() io.Writer { return &strings.Builder{} }, di.Profile("dev", "test"))
+		ctx.Provide(func() io.Writer { return os.Stdout }, di.Profile("prod"))
+	}
+
+	t.Run("registrations for active profiles are made", func(t *testing.T) {
+		ctx := di.New(di.WithProfiles("prod"))
+		wire(ctx)
+		if w := di.MustResolve[io.Writer](ctx); w != os.Stdout {
+			t.Errorf("expected %v got %v", os.Stdout, w)
+		}
+
+		ctx = di.New(di.WithProfiles("test"))
+		wire(ctx)
+		if w := di.MustResolve[io.Writer](ctx); w == os.Stdout {
+			t.Errorf("expected %T got %v", &strings.Builder{}, w)
+		}
+	})
+
+	t.Run("registrations for other profiles are skipped", func(t *testing.T) {
+		ctx := di.New()
+		wire(ctx)
+		if di.Has[io.Writer](ctx) {
+			t.Errorf("expected %v to not be registered", "io.Writer")
+		}
+		if err := di.AddAs[io.Writer](ctx, os.Stdout, di.Profile("prod")); err != nil {
+			t.Errorf("expected %v got %v", nil, err)
+		}
+		if di.Has[io.Writer](ctx) {
+			t.Errorf("expected %v to not be registered", "io.Writer")
+		}
+	})
+
+	t.Run("registrations without a profile are always made", func(t *testing.T) {
+		ctx := di.New(di.WithProfiles("prod"))
+		ctx.Provide(func() *strings.Builder { return &strings.Builder{} })
+		di.AddAs[io.Reader](ctx, os.Stdin)
+		if !di.Has[*strings.Builder](ctx) || !di.Has[io.Reader](ctx) {
+			t.Errorf("expected registrations to be made got %v", ctx.Registrations())
+		}
+	})
+
+	t.Run("modules for other profiles are skipped", func(t *testing.T) {
+		fake := di.Module{Name: "fake", Profiles: []string{"test"}, Register: func(ctx *di.Context) error {
+			ctx.Add(&strings.Builder{})
+			return nil
+		}}
+		ctx := di.New(di.WithProfiles("prod"))
+		if err := ctx.Use(fake); err != nil {
+			t.Errorf("expected %v got %v", nil, err)
+		}
+		if di.Has[*strings.Builder](ctx) {
+			t.Errorf("expected %v to not be registered", "*strings.Builder")
+		}
+
+		ctx = di.New(di.WithProfiles("test"))
+		if err := ctx.Use(fake); err != nil {
+			t.Errorf("expected %v got %v", nil, err)
+		}
+		if !di.Has[*strings.Builder](ctx) {
+			t.Errorf("expected %v to be registered", "*strings.Builder")
+		}
+	})
+
+	t.Run("children have the same profiles", func(t *testing.T) {
+		ctx := di.New(di.WithProfiles("dev", "test")).Child()
+		expected := []string{"dev", "test"}
+		if !reflect.DeepEqual(ctx.Profiles(), expected) {
+			t.Errorf("expected %v got %v", expected, ctx.Profiles())
+		}
+		wire(ctx)
+		if !di.Has[io.Writer](ctx) {
+			t.Errorf("expected %v to be registered", "io.Writer")
+		}
+	})
+}
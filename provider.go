@@ -0,0 +1,434 @@
+package di
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+	"time"
+)
+
+var errorType = reflect.TypeOf((*error)(nil)).Elem()
+
+// lifetime controls how often a provider is called.
+type lifetime int
+
+const (
+	// the provider is called once and its value is reused
+	singleton lifetime = iota
+	// the provider is called every time its value is needed
+	transient
+)
+
+// RegisterOption configures a single registration in a Context.
+type RegisterOption func(*entry)
+
+// Transient makes a provider construct a new value every time one is needed, rather than caching the first value it
+// constructs.
+func Transient() RegisterOption {
+	return func(e *entry) {
+		if e.prov != nil {
+			e.prov.lifetime = transient
+		}
+	}
+}
+
+// Name registers a provider's value under the given name, so it can be requested with Named. See AddNamed.
+func Name(name string) RegisterOption {
+	return func(e *entry) {
+		e.name = name
+	}
+}
+
+// Final seals a registration, so that later attempts to add, provide, or replace a dependency under the same type and
+// name, or to remove it, fail with an error wrapping ErrFinal instead of silently swapping it out. This guarantees that
+// security-sensitive components can't be changed by code which runs after they're wired. To register a value rather
+// than a provider as final, use AddAs with its own type:
+//
+//	di.AddAs[*Authenticator](ctx, auth, di.Final())
+//
+// A clone keeps the registration final. A child can still shadow it, since that never changes what the original
+// Context injects.
+func Final() RegisterOption {
+	return func(e *entry) {
+		e.final = true
+	}
+}
+
+// Timeout makes a provider fail with an error wrapping ErrTimeout if it hasn't returned within d, so that a constructor
+// which hangs, such as one dialing an unreachable database, fails the injection instead of hanging it forever:
+//
+//	ctx.Provide(openDB, di.Timeout(5*time.Second))
+//
+// The constructor can't be stopped, so it is left running in the background, and whatever it eventually returns is
+// thrown away. As with any failure, the constructor is called again the next time its value is needed.
+func Timeout(d time.Duration) RegisterOption {
+	return func(e *entry) {
+		if e.prov != nil {
+			e.prov.timeout = d
+		}
+	}
+}
+
+// Retry makes a provider which fails be called again, up to attempts times in all, so that a transient failure while
+// wiring, such as a DNS blip while dialing a message broker, doesn't fail the whole injection:
+//
+//	ctx.Provide(dialBroker, di.Retry(3, 100*time.Millisecond))
+//
+// The provider is called again after waiting for backoff, which doubles after each further failure. Only failures of
+// the provider itself are retried: errors it returns, panics recovered by WithRecover, and timeouts set with Timeout. A
+// failure to resolve its parameters is returned straight away, and so is a failure once the context.Context of
+// InjectContext or Start is done, which also stops the wait between attempts. The error from the last attempt is
+// returned.
+func Retry(attempts int, backoff time.Duration) RegisterOption {
+	return func(e *entry) {
+		if e.prov != nil {
+			e.prov.attempts, e.prov.backoff = attempts, backoff
+		}
+	}
+}
+
+// provider is a constructor function registered with Provide.
+type provider struct {
+	fn       reflect.Value
+	lifetime lifetime
+	timeout  time.Duration
+	// how many times to try calling the constructor, and how long to wait
+	// before trying the second time
+	attempts int
+	backoff  time.Duration
+	breaker  *breaker
+	// called if the constructor fails
+	fallback *provider
+
+	// guards construction so the value is only built once; held for reading
+	// to get the value once it is built
+	lock  sync.RWMutex
+	done  bool
+	val   reflect.Value
+	built uint64 // when the value was built, in the same sequence as registrations
+}
+
+// Provide registers a constructor for a dependency. The constructor must be a function returning a single value, or a value
+// and an error; it is registered under the type of that first return value, following the same overwrite rules as Add.
+//
+// The constructor is not called when it is registered. Instead, it is called the first time its return type is needed by
+// Inject, with its own parameters resolved from the Context by the same rules as any injected function.
+// This lets the Context work out construction order rather than requiring dependencies to be built by hand.
+// The constructed value is cached and reused for every later injection, unless the Transient option is given, in which
+// case the constructor is called each time.
+//
+// A constructor is never run more than once at a time, so concurrent injections needing the same dependency will all
+// receive the one value it produces.
+//
+// If the constructor returns a struct which embeds Out, each of the struct's fields is registered as a separate dependency
+// instead of the struct itself.
+//
+// If the constructor returns a non-nil error, the injection which needed it is aborted and the error is returned from Inject.
+// If the value it returns implements AfterInjecter, AfterInject is called on it, and an error from it is treated the same.
+// Failures are not cached, so the constructor will be tried again the next time its value is needed.
+// If constructors depend on each other in a cycle, the injection fails with a CycleError listing the types in the cycle,
+// rather than deadlocking, even when concurrent injections enter the cycle from different ends.
+func (ctx *Context) Provide(fn interface{}, opts ...RegisterOption) error {
+	val, err := providerFunc(fn)
+	if err != nil {
+		return err
+	}
+	t := val.Type()
+
+	e := &entry{prov: &provider{fn: val}}
+	for _, opt := range opts {
+		opt(e)
+	}
+	if fallback := e.prov.fallback; fallback != nil {
+		if fallback.fn.Kind() == reflect.Invalid {
+			return fmt.Errorf("fallback %w: %v", ErrInvalidProvider, nil)
+		}
+		if _, err := providerFunc(fallback.fn.Interface()); err != nil {
+			return fmt.Errorf("fallback %w", err)
+		}
+		if out := fallback.fn.Type().Out(0); !out.AssignableTo(t.Out(0)) {
+			return fmt.Errorf("fallback %w: %v returns %v, not %v", ErrInvalidProvider, fallback.fn.Type(), out, t.Out(0))
+		}
+		fallback.lifetime = e.prov.lifetime
+	}
+	if !ctx.enabled(e) {
+		return nil
+	}
+
+	ctx.lock.Lock()
+	defer ctx.commit()
+
+	if isOut(t.Out(0)) {
+		return registerOut(ctx, e, t.Out(0))
+	}
+	return register(ctx, key{t.Out(0), e.name}, e)
+}
+
+// providerFunc checks that fn can be used as a provider.
+func providerFunc(fn interface{}) (reflect.Value, error) {
+	if fn == nil {
+		return reflect.Value{}, fmt.Errorf("%w: %v", ErrInvalidProvider, fn)
+	}
+	val := reflect.ValueOf(fn)
+	t := val.Type()
+	if t.Kind() != reflect.Func || t.NumOut() < 1 || t.NumOut() > 2 {
+		return reflect.Value{}, fmt.Errorf("%w: %v", ErrInvalidProvider, t)
+	}
+	if t.NumOut() == 2 && t.Out(1) != errorType {
+		return reflect.Value{}, fmt.Errorf("%w: %v", ErrInvalidProvider, t)
+	}
+	return val, nil
+}
+
+// Fallback gives a provider a second constructor, which is called only when the provider fails, such as to use an
+// in-memory cache when Redis is unreachable:
+//
+//	ctx.Provide(newRedisCache, di.Fallback(newMemoryCache))
+//
+// The fallback follows the same rules as a provider, and its first return value must be assignable to the type the
+// provider is registered under. Its parameters are resolved only when it is called. A value it constructs is cached
+// in place of the provider's, unless the provider is transient. If the fallback fails too, both errors are returned.
+//
+// The fallback isn't called when the provider fails because the context.Context of InjectContext or Start is done.
+// Options such as Timeout and Retry apply to the provider, not its fallback.
+func Fallback(fn interface{}) RegisterOption {
+	return func(e *entry) {
+		if e.prov != nil {
+			e.prov.fallback = &provider{fn: reflect.ValueOf(fn)}
+		}
+	}
+}
+
+// get returns the cached value, constructing it on first use. Transient providers
+// construct a new value every time.
+func (p *provider) get(ctx *Context, r *resolution) (reflect.Value, error) {
+	if err := r.enter(p); err != nil {
+		return reflect.Value{}, err
+	}
+	defer r.leave()
+
+	if r.validate {
+		return p.validate(ctx, r)
+	}
+	if p.lifetime == transient {
+		return p.build(ctx, r)
+	}
+
+	// injections of a value which is already built don't wait for each other
+	if val, _, ok := p.cached(); ok {
+		return val, nil
+	}
+	// cycles are only found within a resolution, so two injections entering
+	// one from different ends would each wait for the other's provider
+	if err := p.acyclic(ctx, r); err != nil {
+		return reflect.Value{}, err
+	}
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if p.done {
+		return p.val, nil
+	}
+	val, err := p.build(ctx, r)
+	if err != nil {
+		return reflect.Value{}, err
+	}
+	p.val, p.done, p.built = val, true, seq.Add(1)
+	return val, nil
+}
+
+// acyclic checks that the provider doesn't depend on itself, by validating
+// it, which calls no providers and so takes none of their locks.
+func (p *provider) acyclic(ctx *Context, r *resolution) error {
+	vr := newResolution()
+	defer vr.release()
+	vr.validate, vr.stdctx = true, r.stdctx
+
+	var cycle *CycleError
+	if _, err := p.get(ctx, vr); errors.As(err, &cycle) {
+		return cycle
+	}
+	return nil
+}
+
+// build constructs a new value, using the fallback if the provider fails.
+func (p *provider) build(ctx *Context, r *resolution) (reflect.Value, error) {
+	val, err := p.callUnlessOpen(ctx, r)
+	if err == nil || p.fallback == nil || r.context().Err() != nil {
+		return val, err
+	}
+	fallback, fallbackErr := p.fallback.call(ctx, r)
+	if fallbackErr != nil {
+		return reflect.Value{}, errors.Join(err, fmt.Errorf("fallback %w", fallbackErr))
+	}
+	// as the type the provider is registered under, which the fallback's
+	// value may only be assignable to
+	val = reflect.New(p.fn.Type().Out(0)).Elem()
+	val.Set(fallback)
+	return val, nil
+}
+
+// cached returns the value a singleton provider has already built, and when it
+// was built, without building it.
+func (p *provider) cached() (reflect.Value, uint64, bool) {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+
+	return p.val, p.built, p.done
+}
+
+// enter records that p is being called, unless it is already being called
+// further up, which means the providers depend on each other in a cycle.
+func (r *resolution) enter(p *provider) error {
+	for i, other := range r.building {
+		if other == p {
+			path := []reflect.Type{}
+			for _, b := range r.building[i:] {
+				path = append(path, b.fn.Type().Out(0))
+			}
+			return &CycleError{Path: append(path, p.fn.Type().Out(0))}
+		}
+	}
+	r.building = append(r.building, p)
+	return nil
+}
+
+// leave records that the last provider entered has finished.
+func (r *resolution) leave() {
+	r.building = r.building[:len(r.building)-1]
+}
+
+// validate checks that the provider's parameters, or else its fallback's, can
+// be resolved, returning the value it has already built, or else the zero
+// value, without calling it.
+func (p *provider) validate(ctx *Context, r *resolution) (reflect.Value, error) {
+	if val, _, ok := p.cached(); ok {
+		return val, nil
+	}
+	in, err := resolveArgs(ctx, r, p.fn.Type())
+	putArgs(in)
+	if err != nil && p.fallback != nil {
+		in, err = resolveArgs(ctx, r, p.fallback.fn.Type())
+		putArgs(in)
+	}
+	if err != nil {
+		return reflect.Value{}, fmt.Errorf("provider for %v: %w", p.fn.Type().Out(0), err)
+	}
+	return reflect.Zero(p.fn.Type().Out(0)), nil
+}
+
+// call constructs a new value by injecting the Context into the provider,
+// trying again if it fails and was registered with Retry.
+func (p *provider) call(ctx *Context, r *resolution) (val reflect.Value, err error) {
+	end := traceProvider(ctx, r, p.fn.Type().Out(0))
+	defer func() { end(err) }()
+
+	backoff := p.backoff
+	for attempt := 1; ; attempt++ {
+		val, retry, err := p.attempt(ctx, r)
+		if err == nil || !retry || attempt >= p.attempts {
+			if err != nil && attempt > 1 {
+				err = fmt.Errorf("%w (after %d attempts)", err, attempt)
+			}
+			return val, err
+		}
+
+		timer := time.NewTimer(backoff)
+		select {
+		case <-timer.C:
+		case <-r.context().Done():
+			timer.Stop()
+			return reflect.Value{}, fmt.Errorf("%w (stopped retrying: %w)", err, r.context().Err())
+		}
+		backoff *= 2
+	}
+}
+
+// attempt calls the provider once, reporting whether a failure was its own,
+// and so is worth trying again, rather than one resolving its parameters or
+// a canceled context.
+func (p *provider) attempt(ctx *Context, r *resolution) (reflect.Value, bool, error) {
+	in, err := resolveArgs(ctx, r, p.fn.Type())
+	if err != nil {
+		return reflect.Value{}, false, fmt.Errorf("provider for %v: %w", p.fn.Type().Out(0), err)
+	}
+	out, err := p.invoke(ctx, r, in)
+	if err != nil {
+		return reflect.Value{}, r.context().Err() == nil, fmt.Errorf("provider for %v %w", p.fn.Type().Out(0), err)
+	}
+	if len(out) == 2 && !out[1].IsNil() {
+		return reflect.Value{}, true, fmt.Errorf("provider for %v: %w", out[0].Type(), out[1].Interface().(error))
+	}
+	if out[0].CanInterface() {
+		if err := afterInject(out[0].Interface()); err != nil {
+			return reflect.Value{}, true, fmt.Errorf("provider for %v: %w", out[0].Type(), err)
+		}
+	}
+	if r.progress != nil {
+		r.progress.add(p.fn.Type().Out(0))
+	}
+	return out[0], false, nil
+}
+
+// invoke calls the constructor with its arguments, giving up if it has a
+// timeout which passes first, or if the context of the resolution is done
+// first. The arguments are given back with putArgs once the constructor has
+// them.
+func (p *provider) invoke(ctx *Context, r *resolution, in []reflect.Value) ([]reflect.Value, error) {
+	stdctx := r.context()
+	if err := stdctx.Err(); err != nil {
+		putArgs(in)
+		return nil, fmt.Errorf("was canceled: %w", err)
+	}
+	if p.timeout <= 0 && stdctx.Done() == nil {
+		out, err := guardedCall(ctx, p.fn.Interface(), p.fn, in)
+		putArgs(in)
+		return out, err
+	}
+
+	type result struct {
+		out   []reflect.Value
+		err   error
+		panic interface{}
+	}
+	// buffered so the constructor can finish after it has been given up on
+	done := make(chan result, 1)
+	go func() {
+		var res result
+		defer func() {
+			// panic in the caller's goroutine, as if the constructor had been
+			// called there
+			res.panic = recover()
+			done <- res
+		}()
+		res.out, res.err = guardedCall(ctx, p.fn.Interface(), p.fn, in)
+		putArgs(in)
+	}()
+
+	var expired <-chan time.Time
+	if p.timeout > 0 {
+		timer := time.NewTimer(p.timeout)
+		defer timer.Stop()
+		expired = timer.C
+	}
+	var res result
+	select {
+	case res = <-done:
+	case <-expired:
+		return nil, fmt.Errorf("%w after %v", ErrTimeout, p.timeout)
+	case <-stdctx.Done():
+		// a constructor which returned as the context was canceled still
+		// counts, such as one which canceled it
+		select {
+		case res = <-done:
+		default:
+			return nil, fmt.Errorf("was canceled: %w", stdctx.Err())
+		}
+	}
+	if res.panic != nil {
+		panic(res.panic)
+	}
+	return res.out, res.err
+}
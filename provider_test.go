@@ -0,0 +1,654 @@
+package di_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/mcvoid/di"
+)
+
+func TestProvide(t *testing.T) {
+	t.Run("provider is called with its dependencies", func(t *testing.T) {
+		ctx := di.New().Add(os.Stdin)
+		err := ctx.Provide(func(f *os.File) *bytes.Buffer {
+			if f != os.Stdin {
+				t.Errorf("expected %v got %v", os.Stdin, f)
+			}
+			return bytes.NewBufferString("provided")
+		})
+		if err != nil {
+			t.Errorf("expected %v got %v", nil, err)
+		}
+
+		wasCalled := false
+		err = ctx.Inject(func(b *bytes.Buffer) {
+			wasCalled = true
+			if b.String() != "provided" {
+				t.Errorf("expected %v got %v", "provided", b.String())
+			}
+		})
+		if !wasCalled {
+			t.Errorf("expected func to be called")
+		}
+		if err != nil {
+			t.Errorf("expected %v got %v", nil, err)
+		}
+	})
+
+	t.Run("provider matches interfaces", func(t *testing.T) {
+		ctx := di.New()
+		ctx.Provide(func() *bytes.Buffer { return bytes.NewBufferString("provided") })
+
+		err := ctx.Inject(func(r io.Reader) {
+			if r == nil {
+				t.Errorf("expected reader got %v", r)
+			}
+		})
+		if err != nil {
+			t.Errorf("expected %v got %v", nil, err)
+		}
+	})
+
+	t.Run("providers can depend on providers", func(t *testing.T) {
+		var ctx di.Context
+		ctx.Provide(func(b *bytes.Buffer) io.Reader { return b })
+		ctx.Provide(func() *bytes.Buffer { return bytes.NewBufferString("provided") })
+
+		err := ctx.Inject(func(r io.Reader) {
+			b, _ := io.ReadAll(r)
+			if string(b) != "provided" {
+				t.Errorf("expected %v got %v", "provided", string(b))
+			}
+		})
+		if err != nil {
+			t.Errorf("expected %v got %v", nil, err)
+		}
+	})
+
+	t.Run("provider error aborts injection", func(t *testing.T) {
+		errBoom := errors.New("boom")
+		ctx := di.New()
+		ctx.Provide(func() (*bytes.Buffer, error) { return nil, errBoom })
+
+		err := ctx.Inject(func(b *bytes.Buffer) {
+			t.Errorf("expected func to not be called")
+		})
+		if !errors.Is(err, errBoom) {
+			t.Errorf("expected %v got %v", errBoom, err)
+		}
+	})
+
+	t.Run("provider with nil error", func(t *testing.T) {
+		ctx := di.New()
+		ctx.Provide(func() (*bytes.Buffer, error) { return bytes.NewBufferString("provided"), nil })
+
+		wasCalled := false
+		err := ctx.Inject(func(b *bytes.Buffer) {
+			wasCalled = true
+			if b.String() != "provided" {
+				t.Errorf("expected %v got %v", "provided", b.String())
+			}
+		})
+		if !wasCalled {
+			t.Errorf("expected func to be called")
+		}
+		if err != nil {
+			t.Errorf("expected %v got %v", nil, err)
+		}
+	})
+
+	t.Run("second return value is not an error", func(t *testing.T) {
+		err := di.New().Provide(func() (*bytes.Buffer, int) { return nil, 0 })
+		if !errors.Is(err, di.ErrInvalidProvider) {
+			t.Errorf("expected %v got %v", di.ErrInvalidProvider, err)
+		}
+	})
+
+	t.Run("provider is only called once", func(t *testing.T) {
+		calls := 0
+		ctx := di.New()
+		ctx.Provide(func() *bytes.Buffer {
+			calls++
+			return &bytes.Buffer{}
+		})
+
+		var first, second *bytes.Buffer
+		ctx.Inject(func(b *bytes.Buffer) { first = b })
+		ctx.Inject(func(b *bytes.Buffer) { second = b })
+		if calls != 1 {
+			t.Errorf("expected %v got %v", 1, calls)
+		}
+		if first != second {
+			t.Errorf("expected %p got %p", first, second)
+		}
+	})
+
+	t.Run("provider is only called once concurrently", func(t *testing.T) {
+		var calls int32
+		ctx := di.New()
+		ctx.Provide(func() *bytes.Buffer {
+			atomic.AddInt32(&calls, 1)
+			return &bytes.Buffer{}
+		})
+
+		var wg sync.WaitGroup
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				ctx.Inject(func(b *bytes.Buffer) {})
+			}()
+		}
+		wg.Wait()
+		if calls != 1 {
+			t.Errorf("expected %v got %v", 1, calls)
+		}
+	})
+
+	t.Run("transient provider is called every time", func(t *testing.T) {
+		calls := 0
+		ctx := di.New()
+		ctx.Provide(func() *bytes.Buffer {
+			calls++
+			return &bytes.Buffer{}
+		}, di.Transient())
+
+		var first, second *bytes.Buffer
+		ctx.Inject(func(b *bytes.Buffer) { first = b })
+		ctx.Inject(func(b *bytes.Buffer) { second = b })
+		if calls != 2 {
+			t.Errorf("expected %v got %v", 2, calls)
+		}
+		if first == second {
+			t.Errorf("expected different values got %p", first)
+		}
+	})
+
+	t.Run("failed provider is retried", func(t *testing.T) {
+		calls := 0
+		ctx := di.New()
+		ctx.Provide(func() (*bytes.Buffer, error) {
+			calls++
+			if calls == 1 {
+				return nil, errors.New("boom")
+			}
+			return &bytes.Buffer{}, nil
+		})
+
+		if err := ctx.Inject(func(b *bytes.Buffer) {}); err == nil {
+			t.Errorf("expected err got %v", err)
+		}
+		if err := ctx.Inject(func(b *bytes.Buffer) {}); err != nil {
+			t.Errorf("expected %v got %v", nil, err)
+		}
+	})
+
+	t.Run("not a function", func(t *testing.T) {
+		err := di.New().Provide(os.Stdin)
+		if !errors.Is(err, di.ErrInvalidProvider) {
+			t.Errorf("expected %v got %v", di.ErrInvalidProvider, err)
+		}
+	})
+
+	t.Run("wrong number of return values", func(t *testing.T) {
+		err := di.New().Provide(func() {})
+		if !errors.Is(err, di.ErrInvalidProvider) {
+			t.Errorf("expected %v got %v", di.ErrInvalidProvider, err)
+		}
+	})
+
+	t.Run("nil provider", func(t *testing.T) {
+		err := di.New().Provide(nil)
+		if !errors.Is(err, di.ErrInvalidProvider) {
+			t.Errorf("expected %v got %v", di.ErrInvalidProvider, err)
+		}
+	})
+}
+
+func TestFinal(t *testing.T) {
+	t.Run("add of the same type fails", func(t *testing.T) {
+		b := &bytes.Buffer{}
+		ctx := di.New()
+		di.AddAs[*bytes.Buffer](ctx, b, di.Final())
+
+		ctx.Add(&bytes.Buffer{})
+		if err := ctx.Err(); !errors.Is(err, di.ErrFinal) {
+			t.Errorf("expected %v got %v", di.ErrFinal, err)
+		}
+		actual, _ := di.Resolve[*bytes.Buffer](ctx)
+		if actual != b {
+			t.Errorf("expected %p got %p", b, actual)
+		}
+	})
+
+	t.Run("provide of the same type fails", func(t *testing.T) {
+		ctx := di.New()
+		ctx.Provide(func() *bytes.Buffer { return &bytes.Buffer{} }, di.Final())
+
+		err := ctx.Provide(func() *bytes.Buffer { return nil })
+		if !errors.Is(err, di.ErrFinal) {
+			t.Errorf("expected %v got %v", di.ErrFinal, err)
+		}
+	})
+
+	t.Run("replace fails", func(t *testing.T) {
+		ctx := di.New()
+		di.AddAs[*bytes.Buffer](ctx, &bytes.Buffer{}, di.Final())
+
+		err := ctx.Replace(&bytes.Buffer{})
+		if !errors.Is(err, di.ErrFinal) {
+			t.Errorf("expected %v got %v", di.ErrFinal, err)
+		}
+	})
+
+	t.Run("remove fails", func(t *testing.T) {
+		b := &bytes.Buffer{}
+		ctx := di.New()
+		di.AddAs[*bytes.Buffer](ctx, b, di.Final())
+
+		ctx.Remove(b)
+		if err := ctx.Err(); !errors.Is(err, di.ErrFinal) {
+			t.Errorf("expected %v got %v", di.ErrFinal, err)
+		}
+		if di.Remove[*bytes.Buffer](ctx) {
+			t.Errorf("expected %v got %v", false, true)
+		}
+		if !di.Has[*bytes.Buffer](ctx) {
+			t.Errorf("expected %v got %v", true, false)
+		}
+	})
+
+	t.Run("other types are unaffected", func(t *testing.T) {
+		ctx := di.New()
+		di.AddAs[*bytes.Buffer](ctx, &bytes.Buffer{}, di.Final())
+
+		ctx.Add(os.Stdout).Add(os.Stderr)
+		if err := ctx.Err(); err != nil {
+			t.Errorf("expected %v got %v", nil, err)
+		}
+	})
+}
+
+type cycleA struct{}
+type cycleB struct{}
+type cycleC struct{}
+
+func TestCycle(t *testing.T) {
+	newCycle := func() *di.Context {
+		ctx := di.New()
+		ctx.Provide(func(b *cycleB) *cycleA { return &cycleA{} })
+		ctx.Provide(func(c *cycleC) *cycleB { return &cycleB{} })
+		ctx.Provide(func(a *cycleA) *cycleC { return &cycleC{} })
+		return ctx
+	}
+
+	t.Run("inject", func(t *testing.T) {
+		err := newCycle().Inject(func(a *cycleA) {
+			t.Errorf("expected func to not be called")
+		})
+		if !errors.Is(err, di.ErrCycle) {
+			t.Errorf("expected %v got %v", di.ErrCycle, err)
+		}
+		var cycle *di.CycleError
+		if !errors.As(err, &cycle) {
+			t.Fatalf("expected %T got %v", cycle, err)
+		}
+		expected := "*di_test.cycleA -> *di_test.cycleB -> *di_test.cycleC -> *di_test.cycleA"
+		if !strings.Contains(cycle.Error(), expected) {
+			t.Errorf("expected %v got %v", expected, cycle)
+		}
+	})
+
+	t.Run("self", func(t *testing.T) {
+		ctx := di.New()
+		ctx.Provide(func(a *cycleA) *cycleA { return &cycleA{} })
+
+		_, err := di.Resolve[*cycleA](ctx)
+		if !errors.Is(err, di.ErrCycle) {
+			t.Errorf("expected %v got %v", di.ErrCycle, err)
+		}
+	})
+
+	t.Run("validate", func(t *testing.T) {
+		err := newCycle().ValidateAll()
+		if !errors.Is(err, di.ErrCycle) {
+			t.Errorf("expected %v got %v", di.ErrCycle, err)
+		}
+	})
+
+	t.Run("concurrent injections from both ends", func(t *testing.T) {
+		// each holds the first provider it enters while resolving a slow
+		// dependency, so both are inside the cycle at once
+		slow := func() *strings.Builder {
+			time.Sleep(10 * time.Millisecond)
+			return &strings.Builder{}
+		}
+		for i := 0; i < 10; i++ {
+			ctx := di.New()
+			ctx.Provide(slow, di.Transient())
+			ctx.Provide(func(_ *strings.Builder, b *cycleB) *cycleA { return &cycleA{} })
+			ctx.Provide(func(_ *strings.Builder, a *cycleA) *cycleB { return &cycleB{} })
+			errs := make(chan error, 2)
+			go func() { _, err := di.Resolve[*cycleA](ctx); errs <- err }()
+			go func() { _, err := di.Resolve[*cycleB](ctx); errs <- err }()
+			for j := 0; j < 2; j++ {
+				select {
+				case err := <-errs:
+					if !errors.Is(err, di.ErrCycle) {
+						t.Fatalf("expected %v got %v", di.ErrCycle, err)
+					}
+				case <-time.After(time.Second):
+					t.Fatalf("expected %v got deadlock", di.ErrCycle)
+				}
+			}
+		}
+	})
+
+	t.Run("shared dependency is not a cycle", func(t *testing.T) {
+		ctx := di.New()
+		ctx.Provide(func() *cycleC { return &cycleC{} })
+		ctx.Provide(func(c *cycleC) *cycleB { return &cycleB{} })
+		ctx.Provide(func(b *cycleB, c *cycleC) *cycleA { return &cycleA{} })
+
+		_, err := di.Resolve[*cycleA](ctx)
+		if err != nil {
+			t.Errorf("expected %v got %v", nil, err)
+		}
+	})
+}
+
+func TestTimeout(t *testing.T) {
+	t.Run("slow provider fails the injection", func(t *testing.T) {
+		release := make(chan struct{})
+		defer close(release)
+		ctx := di.New()
+		ctx.Provide(func() *bytes.Buffer {
+			<-release
+			return &bytes.Buffer{}
+		}, di.Timeout(10*time.Millisecond))
+
+		err := ctx.Inject(func(*bytes.Buffer) {
+			t.Errorf("expected func to not be called")
+		})
+		if !errors.Is(err, di.ErrTimeout) {
+			t.Errorf("expected %v got %v", di.ErrTimeout, err)
+		}
+		if !strings.Contains(err.Error(), "provider for *bytes.Buffer timed out after 10ms") {
+			t.Errorf("expected provider to be named got %v", err)
+		}
+	})
+
+	t.Run("fast provider succeeds", func(t *testing.T) {
+		buf := &bytes.Buffer{}
+		ctx := di.New()
+		ctx.Provide(func() *bytes.Buffer { return buf }, di.Timeout(time.Minute))
+
+		got, err := di.Resolve[*bytes.Buffer](ctx)
+		if err != nil {
+			t.Errorf("expected %v got %v", nil, err)
+		}
+		if got != buf {
+			t.Errorf("expected %v got %v", buf, got)
+		}
+	})
+
+	t.Run("provider errors are returned", func(t *testing.T) {
+		errBoom := errors.New("boom")
+		ctx := di.New()
+		ctx.Provide(func() (*bytes.Buffer, error) { return nil, errBoom }, di.Timeout(time.Minute))
+
+		_, err := di.Resolve[*bytes.Buffer](ctx)
+		if !errors.Is(err, errBoom) {
+			t.Errorf("expected %v got %v", errBoom, err)
+		}
+	})
+
+	t.Run("provider is tried again after timing out", func(t *testing.T) {
+		var calls atomic.Int32
+		release := make(chan struct{})
+		ctx := di.New()
+		ctx.Provide(func() *bytes.Buffer {
+			if calls.Add(1) == 1 {
+				<-release
+			}
+			return &bytes.Buffer{}
+		}, di.Timeout(10*time.Millisecond))
+
+		if _, err := di.Resolve[*bytes.Buffer](ctx); !errors.Is(err, di.ErrTimeout) {
+			t.Errorf("expected %v got %v", di.ErrTimeout, err)
+		}
+		close(release)
+		if _, err := di.Resolve[*bytes.Buffer](ctx); err != nil {
+			t.Errorf("expected %v got %v", nil, err)
+		}
+		if calls.Load() != 2 {
+			t.Errorf("expected %v got %v", 2, calls.Load())
+		}
+	})
+}
+
+func TestRetry(t *testing.T) {
+	t.Run("provider succeeds after failing", func(t *testing.T) {
+		calls := 0
+		ctx := di.New()
+		ctx.Provide(func() (*bytes.Buffer, error) {
+			calls++
+			if calls < 3 {
+				return nil, errors.New("boom")
+			}
+			return &bytes.Buffer{}, nil
+		}, di.Retry(3, time.Millisecond))
+
+		_, err := di.Resolve[*bytes.Buffer](ctx)
+		if err != nil {
+			t.Errorf("expected %v got %v", nil, err)
+		}
+		if calls != 3 {
+			t.Errorf("expected %v got %v", 3, calls)
+		}
+	})
+
+	t.Run("last error is returned", func(t *testing.T) {
+		errBoom := errors.New("boom")
+		calls := 0
+		ctx := di.New()
+		ctx.Provide(func() (*bytes.Buffer, error) {
+			calls++
+			return nil, errBoom
+		}, di.Retry(3, time.Millisecond))
+
+		_, err := di.Resolve[*bytes.Buffer](ctx)
+		if !errors.Is(err, errBoom) {
+			t.Errorf("expected %v got %v", errBoom, err)
+		}
+		if !strings.Contains(err.Error(), "after 3 attempts") {
+			t.Errorf("expected attempts to be counted got %v", err)
+		}
+		if calls != 3 {
+			t.Errorf("expected %v got %v", 3, calls)
+		}
+	})
+
+	t.Run("timeouts are retried", func(t *testing.T) {
+		var calls atomic.Int32
+		release := make(chan struct{})
+		defer close(release)
+		ctx := di.New()
+		ctx.Provide(func() *bytes.Buffer {
+			if calls.Add(1) == 1 {
+				<-release
+			}
+			return &bytes.Buffer{}
+		}, di.Timeout(10*time.Millisecond), di.Retry(2, time.Millisecond))
+
+		_, err := di.Resolve[*bytes.Buffer](ctx)
+		if err != nil {
+			t.Errorf("expected %v got %v", nil, err)
+		}
+	})
+
+	t.Run("parameter failures aren't retried", func(t *testing.T) {
+		errBoom := errors.New("boom")
+		calls := 0
+		ctx := di.New()
+		ctx.Provide(func() (*strings.Builder, error) {
+			calls++
+			return nil, errBoom
+		})
+		ctx.Provide(func(*strings.Builder) *bytes.Buffer { return &bytes.Buffer{} }, di.Retry(3, time.Millisecond))
+
+		_, err := di.Resolve[*bytes.Buffer](ctx)
+		if !errors.Is(err, errBoom) {
+			t.Errorf("expected %v got %v", errBoom, err)
+		}
+		if calls != 1 {
+			t.Errorf("expected %v got %v", 1, calls)
+		}
+	})
+
+	t.Run("cancellation stops retrying", func(t *testing.T) {
+		errBoom := errors.New("boom")
+		stdctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		ctx := di.New()
+		ctx.Provide(func() (*bytes.Buffer, error) { return nil, errBoom }, di.Retry(3, time.Hour))
+
+		err := ctx.InjectContext(stdctx, func(*bytes.Buffer) {})
+		if !errors.Is(err, errBoom) {
+			t.Errorf("expected %v got %v", errBoom, err)
+		}
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("expected %v got %v", context.DeadlineExceeded, err)
+		}
+	})
+}
+
+func TestFallback(t *testing.T) {
+	errBoom := errors.New("boom")
+
+	t.Run("used when the provider fails", func(t *testing.T) {
+		fallbacks := 0
+		ctx := di.New()
+		ctx.Provide(func() (*bytes.Buffer, error) { return nil, errBoom }, di.Fallback(func() *bytes.Buffer {
+			fallbacks++
+			return bytes.NewBufferString("fallback")
+		}))
+
+		for i := 0; i < 2; i++ {
+			b, err := di.Resolve[*bytes.Buffer](ctx)
+			if err != nil {
+				t.Errorf("expected %v got %v", nil, err)
+			}
+			if b.String() != "fallback" {
+				t.Errorf("expected %v got %v", "fallback", b.String())
+			}
+		}
+		if fallbacks != 1 {
+			t.Errorf("expected %v got %v", 1, fallbacks)
+		}
+	})
+
+	t.Run("not used when the provider succeeds", func(t *testing.T) {
+		ctx := di.New()
+		ctx.Provide(func() *bytes.Buffer { return bytes.NewBufferString("primary") }, di.Fallback(func() *bytes.Buffer {
+			t.Errorf("expected fallback to not be called")
+			return nil
+		}))
+
+		b, err := di.Resolve[*bytes.Buffer](ctx)
+		if err != nil {
+			t.Errorf("expected %v got %v", nil, err)
+		}
+		if b.String() != "primary" {
+			t.Errorf("expected %v got %v", "primary", b.String())
+		}
+	})
+
+	t.Run("both errors are returned", func(t *testing.T) {
+		errFallback := errors.New("fallback")
+		ctx := di.New()
+		ctx.Provide(func() (*bytes.Buffer, error) { return nil, errBoom }, di.Fallback(func() (*bytes.Buffer, error) {
+			return nil, errFallback
+		}))
+
+		_, err := di.Resolve[*bytes.Buffer](ctx)
+		if !errors.Is(err, errBoom) || !errors.Is(err, errFallback) {
+			t.Errorf("expected %v and %v got %v", errBoom, errFallback, err)
+		}
+	})
+
+	t.Run("fallback can return an implementation", func(t *testing.T) {
+		ctx := di.New()
+		ctx.Provide(func() (io.Reader, error) { return nil, errBoom }, di.Fallback(func() *strings.Reader {
+			return strings.NewReader("fallback")
+		}))
+
+		r, err := di.Resolve[io.Reader](ctx)
+		if err != nil {
+			t.Errorf("expected %v got %v", nil, err)
+		}
+		if _, ok := r.(*strings.Reader); !ok {
+			t.Errorf("expected %T got %T", &strings.Reader{}, r)
+		}
+	})
+
+	t.Run("used while the breaker is open", func(t *testing.T) {
+		ctx := di.New()
+		ctx.Provide(func() (*bytes.Buffer, error) { return nil, errBoom }, di.Transient(), di.Breaker(1, time.Hour), di.Fallback(func() *bytes.Buffer {
+			return &bytes.Buffer{}
+		}))
+
+		for i := 0; i < 2; i++ {
+			if _, err := di.Resolve[*bytes.Buffer](ctx); err != nil {
+				t.Errorf("expected %v got %v", nil, err)
+			}
+		}
+	})
+
+	t.Run("invalid fallbacks are rejected", func(t *testing.T) {
+		ctx := di.New()
+		for _, fallback := range []interface{}{nil, "not a function", func() *strings.Reader { return nil }} {
+			err := ctx.Provide(func() *bytes.Buffer { return nil }, di.Fallback(fallback))
+			if !errors.Is(err, di.ErrInvalidProvider) {
+				t.Errorf("expected %v got %v", di.ErrInvalidProvider, err)
+			}
+		}
+		if di.Has[*bytes.Buffer](ctx) {
+			t.Errorf("expected provider to not be registered")
+		}
+	})
+
+	t.Run("validation accepts the fallback's parameters", func(t *testing.T) {
+		ctx := di.New(di.WithStrict()).Add(os.Stdout)
+		ctx.Provide(func(*strings.Builder) *bytes.Buffer { return nil }, di.Fallback(func(*os.File) *bytes.Buffer {
+			return &bytes.Buffer{}
+		}))
+
+		if err := ctx.ValidateAll(); err != nil {
+			t.Errorf("expected %v got %v", nil, err)
+		}
+		if _, err := di.Resolve[*bytes.Buffer](ctx); err != nil {
+			t.Errorf("expected %v got %v", nil, err)
+		}
+	})
+}
+
+func BenchmarkProvider(b *testing.B) {
+	ctx := di.New()
+	ctx.Provide(func() *bytes.Buffer { return &bytes.Buffer{} })
+	target := func(*bytes.Buffer) {}
+
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			ctx.Inject(target)
+		}
+	})
+}
@@ -0,0 +1,168 @@
+package di
+
+import (
+	"log/slog"
+	"reflect"
+)
+
+// binding is a target which is injected again when its dependencies are
+// replaced, made by InjectReactive or by Fill with the Reactive option.
+type binding struct {
+	target interface{}
+	// the registrations the target depends on
+	keys   []key
+	inject func() error
+}
+
+// Reactive makes Fill remember the target, and fill it again each time a registration one of its fields depends on is
+// replaced, such as by Reload, so that long-lived components stay current after a hot swap. See InjectReactive.
+func Reactive() FillOption {
+	return func(o *fillOptions) {
+		o.reactive = true
+	}
+}
+
+// InjectReactive is like Inject, but the Context remembers target, and injects it again each time a registration one
+// of its parameters depends on is replaced, whether by Add, Replace, Reload, or anything else which registers a
+// dependency. A Bind method can then swap its object's dependencies for the new ones:
+//
+//	ctx.InjectReactive(&server)
+//	...
+//	ctx.Reload(newConfig) // calls server.Bind again with the rebuilt dependencies
+//
+// Injections are made once the change is made, by the goroutine which made it, so an object used by other goroutines
+// must guard its own fields, such as by taking a lock in its Bind method. Errors from later injections are logged, and
+// the target is left as that injection left it. Nothing is remembered if the first injection fails.
+//
+// The Context holds on to target until Forget is called with it. Only registrations in the Context itself are watched,
+// not those in its parent or children.
+func (ctx *Context) InjectReactive(target interface{}) error {
+	fn, err := injectable(ctx, target)
+	if err != nil {
+		return err
+	}
+	if err := ctx.Inject(target); err != nil {
+		return err
+	}
+	t := fn.Type()
+	keys := []key{}
+	for i := 0; i < t.NumIn(); i++ {
+		keys = append(keys, paramKeys(t.In(i))...)
+	}
+	ctx.remember(&binding{target: target, keys: keys, inject: func() error { return ctx.Inject(target) }})
+	return nil
+}
+
+// Forget stops the Context from injecting target again, after InjectReactive or Fill with the Reactive option.
+func (ctx *Context) Forget(target interface{}) *Context {
+	ctx.lock.Lock()
+	defer ctx.lock.Unlock()
+
+	// copied, since the bindings may be being injected
+	kept := []*binding{}
+	for _, b := range ctx.bindings {
+		if !sameTarget(b.target, target) {
+			kept = append(kept, b)
+		}
+	}
+	ctx.bindings = kept
+	return ctx
+}
+
+// remember adds a binding to be injected again when its dependencies change.
+func (ctx *Context) remember(b *binding) {
+	ctx.lock.Lock()
+	defer ctx.lock.Unlock()
+
+	ctx.bindings = append(ctx.bindings, b)
+}
+
+// fieldKeys returns the registrations the fields of a struct type would be
+// filled from.
+func fieldKeys(t reflect.Type, o fillOptions) []key {
+	keys := []key{}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		opts, err := parseTag(field.Tag.Get(tagName))
+		if err != nil || opts.skip || opts.secret != "" {
+			continue
+		}
+		if opts.name != "" {
+			keys = append(keys, key{field.Type, opts.name})
+			continue
+		}
+		keys = append(keys, paramKeys(field.Type)...)
+		if o.recursive && field.Type.Kind() == reflect.Struct {
+			keys = append(keys, fieldKeys(field.Type, o)...)
+		}
+	}
+	return keys
+}
+
+// rebind schedules the bindings which depend on a replaced registration to
+// be injected again once the lock is released. Each is only injected once,
+// however many of its dependencies are replaced at a time.
+// The caller must hold ctx.lock.
+func (ctx *Context) rebind(k key) {
+	for _, b := range ctx.bindings {
+		if !b.dependsOn(k) || containsBinding(ctx.stale, b) {
+			continue
+		}
+		if len(ctx.stale) == 0 {
+			ctx.later(ctx.injectStale)
+		}
+		ctx.stale = append(ctx.stale, b)
+	}
+}
+
+// injectStale injects the bindings whose dependencies were replaced.
+func (ctx *Context) injectStale() {
+	ctx.lock.Lock()
+	stale := ctx.stale
+	ctx.stale = nil
+	ctx.lock.Unlock()
+
+	for _, b := range stale {
+		if err := b.inject(); err != nil {
+			logDebug(ctx, "di: reinjection failed", slog.String("target", describe(b.target, ctx.bindMethodName())), slog.String("error", err.Error()))
+		}
+	}
+}
+
+// dependsOn reports whether the binding would be injected with the
+// registration under k.
+func (b *binding) dependsOn(k key) bool {
+	for _, dep := range b.keys {
+		if dep.name != k.name {
+			continue
+		}
+		if dep.typ == k.typ || dep.typ.Kind() == reflect.Interface && k.typ.Implements(dep.typ) {
+			return true
+		}
+	}
+	return false
+}
+
+// containsBinding reports whether b is in bindings.
+func containsBinding(bindings []*binding, b *binding) bool {
+	for _, other := range bindings {
+		if other == b {
+			return true
+		}
+	}
+	return false
+}
+
+// sameTarget reports whether two injection targets are the same, including
+// functions, which can't be compared with ==.
+func sameTarget(a, b interface{}) bool {
+	va, vb := reflect.ValueOf(a), reflect.ValueOf(b)
+	if !va.IsValid() || !vb.IsValid() || va.Type() != vb.Type() {
+		return false
+	}
+	switch va.Kind() {
+	case reflect.Pointer, reflect.Func, reflect.Map, reflect.Slice, reflect.Chan, reflect.UnsafePointer:
+		return va.Pointer() == vb.Pointer()
+	}
+	return va.Type().Comparable() && a == b
+}
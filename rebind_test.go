@@ -0,0 +1,116 @@
+package di_test
+
+import (
+	"io"
+	"os"
+	"testing"
+
+	"github.com/mcvoid/di"
+)
+
+// a long-lived component which keeps its dependencies
+type reporter struct {
+	out   io.Writer
+	binds int
+}
+
+func (r *reporter) Bind(out io.Writer, log *logger) {
+	r.out = out
+	r.binds++
+}
+
+func TestInjectReactive(t *testing.T) {
+	t.Run("target is injected again when a dependency is replaced", func(t *testing.T) {
+		ctx := di.New().Add(os.Stdout)
+		r := &reporter{}
+		if err := ctx.InjectReactive(r); err != nil {
+			t.Fatalf("expected %v got %v", nil, err)
+		}
+
+		ctx.Add(os.Stderr)
+		if r.out != os.Stderr || r.binds != 2 {
+			t.Errorf("expected %v after %v binds got %v after %v", os.Stderr, 2, r.out, r.binds)
+		}
+	})
+
+	t.Run("other replacements are ignored", func(t *testing.T) {
+		ctx := di.New().Add(os.Stdout, "text")
+		r := &reporter{}
+		ctx.InjectReactive(r)
+		ctx.Add("more text")
+		if r.binds != 1 {
+			t.Errorf("expected %v got %v", 1, r.binds)
+		}
+	})
+
+	t.Run("reloads are injected once", func(t *testing.T) {
+		ctx := di.New().Add(os.Stdout, logConfig{Level: "info"})
+		ctx.Provide(func(cfg logConfig) *logger { return &logger{cfg.Level} })
+		r := &reporter{}
+		ctx.InjectReactive(r)
+
+		ctx.Reload(logConfig{Level: "debug"}, os.Stderr)
+		if r.out != os.Stderr || r.binds != 2 {
+			t.Errorf("expected %v after %v binds got %v after %v", os.Stderr, 2, r.out, r.binds)
+		}
+	})
+
+	t.Run("forgotten targets aren't injected", func(t *testing.T) {
+		ctx := di.New().Add(os.Stdout)
+		r := &reporter{}
+		ctx.InjectReactive(r)
+		ctx.Forget(r)
+		ctx.Add(os.Stderr)
+		if r.binds != 1 {
+			t.Errorf("expected %v got %v", 1, r.binds)
+		}
+	})
+
+	t.Run("functions can be targets", func(t *testing.T) {
+		ctx := di.New().Add(os.Stdout)
+		var got io.Writer
+		fn := func(w io.Writer) { got = w }
+		ctx.InjectReactive(fn)
+		ctx.Add(os.Stderr)
+		if got != os.Stderr {
+			t.Errorf("expected %v got %v", os.Stderr, got)
+		}
+		ctx.Forget(fn)
+		ctx.Add(os.Stdin)
+		if got != os.Stderr {
+			t.Errorf("expected %v got %v", os.Stderr, got)
+		}
+	})
+}
+
+func TestReactive(t *testing.T) {
+	t.Run("struct is filled again when a dependency is replaced", func(t *testing.T) {
+		ctx := di.New().AddNamed("replica", os.Stdin).Add(os.Stdout)
+		target := struct {
+			Out     io.Writer
+			Replica *os.File `di:"name=replica"`
+		}{}
+		if err := ctx.Fill(&target, di.Reactive()); err != nil {
+			t.Fatalf("expected %v got %v", nil, err)
+		}
+
+		ctx.Add(os.Stderr)
+		if target.Out != os.Stderr {
+			t.Errorf("expected %v got %v", os.Stderr, target.Out)
+		}
+		ctx.AddNamed("replica", os.Stdout)
+		if target.Replica != os.Stdout {
+			t.Errorf("expected %v got %v", os.Stdout, target.Replica)
+		}
+	})
+
+	t.Run("without the option the struct is left alone", func(t *testing.T) {
+		ctx := di.New().Add(os.Stdout)
+		target := struct{ Out io.Writer }{}
+		ctx.Fill(&target)
+		ctx.Add(os.Stderr)
+		if target.Out != os.Stdout {
+			t.Errorf("expected %v got %v", os.Stdout, target.Out)
+		}
+	})
+}
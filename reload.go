@@ -0,0 +1,205 @@
+package di
+
+import (
+	"fmt"
+	"log/slog"
+	"reflect"
+	"sort"
+)
+
+// Reload replaces registered values, typically configuration which has changed, and rebuilds every singleton provider
+// in the Context which depends on any of them, directly or through other dependencies, so that what they built
+// reflects the change without restarting:
+//
+//	ctx.Reload(LogConfig{Level: "debug"})
+//
+// Values are registered by their type, as with Add. Providers whose values had already been built are called again,
+// in the order they were first built, with their dependencies resolved from the new values; the others are reset, to
+// be called when they're needed. Nothing changes until every provider has been rebuilt: if any fails, its error is
+// returned and the Context is left as it was. Otherwise the new values all replace the old ones at once, so that an
+// injection sees either the old wiring or the new, never a mix, and the OnReload hooks are called.
+//
+// Injections which already got the old values keep them, and they aren't stopped. Dependents registered in child
+// Contexts aren't rebuilt. Reloading a dependency registered with the Final option is an error wrapping ErrFinal, and
+// reloading a frozen Context one wrapping ErrFrozen; both are found before any provider is called.
+func (ctx *Context) Reload(deps ...interface{}) error {
+	changed := map[key]*entry{}
+	for _, dep := range deps {
+		if dep != nil {
+			v := reflect.ValueOf(dep)
+			changed[key{typ: v.Type()}] = &entry{val: v}
+		}
+	}
+	if len(changed) == 0 {
+		return nil
+	}
+	// before building anything, since providers may have side effects
+	ctx.lock.RLock()
+	err := reloadable(ctx, changed)
+	ctx.lock.RUnlock()
+	if err != nil {
+		return err
+	}
+
+	// rebuild in a copy of the Context, so nothing is seen until it's done
+	scratch := ctx.Clone()
+	scratch.lock.Lock()
+	original := make(map[key]*entry, len(scratch.deps))
+	for k, e := range scratch.deps {
+		original[k] = e
+	}
+	for k, e := range changed {
+		scratch.deps[k] = e
+	}
+	affected := dependents(ctx, changed)
+	renewed := map[*provider]*provider{}
+	for _, k := range affected {
+		scratch.deps[k] = original[k].renew(renewed)
+	}
+	scratch.changed()
+	scratch.commit()
+
+	rebuild := []key{}
+	built := map[key]uint64{}
+	for _, k := range affected {
+		if original[k].prov == nil {
+			continue
+		}
+		if _, order, ok := original[k].prov.cached(); ok {
+			rebuild = append(rebuild, k)
+			built[k] = order
+		}
+	}
+	sort.Slice(rebuild, func(i, j int) bool { return built[rebuild[i]] < built[rebuild[j]] })
+	for _, k := range rebuild {
+		if _, err := scratch.deps[k].value(scratch, &resolution{}); err != nil {
+			return fmt.Errorf("reloading %s: %w", nodeID(k.typ, k.name), err)
+		}
+	}
+
+	ctx.lock.Lock()
+	defer ctx.commit()
+
+	// in case it changed while rebuilding
+	if err := reloadable(ctx, changed); err != nil {
+		return err
+	}
+	types := []reflect.Type{}
+	for k, e := range changed {
+		existing, ok := ctx.deps[k]
+		if ok {
+			e.seq, e.module = existing.seq, existing.module
+		} else {
+			e.seq = seq.Add(1)
+			ctx.added = append(ctx.added, k)
+		}
+		ctx.deps[k] = e
+		ctx.replaced(k, existing, e)
+		types = append(types, k.typ)
+	}
+	for _, k := range affected {
+		// unless it was changed while rebuilding
+		if ctx.deps[k] == original[k] {
+			ctx.deps[k] = scratch.deps[k]
+			ctx.replaced(k, original[k], ctx.deps[k])
+			types = append(types, k.typ)
+		}
+	}
+	sort.Slice(types, func(i, j int) bool { return types[i].String() < types[j].String() })
+	ctx.changed()
+
+	hooks := ctx.onReload
+	ctx.later(func() {
+		logDebug(ctx, "di: reloaded", slog.Int("changed", len(types)))
+		for _, hook := range hooks {
+			hook(types)
+		}
+	})
+	return nil
+}
+
+// reloadable checks that the changed registrations can be made in ctx.
+// The caller must hold ctx.lock.
+func reloadable(ctx *Context, changed map[key]*entry) error {
+	if ctx.frozen {
+		return fmt.Errorf("%w: cannot reload dependencies", ErrFrozen)
+	}
+	for k := range changed {
+		if existing, ok := ctx.deps[k]; ok && existing.final {
+			return fmt.Errorf("%w: cannot reload %v", ErrFinal, k.typ)
+		}
+	}
+	return nil
+}
+
+// OnReload registers a hook to be called each time Reload replaces dependencies, with the types of every registration
+// it replaced, including those of the rebuilt providers, so that components holding on to the old values can react.
+// Hooks are called in the order they were registered.
+func (ctx *Context) OnReload(hook func(changed []reflect.Type)) *Context {
+	ctx.lock.Lock()
+	defer ctx.lock.Unlock()
+
+	ctx.onReload = append(ctx.onReload, hook)
+	return ctx
+}
+
+// dependents returns the keys of the providers registered in ctx which
+// depend on any of the changed registrations, directly or not.
+func dependents(ctx *Context, changed map[key]*entry) []key {
+	g := buildGraph(ctx)
+	affected := map[string]bool{}
+	for k := range changed {
+		affected[nodeID(k.typ, k.name)] = true
+	}
+	for grew := true; grew; {
+		grew = false
+		for _, edge := range g.edges {
+			if affected[edge.to] && !affected[edge.from] {
+				affected[edge.from], grew = true, true
+			}
+		}
+	}
+
+	keys := []key{}
+	for k, e := range ctx.load().deps {
+		if _, ok := changed[k]; !ok && e.prov != nil && affected[nodeID(k.typ, k.name)] {
+			keys = append(keys, k)
+		}
+	}
+	return keys
+}
+
+// renew copies a registration with providers which haven't built anything,
+// so it can be rebuilt without changing the original. Providers shared by
+// several registrations, such as those returning Out structs, stay shared.
+func (e *entry) renew(renewed map[*provider]*provider) *entry {
+	fresh := *e
+	if e.prov != nil {
+		fresh.prov = e.prov.renew(renewed)
+	}
+	if e.off != nil {
+		fresh.off = e.off.renew(renewed)
+	}
+	return &fresh
+}
+
+// renew returns a copy of a singleton provider without its value.
+func (p *provider) renew(renewed map[*provider]*provider) *provider {
+	if p.lifetime == transient {
+		return p
+	}
+	if fresh, ok := renewed[p]; ok {
+		return fresh
+	}
+	fresh := &provider{
+		fn:       p.fn,
+		lifetime: p.lifetime,
+		timeout:  p.timeout,
+		attempts: p.attempts,
+		backoff:  p.backoff,
+		breaker:  p.breaker,
+		fallback: p.fallback,
+	}
+	renewed[p] = fresh
+	return fresh
+}
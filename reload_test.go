@@ -0,0 +1,132 @@
+package di_test
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/mcvoid/di"
+)
+
+type logConfig struct {
+	Level string
+}
+
+// a dependency built from the configuration
+type logger struct {
+	level string
+}
+
+// a dependency built from one built from the configuration
+type service struct {
+	log *logger
+}
+
+func TestReload(t *testing.T) {
+	wire := func(calls map[string]int) *di.Context {
+		ctx := di.New().Add(logConfig{Level: "info"})
+		ctx.Provide(func(cfg logConfig) (*logger, error) {
+			calls["logger"]++
+			if cfg.Level == "" {
+				return nil, errors.New("no level")
+			}
+			return &logger{cfg.Level}, nil
+		})
+		ctx.Provide(func(l *logger) *service {
+			calls["service"]++
+			return &service{l}
+		})
+		ctx.Provide(func() *strings.Builder {
+			calls["builder"]++
+			return &strings.Builder{}
+		})
+		return ctx
+	}
+
+	t.Run("dependents are rebuilt", func(t *testing.T) {
+		calls := map[string]int{}
+		ctx := wire(calls)
+		ctx.Inject(func(*service, *strings.Builder) {})
+
+		if err := ctx.Reload(logConfig{Level: "debug"}); err != nil {
+			t.Fatalf("expected %v got %v", nil, err)
+		}
+		if calls["logger"] != 2 || calls["service"] != 2 {
+			t.Errorf("expected providers to be called again got %v", calls)
+		}
+		if calls["builder"] != 1 {
+			t.Errorf("expected %v got %v", 1, calls["builder"])
+		}
+		if s := di.MustResolve[*service](ctx); s.log.level != "debug" {
+			t.Errorf("expected %v got %v", "debug", s.log.level)
+		}
+	})
+
+	t.Run("providers which weren't built are left until they're needed", func(t *testing.T) {
+		calls := map[string]int{}
+		ctx := wire(calls)
+		if err := ctx.Reload(logConfig{Level: "debug"}); err != nil {
+			t.Fatalf("expected %v got %v", nil, err)
+		}
+		if calls["logger"] != 0 {
+			t.Errorf("expected %v got %v", 0, calls["logger"])
+		}
+		if l := di.MustResolve[*logger](ctx); l.level != "debug" {
+			t.Errorf("expected %v got %v", "debug", l.level)
+		}
+	})
+
+	t.Run("nothing changes if a provider fails", func(t *testing.T) {
+		calls := map[string]int{}
+		ctx := wire(calls)
+		before := di.MustResolve[*service](ctx)
+
+		if err := ctx.Reload(logConfig{}); err == nil {
+			t.Errorf("expected an error got %v", err)
+		}
+		if cfg := di.MustResolve[logConfig](ctx); cfg.Level != "info" {
+			t.Errorf("expected %v got %v", "info", cfg.Level)
+		}
+		if s := di.MustResolve[*service](ctx); s != before {
+			t.Errorf("expected %v got %v", before, s)
+		}
+	})
+
+	t.Run("hooks get the changed types", func(t *testing.T) {
+		calls := map[string]int{}
+		ctx := wire(calls)
+		ctx.Inject(func(*service) {})
+
+		got := []reflect.Type{}
+		ctx.OnReload(func(changed []reflect.Type) { got = changed })
+		ctx.Reload(logConfig{Level: "debug"})
+
+		expected := []reflect.Type{reflect.TypeOf(&logger{}), reflect.TypeOf(&service{}), reflect.TypeOf(logConfig{})}
+		if !reflect.DeepEqual(got, expected) {
+			t.Errorf("expected %v got %v", expected, got)
+		}
+	})
+
+	t.Run("frozen contexts don't rebuild anything", func(t *testing.T) {
+		calls := map[string]int{}
+		ctx := wire(calls)
+		ctx.Inject(func(*service) {})
+		ctx.Freeze()
+
+		if err := ctx.Reload(logConfig{Level: "debug"}); !errors.Is(err, di.ErrFrozen) {
+			t.Errorf("expected %v got %v", di.ErrFrozen, err)
+		}
+		if calls["logger"] != 1 || calls["service"] != 1 {
+			t.Errorf("expected providers to not be called again got %v", calls)
+		}
+	})
+
+	t.Run("final dependencies can't be reloaded", func(t *testing.T) {
+		ctx := di.New()
+		di.AddAs[logConfig](ctx, logConfig{}, di.Final())
+		if err := ctx.Reload(logConfig{Level: "debug"}); !errors.Is(err, di.ErrFinal) {
+			t.Errorf("expected %v got %v", di.ErrFinal, err)
+		}
+	})
+}
@@ -0,0 +1,244 @@
+package di
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+)
+
+// Resolve returns a single dependency from the Context, using the same rules as Inject does for a parameter of type T.
+// It is equivalent to injecting into a function which takes a T and returning it.
+func Resolve[T any](ctx *Context) (T, error) {
+	var out T
+
+	r := newResolution()
+	defer r.release()
+	beforeResolve(ctx, r, typeOf[T]())
+	val, err := resolve(ctx, r, typeOf[T]())
+	if err == nil && !val.IsValid() {
+		val, err = missing(ctx, r, typeOf[T](), -1)
+	}
+	afterResolve(ctx, r, typeOf[T](), val, err)
+	if err != nil {
+		return out, err
+	}
+
+	// set through reflection, since a zero interface value can't be type asserted
+	reflect.ValueOf(&out).Elem().Set(val)
+	return out, nil
+}
+
+// MustResolve is like Resolve but panics if the dependency can't be resolved.
+func MustResolve[T any](ctx *Context) T {
+	out, err := Resolve[T](ctx)
+	if err != nil {
+		panic(err)
+	}
+	return out
+}
+
+// Call injects the Context into fn, like Invoke, and returns fn's first return value as a T. This makes it easy to
+// construct an object from its dependencies with compile-time typing:
+//
+//	srv, err := di.Call[*Server](ctx, NewServer)
+//
+// fn may be a function or a value with a Bind method. If its first return value can't be assigned to a T, an error
+// wrapping ErrResultType is returned without calling it. If fn returns an error, it is returned as with Inject.
+func Call[T any](ctx *Context, fn interface{}) (T, error) {
+	var out T
+
+	f, err := injectable(ctx, fn)
+	if err != nil {
+		return out, err
+	}
+	t := f.Type()
+	if t.NumOut() < 1 || !t.Out(0).AssignableTo(typeOf[T]()) {
+		return out, fmt.Errorf("%w: %v", ErrResultType, t)
+	}
+
+	results, err := injectFunc(ctx, newResolution(), fn, f, ctx.bindMethodName())
+	if results == nil {
+		return out, err
+	}
+	reflect.ValueOf(&out).Elem().Set(results[0])
+	return out, err
+}
+
+// Has reports whether the Context has a dependency which would be injected into a parameter of type T: either one
+// registered as exactly T, or, if T is an interface, at least one which implements it. A slice of interfaces has one if
+// any dependency implements the interface, and a map keyed by strings if any named dependency would be in it. Providers
+// are not called.
+func Has[T any](ctx *Context) bool {
+	return ctx.Contains(typeOf[T]())
+}
+
+// Contains is like Has, for a type known only at runtime.
+func (ctx *Context) Contains(t reflect.Type) bool {
+	name := ""
+	if t.Implements(qualifiedType) {
+		t, name = reflect.Zero(t).Interface().(qualified).qualifier()
+	}
+
+	for ; ctx != nil; ctx = ctx.parent {
+		if contains(ctx, t, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// contains reports whether ctx itself, ignoring its parent, has a dependency
+// for the type and name.
+func contains(ctx *Context, t reflect.Type, name string) bool {
+	s := ctx.load()
+	if _, ok := s.deps[key{t, name}]; ok {
+		return true
+	}
+	// as with resolveNamed, a slice of interfaces gets every implementation,
+	// and a map keyed by strings every named dependency
+	if t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Interface {
+		t = t.Elem()
+	}
+	if name == "" && t.Kind() == reflect.Map && t.Key().Kind() == reflect.String {
+		for k := range s.deps {
+			if k.name != "" && k.typ.AssignableTo(t.Elem()) {
+				return true
+			}
+		}
+		return false
+	}
+	if t.Kind() != reflect.Interface {
+		return false
+	}
+	for _, k := range implementers(s, t) {
+		if k.name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Remove unregisters the dependency registered as exactly T, reporting whether there was one. A dependency registered
+// with AddAs is removed under both its types. Nothing is removed from a frozen Context, and final dependencies are never
+// removed.
+func Remove[T any](ctx *Context) bool {
+	ctx.lock.Lock()
+	defer ctx.commit()
+
+	if ctx.frozen {
+		return false
+	}
+
+	e, ok := ctx.deps[key{typ: typeOf[T]()}]
+	if !ok || e.final {
+		return false
+	}
+	unregister(ctx, e)
+	return true
+}
+
+// Prefer breaks ties between dependencies implementing the interface T. When a parameter of type T matches more than
+// one dependency, the one registered under the same type as dep is used instead of returning an error. Dependencies of
+// other types are still registered, and still used for parameters which match them exactly.
+//
+//	ctx.Add(os.Stdout, logFile)
+//	di.Prefer[io.Writer](ctx, logFile)
+//
+// Preferences only apply to dependencies in the same Context. Setting a preference on a frozen Context is an error
+// wrapping ErrFrozen.
+func Prefer[T any](ctx *Context, dep T) error {
+	ctx.lock.Lock()
+	defer ctx.commit()
+
+	if ctx.frozen {
+		return fmt.Errorf("%w: cannot set preference", ErrFrozen)
+	}
+	if ctx.prefs == nil {
+		ctx.prefs = map[reflect.Type]reflect.Type{}
+	}
+	ctx.prefs[typeOf[T]()] = reflect.TypeOf(dep)
+	ctx.changed()
+	return nil
+}
+
+// typeOf returns the reflect.Type of T, even when T is an interface.
+func typeOf[T any]() reflect.Type {
+	return reflect.TypeOf((*T)(nil)).Elem()
+}
+
+// resolveAside resolves a dependency the Context itself needs, such as the
+// FeatureFlags, which mustn't be explained or compiled as though it were the
+// parameter being resolved. It is an error if there is none.
+func resolveAside(ctx *Context, r *resolution, t reflect.Type) (reflect.Value, error) {
+	aside := &resolution{validate: r.validate, stdctx: r.stdctx, passContext: r.passContext, progress: r.progress}
+	aside.building = append(aside.building, r.building...)
+	val, err := resolve(ctx, aside, t)
+	if err == nil && !val.IsValid() {
+		err = fmt.Errorf("%w: %v", ErrNotRegistered, t)
+	}
+	return val, err
+}
+
+// Registration describes a dependency registered in a Context, as returned by Registrations.
+type Registration struct {
+	// The type the dependency is registered under
+	Type reflect.Type
+	// The name the dependency is registered under, if any
+	Name string
+	// Whether the dependency is constructed by a provider
+	Provided bool
+	// Whether the provider constructs a new value every time
+	Transient bool
+	// Whether a singleton provider has already constructed its value
+	Built bool
+	// Whether the registration was made with the Final option
+	Final bool
+	// Whether the dependency is registered in an ancestor of the Context rather than the Context itself
+	Inherited bool
+	// The name of the module which registered the dependency, if it was registered by a module passed to Use
+	Module string
+	// The feature flag the dependency is behind, if it was registered with the WhenFlag option
+	Flag string
+}
+
+// Types returns every type a dependency is registered under in the Context or its ancestors, sorted by name, without
+// duplicates. Together with Contains and Has, this lets frameworks built on the Context discover what is available.
+func (ctx *Context) Types() []reflect.Type {
+	types := []reflect.Type{}
+	for _, r := range ctx.Registrations() {
+		if len(types) == 0 || types[len(types)-1] != r.Type {
+			types = append(types, r.Type)
+		}
+	}
+	return types
+}
+
+// Registrations describes every dependency registered in the Context or its ancestors, sorted by type and then name.
+// Registrations in an ancestor which are shadowed by one in a descendant are left out. No providers are called.
+func (ctx *Context) Registrations() []Registration {
+	regs := []Registration{}
+	seen := map[key]bool{}
+	for c := ctx; c != nil; c = c.parent {
+		for k, e := range c.load().deps {
+			if seen[k] {
+				continue
+			}
+			seen[k] = true
+
+			r := Registration{Type: k.typ, Name: k.name, Final: e.final, Inherited: c != ctx, Module: e.module, Flag: e.flag}
+			if e.prov != nil {
+				_, _, built := e.prov.cached()
+				r.Provided, r.Transient, r.Built = true, e.prov.lifetime == transient, built
+			}
+			regs = append(regs, r)
+		}
+	}
+
+	sort.Slice(regs, func(i, j int) bool {
+		if regs[i].Type != regs[j].Type {
+			return regs[i].Type.String() < regs[j].Type.String()
+		}
+		return regs[i].Name < regs[j].Name
+	})
+	return regs
+}
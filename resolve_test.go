@@ -0,0 +1,309 @@
+package di_test
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/mcvoid/di"
+)
+
+func TestResolve(t *testing.T) {
+	t.Run("exact type match", func(t *testing.T) {
+		ctx := di.New().Add(os.Stdin)
+
+		f, err := di.Resolve[*os.File](ctx)
+		if f != os.Stdin {
+			t.Errorf("expected %v got %v", os.Stdin, f)
+		}
+		if err != nil {
+			t.Errorf("expected %v got %v", nil, err)
+		}
+	})
+
+	t.Run("interface match", func(t *testing.T) {
+		ctx := di.New().Add(os.Stdin)
+
+		r, err := di.Resolve[io.Reader](ctx)
+		if r != os.Stdin {
+			t.Errorf("expected %v got %v", os.Stdin, r)
+		}
+		if err != nil {
+			t.Errorf("expected %v got %v", nil, err)
+		}
+	})
+
+	t.Run("no match", func(t *testing.T) {
+		var ctx di.Context
+
+		r, err := di.Resolve[io.Reader](&ctx)
+		if r != nil {
+			t.Errorf("expected %v got %v", nil, r)
+		}
+		if err != nil {
+			t.Errorf("expected %v got %v", nil, err)
+		}
+	})
+
+	t.Run("ambiguous match", func(t *testing.T) {
+		ctx := di.New().Add(os.Stdout).Add(&bytes.Buffer{})
+
+		_, err := di.Resolve[io.Writer](ctx)
+		if !errors.Is(err, di.ErrAmbiguous) {
+			t.Errorf("expected %v got %v", di.ErrAmbiguous, err)
+		}
+	})
+
+	t.Run("provided value", func(t *testing.T) {
+		b := &bytes.Buffer{}
+		ctx := di.New()
+		ctx.Provide(func() *bytes.Buffer { return b })
+
+		got, err := di.Resolve[*bytes.Buffer](ctx)
+		if got != b {
+			t.Errorf("expected %p got %p", b, got)
+		}
+		if err != nil {
+			t.Errorf("expected %v got %v", nil, err)
+		}
+	})
+}
+
+func TestMustResolve(t *testing.T) {
+	t.Run("returns the value", func(t *testing.T) {
+		ctx := di.New().Add(os.Stdin)
+
+		r := di.MustResolve[io.Reader](ctx)
+		if r != os.Stdin {
+			t.Errorf("expected %v got %v", os.Stdin, r)
+		}
+	})
+
+	t.Run("panics on error", func(t *testing.T) {
+		ctx := di.New().Add(os.Stdout).Add(&bytes.Buffer{})
+
+		defer func() {
+			val := recover()
+			err, _ := val.(error)
+			if !errors.Is(err, di.ErrAmbiguous) {
+				t.Errorf("expected %v got %v", di.ErrAmbiguous, val)
+			}
+		}()
+		di.MustResolve[io.Writer](ctx)
+	})
+}
+
+func TestHas(t *testing.T) {
+	t.Run("exact type", func(t *testing.T) {
+		ctx := di.New().Add(os.Stdin)
+		if !di.Has[*os.File](ctx) {
+			t.Errorf("expected %v got %v", true, false)
+		}
+	})
+
+	t.Run("interface", func(t *testing.T) {
+		ctx := di.New().Add(os.Stdin)
+		if !di.Has[io.Reader](ctx) {
+			t.Errorf("expected %v got %v", true, false)
+		}
+	})
+
+	t.Run("groups and maps", func(t *testing.T) {
+		ctx := di.New()
+		if di.Has[[]io.Reader](ctx) || di.Has[map[string]io.Reader](ctx) {
+			t.Errorf("expected %v got %v", false, true)
+		}
+		ctx.Add(os.Stdin)
+		if !di.Has[[]io.Reader](ctx) {
+			t.Errorf("expected %v got %v", true, false)
+		}
+		if di.Has[map[string]io.Reader](ctx) {
+			t.Errorf("expected %v got %v", false, true)
+		}
+		ctx.AddNamed("replica", &bytes.Buffer{})
+		if !di.Has[map[string]io.Reader](ctx.Child()) {
+			t.Errorf("expected %v got %v", true, false)
+		}
+	})
+
+	t.Run("missing", func(t *testing.T) {
+		var ctx di.Context
+		if di.Has[*bytes.Buffer](&ctx) {
+			t.Errorf("expected %v got %v", false, true)
+		}
+		if di.Has[io.Reader](&ctx) {
+			t.Errorf("expected %v got %v", false, true)
+		}
+	})
+
+	t.Run("provider is not called", func(t *testing.T) {
+		ctx := di.New()
+		ctx.Provide(func() *bytes.Buffer {
+			t.Errorf("expected provider to not be called")
+			return nil
+		})
+		if !di.Has[*bytes.Buffer](ctx) {
+			t.Errorf("expected %v got %v", true, false)
+		}
+	})
+
+	t.Run("reflect type", func(t *testing.T) {
+		ctx := di.New().Add(os.Stdin)
+		if !ctx.Contains(reflect.TypeOf(os.Stdin)) {
+			t.Errorf("expected %v got %v", true, false)
+		}
+	})
+}
+
+func TestRemoveType(t *testing.T) {
+	t.Run("removes the registration", func(t *testing.T) {
+		ctx := di.New().Add(os.Stdin)
+		if !di.Remove[*os.File](ctx) {
+			t.Errorf("expected %v got %v", true, false)
+		}
+		if di.Has[*os.File](ctx) {
+			t.Errorf("expected dependency to be removed")
+		}
+	})
+
+	t.Run("removes providers", func(t *testing.T) {
+		ctx := di.New()
+		ctx.Provide(func() *bytes.Buffer { return &bytes.Buffer{} })
+		if !di.Remove[*bytes.Buffer](ctx) {
+			t.Errorf("expected %v got %v", true, false)
+		}
+		if di.Has[*bytes.Buffer](ctx) {
+			t.Errorf("expected dependency to be removed")
+		}
+	})
+
+	t.Run("nothing to remove", func(t *testing.T) {
+		var ctx di.Context
+		if di.Remove[*os.File](&ctx) {
+			t.Errorf("expected %v got %v", false, true)
+		}
+	})
+}
+
+func TestCall(t *testing.T) {
+	t.Run("returns the first result", func(t *testing.T) {
+		ctx := di.New().Add(os.Stdin)
+
+		r, err := di.Call[io.Reader](ctx, func(f *os.File) *os.File { return f })
+		if r != os.Stdin {
+			t.Errorf("expected %v got %v", os.Stdin, r)
+		}
+		if err != nil {
+			t.Errorf("expected %v got %v", nil, err)
+		}
+	})
+
+	t.Run("wrong result type", func(t *testing.T) {
+		ctx := di.New().Add(os.Stdin)
+
+		_, err := di.Call[*bytes.Buffer](ctx, func(f *os.File) *os.File {
+			t.Errorf("expected func to not be called")
+			return f
+		})
+		if !errors.Is(err, di.ErrResultType) {
+			t.Errorf("expected %v got %v", di.ErrResultType, err)
+		}
+	})
+
+	t.Run("no results", func(t *testing.T) {
+		_, err := di.Call[*bytes.Buffer](di.New(), func() {})
+		if !errors.Is(err, di.ErrResultType) {
+			t.Errorf("expected %v got %v", di.ErrResultType, err)
+		}
+	})
+}
+
+func TestPrefer(t *testing.T) {
+	t.Run("preferred dependency breaks the tie", func(t *testing.T) {
+		b := &bytes.Buffer{}
+		ctx := di.New().Add(os.Stdout, b)
+		err := di.Prefer[io.Writer](ctx, b)
+		if err != nil {
+			t.Errorf("expected %v got %v", nil, err)
+		}
+
+		w, err := di.Resolve[io.Writer](ctx)
+		if w != b {
+			t.Errorf("expected %p got %v", b, w)
+		}
+		if err != nil {
+			t.Errorf("expected %v got %v", nil, err)
+		}
+	})
+
+	t.Run("exact matches are unaffected", func(t *testing.T) {
+		ctx := di.New().Add(os.Stdout, &bytes.Buffer{})
+		di.Prefer[io.Writer](ctx, &bytes.Buffer{})
+
+		f, _ := di.Resolve[*os.File](ctx)
+		if f != os.Stdout {
+			t.Errorf("expected %v got %v", os.Stdout, f)
+		}
+	})
+
+	t.Run("preference that isn't registered", func(t *testing.T) {
+		ctx := di.New().Add(os.Stdout, &bytes.Buffer{})
+		di.Prefer[io.Writer](ctx, &strings.Builder{})
+
+		_, err := di.Resolve[io.Writer](ctx)
+		if !errors.Is(err, di.ErrAmbiguous) {
+			t.Errorf("expected %v got %v", di.ErrAmbiguous, err)
+		}
+	})
+
+	t.Run("frozen", func(t *testing.T) {
+		ctx := di.New().Freeze()
+
+		err := di.Prefer[io.Writer](ctx, os.Stdout)
+		if !errors.Is(err, di.ErrFrozen) {
+			t.Errorf("expected %v got %v", di.ErrFrozen, err)
+		}
+	})
+}
+
+func TestRegistrations(t *testing.T) {
+	t.Run("metadata", func(t *testing.T) {
+		parent := di.New().Add(os.Stdout, &bytes.Buffer{})
+		ctx := parent.Child().Add(os.Stderr).AddNamed("out", os.Stdout)
+		ctx.Provide(func() *strings.Builder { return &strings.Builder{} }, di.Transient())
+		ctx.Provide(func() *strings.Reader { return strings.NewReader("") }, di.Final())
+		di.Resolve[*strings.Reader](ctx)
+
+		expected := []di.Registration{
+			{Type: reflect.TypeOf(&bytes.Buffer{}), Inherited: true},
+			{Type: reflect.TypeOf(os.Stdout)},
+			{Type: reflect.TypeOf(os.Stdout), Name: "out"},
+			{Type: reflect.TypeOf(&strings.Builder{}), Provided: true, Transient: true},
+			{Type: reflect.TypeOf(&strings.Reader{}), Provided: true, Built: true, Final: true},
+		}
+		actual := ctx.Registrations()
+		if !reflect.DeepEqual(actual, expected) {
+			t.Errorf("expected %v got %v", expected, actual)
+		}
+	})
+
+	t.Run("types", func(t *testing.T) {
+		ctx := di.New().Add(os.Stdout, &bytes.Buffer{}).AddNamed("out", os.Stdout)
+
+		expected := []reflect.Type{reflect.TypeOf(&bytes.Buffer{}), reflect.TypeOf(os.Stdout)}
+		actual := ctx.Types()
+		if !reflect.DeepEqual(actual, expected) {
+			t.Errorf("expected %v got %v", expected, actual)
+		}
+	})
+
+	t.Run("empty", func(t *testing.T) {
+		if types := di.New().Types(); len(types) != 0 {
+			t.Errorf("expected %v got %v", 0, len(types))
+		}
+	})
+}
@@ -0,0 +1,65 @@
+package di
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+// Schedule decides when a job run by Context.Schedule runs next. Cron expressions and the like can be supported by
+// implementing it.
+type Schedule interface {
+	// Next returns the time to run the job after the given time, or the zero time to stop running it.
+	Next(after time.Time) time.Time
+}
+
+// Interval is a Schedule which runs a job at a fixed interval.
+type Interval time.Duration
+
+// Next returns after plus the interval.
+func (i Interval) Next(after time.Time) time.Time {
+	return after.Add(time.Duration(i))
+}
+
+// Every runs target every interval, injecting it afresh each time, so that periodic jobs such as refreshing caches and
+// flushing metrics can be declared next to the wiring:
+//
+//	ctx.Every(time.Minute, func(stdctx context.Context, cache *Cache, db *sql.DB) error {
+//		return cache.Refresh(stdctx, db)
+//	})
+//
+// It is equivalent to calling Schedule with an Interval.
+func (ctx *Context) Every(interval time.Duration, target interface{}) (stop func(), err error) {
+	return ctx.Schedule(Interval(interval), target)
+}
+
+// Schedule runs target at the times given by s, injecting it each time as InjectContext does, with a context.Context
+// which is canceled once the job is stopped. Errors injecting target, or returned by it, are logged, and the job keeps
+// running. A run which takes longer than the time to the next one delays it rather than overlapping it.
+//
+// The job runs in a goroutine started with Go, so Wait waits for it, until it's stopped, either by calling the returned
+// function or by Stop. Both wait for a run in progress to finish, so they mustn't be called from target itself.
+//
+// If s is an Interval which isn't positive, which would run the job over and over without pause, an error wrapping
+// ErrInterval is returned and the job isn't started.
+func (ctx *Context) Schedule(s Schedule, target interface{}) (stop func(), err error) {
+	if i, ok := s.(Interval); ok && i <= 0 {
+		return nil, fmt.Errorf("%w: %v", ErrInterval, time.Duration(i))
+	}
+	return ctx.background(func(stdctx context.Context) error {
+		for next := s.Next(time.Now()); !next.IsZero(); next = s.Next(time.Now()) {
+			timer := time.NewTimer(time.Until(next))
+			select {
+			case <-stdctx.Done():
+				timer.Stop()
+				return nil
+			case <-timer.C:
+			}
+			if err := ctx.InjectContext(stdctx, target); err != nil {
+				logDebug(ctx, "di: scheduled job failed", slog.String("target", describe(target, ctx.bindMethodName())), slog.String("error", err.Error()))
+			}
+		}
+		return nil
+	}), nil
+}
@@ -0,0 +1,104 @@
+package di_test
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/mcvoid/di"
+)
+
+// a Schedule which runs a job a fixed number of times
+type times struct {
+	left int
+}
+
+func (s *times) Next(after time.Time) time.Time {
+	if s.left == 0 {
+		return time.Time{}
+	}
+	s.left--
+	return after
+}
+
+func TestSchedule(t *testing.T) {
+	t.Run("jobs are injected on every tick", func(t *testing.T) {
+		ctx := di.New().Add(logConfig{Level: "info"})
+		var runs atomic.Int32
+		ticked := make(chan struct{}, 10)
+		stop, err := ctx.Every(time.Millisecond, func(cfg logConfig) {
+			if cfg.Level != "info" {
+				t.Errorf("expected %v got %v", "info", cfg.Level)
+			}
+			runs.Add(1)
+			ticked <- struct{}{}
+		})
+		if err != nil {
+			t.Fatalf("expected %v got %v", nil, err)
+		}
+		<-ticked
+		<-ticked
+		stop()
+
+		after := runs.Load()
+		if after < 2 {
+			t.Errorf("expected at least %v got %v", 2, after)
+		}
+		time.Sleep(5 * time.Millisecond)
+		if runs.Load() != after {
+			t.Errorf("expected %v got %v", after, runs.Load())
+		}
+	})
+
+	t.Run("jobs stop when the schedule ends", func(t *testing.T) {
+		ctx := di.New()
+		var runs atomic.Int32
+		ctx.Schedule(&times{left: 3}, func() error {
+			runs.Add(1)
+			return errors.New("failures don't stop the job")
+		})
+		if err := ctx.Wait(); err != nil {
+			t.Errorf("expected %v got %v", nil, err)
+		}
+		if runs.Load() != 3 {
+			t.Errorf("expected %v got %v", 3, runs.Load())
+		}
+	})
+
+	t.Run("intervals must be positive", func(t *testing.T) {
+		ctx := di.New()
+		for _, interval := range []time.Duration{0, -time.Second} {
+			if _, err := ctx.Every(interval, func() {}); !errors.Is(err, di.ErrInterval) {
+				t.Errorf("expected %v got %v", di.ErrInterval, err)
+			}
+		}
+		if _, err := ctx.Schedule(di.Interval(0), func() {}); !errors.Is(err, di.ErrInterval) {
+			t.Errorf("expected %v got %v", di.ErrInterval, err)
+		}
+	})
+
+	t.Run("stopping the context stops its jobs", func(t *testing.T) {
+		ctx := di.New()
+		ctx.Every(time.Hour, func() {})
+		var canceled atomic.Bool
+		started := make(chan struct{})
+		ctx.Schedule(&times{left: 1}, func(stdctx context.Context) {
+			close(started)
+			<-stdctx.Done()
+			canceled.Store(true)
+		})
+		<-started
+
+		if err := ctx.Stop(context.Background()); err != nil {
+			t.Errorf("expected %v got %v", nil, err)
+		}
+		if !canceled.Load() {
+			t.Errorf("expected the job's context to be canceled")
+		}
+		if err := ctx.Wait(); err != nil {
+			t.Errorf("expected %v got %v", nil, err)
+		}
+	})
+}
@@ -0,0 +1,106 @@
+package di
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+)
+
+// SecretSource looks up secrets, such as passwords and API keys, by name. Registering an implementation, such as a
+// client for Vault or a cloud secrets manager, lets providers and injected functions ask for secrets with Secret
+// parameters or the secret struct tag, so that credentials are fetched when they're needed rather than kept in
+// configuration structs.
+type SecretSource interface {
+	// Secret returns the value of the named secret. stdctx is the context given to InjectContext, or
+	// context.Background.
+	Secret(stdctx context.Context, name string) (string, error)
+}
+
+// SecretDir is a SecretSource which reads each secret from the file of the same name in a directory, as mounted by
+// Docker and Kubernetes:
+//
+//	ctx.Add(di.SecretDir("/run/secrets"))
+//
+// A trailing newline is removed. Names which would refer to files outside the directory are an error.
+type SecretDir string
+
+// Secret reads the named secret's file.
+func (d SecretDir) Secret(_ context.Context, name string) (string, error) {
+	if !filepath.IsLocal(name) {
+		return "", fmt.Errorf("invalid secret name %q", name)
+	}
+	data, err := os.ReadFile(filepath.Join(string(d), name))
+	if err != nil {
+		return "", err
+	}
+	return string(bytes.TrimSuffix(bytes.TrimSuffix(data, []byte("\n")), []byte("\r"))), nil
+}
+
+// Secret is a parameter type which requests the secret named by Q from the SecretSource registered in the Context,
+// fetched when the parameter is resolved. The secret is stored in Value.
+//
+//	type dbPassword struct{}
+//
+//	func (dbPassword) Qualifier() string { return "db/password" }
+//
+//	ctx.Provide(func(cfg DBConfig, password di.Secret[dbPassword]) (*sql.DB, error) {
+//		return sql.Open("postgres", cfg.DSN(password.Value))
+//	})
+//
+// If no SecretSource is registered, or it fails, the injection fails. Fields of Fill targets and In structs can instead
+// use the `di:"secret=name"` struct tag.
+type Secret[Q Qualifier] struct {
+	Value string
+}
+
+func (Secret[Q]) secretName() string {
+	var q Q
+	return q.Qualifier()
+}
+
+// secret is implemented by every instantiation of Secret.
+type secret interface {
+	secretName() string
+}
+
+var (
+	secretType       = reflect.TypeOf((*secret)(nil)).Elem()
+	secretSourceType = typeOf[SecretSource]()
+)
+
+// resolveSecretParam resolves a Secret parameter and wraps the value in it.
+func resolveSecretParam(ctx *Context, r *resolution, argType reflect.Type) (reflect.Value, error) {
+	name := reflect.Zero(argType).Interface().(secret).secretName()
+	val, err := resolveSecret(ctx, r, argType.Field(0).Type, name)
+	if err != nil {
+		return val, err
+	}
+
+	wrapped := reflect.New(argType).Elem()
+	wrapped.Field(0).Set(val)
+	return wrapped, nil
+}
+
+// resolveSecret fetches the named secret from the registered SecretSource,
+// as a value of type t, which must be a string or a byte slice. While
+// validating, only the source is checked.
+func resolveSecret(ctx *Context, r *resolution, t reflect.Type, name string) (reflect.Value, error) {
+	if t.Kind() != reflect.String && !(t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint8) {
+		return reflect.Value{}, fmt.Errorf("%w: secret %q can't be a %v", ErrInvalidTag, name, t)
+	}
+	source, err := resolveAside(ctx, r, secretSourceType)
+	if err != nil {
+		return reflect.Value{}, fmt.Errorf("secret %q: %w", name, err)
+	}
+	if r.validate {
+		return reflect.Zero(t), nil
+	}
+	s, err := source.Interface().(SecretSource).Secret(r.context(), name)
+	if err != nil {
+		return reflect.Value{}, fmt.Errorf("secret %q: %w", name, err)
+	}
+	return reflect.ValueOf(s).Convert(t), nil
+}
@@ -0,0 +1,109 @@
+package di_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mcvoid/di"
+)
+
+type dbPassword struct{}
+
+func (dbPassword) Qualifier() string { return "db/password" }
+
+// a SecretSource which holds its secrets in memory
+type secrets map[string]string
+
+func (s secrets) Secret(_ context.Context, name string) (string, error) {
+	secret, ok := s[name]
+	if !ok {
+		return "", errors.New("no such secret")
+	}
+	return secret, nil
+}
+
+type credentials struct {
+	User     string
+	Password []byte `di:"secret=db/password"`
+}
+
+func TestSecret(t *testing.T) {
+	t.Run("parameters get the secret", func(t *testing.T) {
+		ctx := di.New().Add(secrets{"db/password": "hunter2"})
+		wasCalled := false
+		err := ctx.Inject(func(password di.Secret[dbPassword]) {
+			wasCalled = true
+			if password.Value != "hunter2" {
+				t.Errorf("expected %v got %v", "hunter2", password.Value)
+			}
+		})
+		if err != nil {
+			t.Errorf("expected %v got %v", nil, err)
+		}
+		if !wasCalled {
+			t.Errorf("expected %v got %v", true, wasCalled)
+		}
+	})
+
+	t.Run("fields get the secret", func(t *testing.T) {
+		ctx := di.New().Add(secrets{"db/password": "hunter2"}, "admin")
+		creds := credentials{}
+		if err := ctx.Fill(&creds); err != nil {
+			t.Errorf("expected %v got %v", nil, err)
+		}
+		if creds.User != "admin" || string(creds.Password) != "hunter2" {
+			t.Errorf("expected %v got %v", "admin:hunter2", creds)
+		}
+	})
+
+	t.Run("errors from the source are returned", func(t *testing.T) {
+		ctx := di.New().Add(secrets{})
+		err := ctx.Inject(func(di.Secret[dbPassword]) {
+			t.Errorf("expected func to not be called")
+		})
+		if err == nil {
+			t.Errorf("expected an error got %v", err)
+		}
+	})
+
+	t.Run("a source must be registered", func(t *testing.T) {
+		ctx := di.New()
+		err := ctx.Inject(func(di.Secret[dbPassword]) {
+			t.Errorf("expected func to not be called")
+		})
+		if !errors.Is(err, di.ErrNotRegistered) {
+			t.Errorf("expected %v got %v", di.ErrNotRegistered, err)
+		}
+		if err := ctx.Validate(func(di.Secret[dbPassword]) {}); !errors.Is(err, di.ErrNotRegistered) {
+			t.Errorf("expected %v got %v", di.ErrNotRegistered, err)
+		}
+	})
+
+	t.Run("secrets must be strings or bytes", func(t *testing.T) {
+		ctx := di.New().Add(secrets{"n": "1"})
+		target := struct {
+			N int `di:"secret=n"`
+		}{}
+		if err := ctx.Fill(&target); !errors.Is(err, di.ErrInvalidTag) {
+			t.Errorf("expected %v got %v", di.ErrInvalidTag, err)
+		}
+	})
+
+	t.Run("secrets are read from a directory", func(t *testing.T) {
+		dir := t.TempDir()
+		os.MkdirAll(filepath.Join(dir, "db"), 0o700)
+		os.WriteFile(filepath.Join(dir, "db", "password"), []byte("hunter2\n"), 0o600)
+		source := di.SecretDir(dir)
+
+		secret, err := source.Secret(context.Background(), "db/password")
+		if err != nil || secret != "hunter2" {
+			t.Errorf("expected %v got %v, %v", "hunter2", secret, err)
+		}
+		if _, err := source.Secret(context.Background(), "../password"); err == nil {
+			t.Errorf("expected an error for a name outside the directory")
+		}
+	})
+}
@@ -0,0 +1,63 @@
+package di
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"reflect"
+)
+
+// WithSlog logs the wiring decisions of the Context to logger at debug level: each dependency registered, each
+// parameter or type resolved and the type of the value it resolved to, each ambiguity, and each parameter which falls
+// back to the zero value because nothing matched. This allows wiring diagnostics to be turned on in production by
+// changing the logger's level.
+func WithSlog(logger *slog.Logger) Option {
+	return func(ctx *Context) {
+		ctx.logger = logger
+		ctx.observers = append(ctx.observers, slogObserver{logger})
+	}
+}
+
+// logDebug logs a message at debug level, if the Context has a logger.
+func logDebug(ctx *Context, msg string, attrs ...slog.Attr) {
+	if ctx.logger == nil {
+		return
+	}
+	ctx.logger.LogAttrs(context.Background(), slog.LevelDebug, msg, attrs...)
+}
+
+// slogObserver logs resolutions.
+type slogObserver struct {
+	logger *slog.Logger
+}
+
+func (o slogObserver) BeforeResolve(t reflect.Type) {}
+
+func (o slogObserver) AfterResolve(t reflect.Type, val interface{}, err error) {
+	ctx := context.Background()
+	var amb *AmbiguousError
+	switch {
+	case errors.As(err, &amb):
+		o.logger.LogAttrs(ctx, slog.LevelDebug, "di: ambiguous",
+			slog.String("type", t.String()), slog.Any("candidates", typeNames(amb.Candidates)))
+	case err != nil:
+		o.logger.LogAttrs(ctx, slog.LevelDebug, "di: resolve failed",
+			slog.String("type", t.String()), slog.String("error", err.Error()))
+	default:
+		o.logger.LogAttrs(ctx, slog.LevelDebug, "di: resolved",
+			slog.String("type", t.String()), slog.String("value", dynamicType(reflect.ValueOf(val), val != nil)))
+	}
+}
+
+func (o slogObserver) BeforeCall(target interface{}) {}
+
+func (o slogObserver) AfterCall(target interface{}, err error) {}
+
+// typeNames returns the names of the types.
+func typeNames(types []reflect.Type) []string {
+	names := make([]string, len(types))
+	for i, t := range types {
+		names[i] = t.String()
+	}
+	return names
+}
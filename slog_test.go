@@ -0,0 +1,56 @@
+package di_test
+
+import (
+	"bytes"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/mcvoid/di"
+)
+
+// newTestLogger logs at debug level to b, without timestamps.
+func newTestLogger(b *bytes.Buffer, level slog.Level) *slog.Logger {
+	return slog.New(slog.NewTextHandler(b, &slog.HandlerOptions{
+		Level: level,
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			if a.Key == slog.TimeKey {
+				return slog.Attr{}
+			}
+			return a
+		},
+	}))
+}
+
+func TestWithSlog(t *testing.T) {
+	t.Run("decisions are logged", func(t *testing.T) {
+		b := &bytes.Buffer{}
+		ctx := di.New(di.WithSlog(newTestLogger(b, slog.LevelDebug))).Add(os.Stdout)
+		ctx.Inject(func(f *os.File, r *strings.Reader) {})
+		ctx.Add(&bytes.Buffer{})
+		ctx.Inject(func(w io.Writer) {})
+
+		expected := `level=DEBUG msg="di: registered" type=*os.File name="" provider=false
+level=DEBUG msg="di: resolved" type=*os.File value=*os.File
+level=DEBUG msg="di: zero value" type=*strings.Reader
+level=DEBUG msg="di: resolved" type=*strings.Reader value=*strings.Reader
+level=DEBUG msg="di: registered" type=*bytes.Buffer name="" provider=false
+level=DEBUG msg="di: ambiguous" type=io.Writer candidates="[*bytes.Buffer *os.File]"
+`
+		if b.String() != expected {
+			t.Errorf("expected %v got %v", expected, b.String())
+		}
+	})
+
+	t.Run("nothing above debug", func(t *testing.T) {
+		b := &bytes.Buffer{}
+		ctx := di.New(di.WithSlog(newTestLogger(b, slog.LevelInfo))).Add(os.Stdout)
+		ctx.Inject(func(f *os.File) {})
+
+		if b.Len() != 0 {
+			t.Errorf("expected %v got %v", "", b.String())
+		}
+	})
+}
@@ -0,0 +1,70 @@
+package di
+
+import "reflect"
+
+// mostSpecific returns the index of the type whose method set strictly
+// contains the method sets of all the others, or -1 if there isn't one.
+func mostSpecific(types []reflect.Type) int {
+	for i, t := range types {
+		best := true
+		for j, other := range types {
+			if i != j && !(hasMethods(t, other) && !hasMethods(other, t)) {
+				best = false
+				break
+			}
+		}
+		if best {
+			return i
+		}
+	}
+	return -1
+}
+
+// hasMethods reports whether t has every method other has, with the same
+// signature.
+func hasMethods(t, other reflect.Type) bool {
+	for i := 0; i < other.NumMethod(); i++ {
+		want := other.Method(i)
+		got, ok := t.MethodByName(want.Name)
+		if !ok || !sameSignature(params(t, got.Type), params(other, want.Type)) {
+			return false
+		}
+	}
+	return true
+}
+
+// params returns the parameter and result types of a method of t, without
+// the receiver.
+func params(t, method reflect.Type) []reflect.Type {
+	types := []reflect.Type{}
+	// methods of concrete types take the receiver as their first parameter
+	first := 1
+	if t.Kind() == reflect.Interface {
+		first = 0
+	}
+	for i := first; i < method.NumIn(); i++ {
+		types = append(types, method.In(i))
+	}
+	// a nil separates parameters from results
+	types = append(types, nil)
+	for i := 0; i < method.NumOut(); i++ {
+		types = append(types, method.Out(i))
+	}
+	if method.IsVariadic() {
+		types = append(types, nil)
+	}
+	return types
+}
+
+// sameSignature compares the types returned by params.
+func sameSignature(a, b []reflect.Type) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,99 @@
+package di
+
+import (
+	"reflect"
+	"sync"
+)
+
+// state is an immutable snapshot of a Context's registrations. Changes are
+// made to the Context's own maps while holding its lock, then published as a
+// new snapshot, so injections read the current snapshot without locking and
+// never wait for one another or for a change in progress.
+type state struct {
+	deps     map[key]*entry
+	prefs    map[reflect.Type]reflect.Type
+	defaults *Context
+
+	// caches, filled in as the snapshot is read
+	// the keys of the dependencies implementing each interface resolved so far
+	index sync.Map
+	// the plans made for injecting each function type
+	plans sync.Map
+}
+
+// empty is the state of a Context which has never been changed. Nothing is
+// cached in it, since it is shared.
+var empty = &state{}
+
+// load returns the current snapshot of the Context's registrations.
+func (ctx *Context) load() *state {
+	if s := ctx.current.Load(); s != nil {
+		return s
+	}
+	return empty
+}
+
+// own reports whether the snapshot has anything which would change how a
+// dependency is resolved, compared to resolving it in the parent.
+func (s *state) own() bool {
+	return len(s.deps) > 0 || len(s.prefs) > 0 || s.defaults != nil
+}
+
+// changed records that the Context's registrations have changed, so that
+// they are published when it is unlocked by commit.
+// The caller must hold ctx.lock.
+func (ctx *Context) changed() {
+	ctx.dirty = true
+}
+
+// commit publishes any changes made while the lock was held, then unlocks
+// it and makes the calls to user code put off by later. Every method which
+// changes the registrations unlocks with commit.
+func (ctx *Context) commit() {
+	if ctx.dirty {
+		ctx.publish()
+	}
+	pending := ctx.pending
+	ctx.pending = nil
+	ctx.lock.Unlock()
+
+	for _, fn := range pending {
+		fn()
+	}
+}
+
+// later puts off a call to user code, such as a hook, until commit releases
+// the lock, so that the code can use the Context without deadlocking.
+// The caller must hold ctx.lock.
+func (ctx *Context) later(fn func()) {
+	ctx.pending = append(ctx.pending, fn)
+}
+
+// publish swaps in a new snapshot of the Context's registrations. The
+// interface index is carried over, updated for the keys registered since the
+// last snapshot, unless the registrations were replaced wholesale.
+// The caller must hold ctx.lock.
+func (ctx *Context) publish() {
+	s := &state{
+		deps:     make(map[key]*entry, len(ctx.deps)),
+		defaults: ctx.defaults,
+	}
+	for k, e := range ctx.deps {
+		s.deps[k] = e
+	}
+	if len(ctx.prefs) > 0 {
+		s.prefs = make(map[reflect.Type]reflect.Type, len(ctx.prefs))
+		for iface, t := range ctx.prefs {
+			s.prefs[iface] = t
+		}
+	}
+	if old := ctx.current.Load(); old != nil && !ctx.reindex {
+		old.index.Range(func(iface, keys interface{}) bool {
+			s.index.Store(iface, reindex(s, iface.(reflect.Type), keys.([]key), ctx.added))
+			return true
+		})
+	}
+
+	ctx.added, ctx.reindex, ctx.dirty = nil, false, false
+	ctx.current.Store(s)
+}
@@ -0,0 +1,49 @@
+package di_test
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/mcvoid/di"
+)
+
+func TestSnapshot(t *testing.T) {
+	t.Run("changes are seen once made", func(t *testing.T) {
+		ctx := di.New()
+		if di.Has[io.Writer](ctx) {
+			t.Errorf("expected no writer")
+		}
+		ctx.Add(&strings.Builder{})
+		if !di.Has[io.Writer](ctx) {
+			t.Errorf("expected writer")
+		}
+	})
+
+	t.Run("concurrent changes and injections", func(t *testing.T) {
+		ctx := di.New().Add(&strings.Builder{})
+		var wg sync.WaitGroup
+		for i := 0; i < 8; i++ {
+			wg.Add(2)
+			go func() {
+				defer wg.Done()
+				for j := 0; j < 100; j++ {
+					ctx.Replace(&strings.Builder{})
+					ctx.Add(&bytes.Buffer{})
+					ctx.Remove(&bytes.Buffer{})
+				}
+			}()
+			go func() {
+				defer wg.Done()
+				for j := 0; j < 100; j++ {
+					if err := ctx.Inject(func(*strings.Builder, []io.Writer) {}); err != nil {
+						t.Errorf("expected %v got %v", nil, err)
+					}
+				}
+			}()
+		}
+		wg.Wait()
+	})
+}
@@ -0,0 +1,55 @@
+package di
+
+import (
+	"context"
+	"reflect"
+)
+
+// Tracer times the slow parts of wiring an application: each call to a provider, and each dependency and hook run by
+// Start. Trace starts a span with the given name as a child of ctx, returning the context for the span and a function
+// to end it with the error it ended in, if any. Tracers are set with WithTracer; package diotel has one for
+// OpenTelemetry.
+type Tracer interface {
+	Trace(ctx context.Context, name string) (context.Context, func(err error))
+}
+
+// WithTracer sets a Tracer to time provider calls and Start. Spans for providers called while another provider is
+// being called are children of the other provider's span, and spans for providers called by Start are children of the
+// context passed to Start.
+func WithTracer(t Tracer) Option {
+	return func(ctx *Context) {
+		ctx.tracer = t
+	}
+}
+
+// trace starts a span if the Context has a Tracer, returning the context for
+// the span and the function to end it.
+func trace(ctx *Context, stdctx context.Context, name string) (context.Context, func(error)) {
+	if ctx.tracer == nil {
+		return stdctx, func(error) {}
+	}
+	return ctx.tracer.Trace(stdctx, name)
+}
+
+// context returns the context the resolution is running in.
+func (r *resolution) context() context.Context {
+	if r.stdctx == nil {
+		return context.Background()
+	}
+	return r.stdctx
+}
+
+// traceProvider starts a span for a call to a provider, whose dependencies
+// are then resolved within the span.
+func traceProvider(ctx *Context, r *resolution, t reflect.Type) func(error) {
+	if ctx.tracer == nil {
+		return func(error) {}
+	}
+	parent := r.stdctx
+	stdctx, end := ctx.tracer.Trace(r.context(), "provide "+t.String())
+	r.stdctx = stdctx
+	return func(err error) {
+		r.stdctx = parent
+		end(err)
+	}
+}
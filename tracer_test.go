@@ -0,0 +1,56 @@
+package di_test
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/mcvoid/di"
+)
+
+// a tracer which records the spans it starts and ends
+type recordingTracer struct {
+	spans []string
+}
+
+type spanKey struct{}
+
+func (tr *recordingTracer) Trace(ctx context.Context, name string) (context.Context, func(error)) {
+	parent, _ := ctx.Value(spanKey{}).(string)
+	tr.spans = append(tr.spans, fmt.Sprintf("start %q in %q", name, parent))
+	return context.WithValue(ctx, spanKey{}, name), func(err error) {
+		tr.spans = append(tr.spans, fmt.Sprintf("end %q %v", name, err))
+	}
+}
+
+func startHook(ctx context.Context) error { return nil }
+
+func TestWithTracer(t *testing.T) {
+	tr := &recordingTracer{}
+	ctx := di.New(di.WithTracer(tr)).Add(os.Stdout)
+	ctx.Provide(func(f *os.File) *bytes.Buffer { return &bytes.Buffer{} })
+	ctx.Provide(func(b *bytes.Buffer) *component { return &component{rec: &recorder{}} })
+	ctx.OnStart(startHook)
+
+	err := ctx.Start(context.WithValue(context.Background(), spanKey{}, "root"))
+	if err != nil {
+		t.Errorf("expected %v got %v", nil, err)
+	}
+
+	expected := []string{
+		`start "provide *bytes.Buffer" in "root"`,
+		`end "provide *bytes.Buffer" <nil>`,
+		`start "provide *di_test.component" in "root"`,
+		`end "provide *di_test.component" <nil>`,
+		`start "start *di_test.component" in "root"`,
+		`end "start *di_test.component" <nil>`,
+		`start "start hook github.com/mcvoid/di_test.startHook" in "root"`,
+		`end "start hook github.com/mcvoid/di_test.startHook" <nil>`,
+	}
+	if !reflect.DeepEqual(tr.spans, expected) {
+		t.Errorf("expected %v got %v", expected, tr.spans)
+	}
+}
@@ -0,0 +1,107 @@
+package di
+
+import (
+	"log/slog"
+	"reflect"
+)
+
+// watcher is a function registered with Watch.
+type watcher struct {
+	fn func(old, new reflect.Value)
+}
+
+// Watch calls fn each time the registration of type T in the Context is replaced, whether by Add, Provide, Replace,
+// Reload, Rollback, or anything else which registers a T, with the value it had and the value it has now. Components
+// which hold on to the old value can then react, such as by reopening connections once the wiring is hot-swapped:
+//
+//	stop := di.Watch(ctx, func(old, new *sql.DB) {
+//		pool.Swap(new)
+//		old.Close()
+//	})
+//	defer stop()
+//
+// old is the zero value if it came from a provider which was never called. If the new registration is a provider, it
+// is called to get the value; if that fails, fn isn't called, and the error is logged. fn is called once the change is
+// made, by the goroutine which made it. Only unnamed registrations in the Context itself are watched, not those in
+// its parent or children.
+//
+// The returned function stops fn from being called.
+func Watch[T any](ctx *Context, fn func(old, new T)) (stop func()) {
+	w := &watcher{fn: func(old, new reflect.Value) {
+		var o, n T
+		reflect.ValueOf(&o).Elem().Set(old)
+		reflect.ValueOf(&n).Elem().Set(new)
+		fn(o, n)
+	}}
+	t := typeOf[T]()
+
+	ctx.lock.Lock()
+	defer ctx.lock.Unlock()
+
+	if ctx.watchers == nil {
+		ctx.watchers = map[reflect.Type][]*watcher{}
+	}
+	ctx.watchers[t] = append(ctx.watchers[t], w)
+	return func() {
+		ctx.lock.Lock()
+		defer ctx.lock.Unlock()
+
+		// copied, since the watchers may be being called
+		kept := []*watcher{}
+		for _, other := range ctx.watchers[t] {
+			if other != w {
+				kept = append(kept, other)
+			}
+		}
+		ctx.watchers[t] = kept
+	}
+}
+
+// replaced calls the watchers of a registration which has been replaced,
+// and injects the targets which depend on it again, once the lock is
+// released.
+// The caller must hold ctx.lock.
+func (ctx *Context) replaced(k key, old, new *entry) {
+	if old == nil || old == new {
+		return
+	}
+	ctx.rebind(k)
+	if k.name != "" || len(ctx.watchers[k.typ]) == 0 {
+		return
+	}
+	watchers := ctx.watchers[k.typ]
+	ctx.later(func() {
+		oldVal := reflect.Zero(k.typ)
+		if val, ok := old.built(); ok {
+			oldVal = as(k.typ, val)
+		}
+		newVal, err := new.value(ctx, &resolution{})
+		if err != nil {
+			logDebug(ctx, "di: watch failed", slog.String("type", k.typ.String()), slog.String("error", err.Error()))
+			return
+		}
+		for _, w := range watchers {
+			w.fn(oldVal, as(k.typ, newVal))
+		}
+	})
+}
+
+// built returns the entry's value without calling its provider, reporting
+// whether there is one.
+func (e *entry) built() (reflect.Value, bool) {
+	if e.prov == nil {
+		return e.val, true
+	}
+	val, _, ok := e.prov.cached()
+	if !ok || e.field == nil {
+		return val, ok
+	}
+	return val.FieldByIndex(e.field), true
+}
+
+// as returns val as a value of type t, which it must be assignable to.
+func as(t reflect.Type, val reflect.Value) reflect.Value {
+	out := reflect.New(t).Elem()
+	out.Set(val)
+	return out
+}
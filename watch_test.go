@@ -0,0 +1,85 @@
+package di_test
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/mcvoid/di"
+)
+
+func TestWatch(t *testing.T) {
+	t.Run("replacements are watched", func(t *testing.T) {
+		ctx := di.New().Add(os.Stdout)
+		calls := [][2]*os.File{}
+		di.Watch(ctx, func(old, new *os.File) { calls = append(calls, [2]*os.File{old, new}) })
+
+		ctx.Add(os.Stderr)
+		ctx.Replace(os.Stdin)
+		expected := [][2]*os.File{{os.Stdout, os.Stderr}, {os.Stderr, os.Stdin}}
+		if len(calls) != 2 || calls[0] != expected[0] || calls[1] != expected[1] {
+			t.Errorf("expected %v got %v", expected, calls)
+		}
+	})
+
+	t.Run("first registrations aren't replacements", func(t *testing.T) {
+		ctx := di.New()
+		di.Watch(ctx, func(old, new *os.File) { t.Errorf("expected watcher to not be called") })
+		ctx.Add(os.Stdout)
+	})
+
+	t.Run("providers are called for the new value", func(t *testing.T) {
+		ctx := di.New()
+		ctx.Provide(func() *bytes.Buffer { return bytes.NewBufferString("old") })
+		var got [2]string
+		di.Watch(ctx, func(old, new *bytes.Buffer) {
+			if old != nil {
+				got[0] = old.String()
+			}
+			got[1] = new.String()
+		})
+
+		ctx.Provide(func() *bytes.Buffer { return bytes.NewBufferString("new") })
+		if got != [2]string{"", "new"} {
+			t.Errorf("expected %v got %v", [2]string{"", "new"}, got)
+		}
+
+		ctx.Provide(func() *bytes.Buffer { return bytes.NewBufferString("newer") })
+		if got != [2]string{"new", "newer"} {
+			t.Errorf("expected %v got %v", [2]string{"new", "newer"}, got)
+		}
+	})
+
+	t.Run("reloaded providers are watched", func(t *testing.T) {
+		ctx := di.New().Add(logConfig{Level: "info"})
+		ctx.Provide(func(cfg logConfig) *logger { return &logger{cfg.Level} })
+		ctx.Inject(func(*logger) {})
+
+		levels := []string{}
+		di.Watch(ctx, func(old, new *logger) { levels = append(levels, old.level, new.level) })
+		ctx.Reload(logConfig{Level: "debug"})
+		if strings.Join(levels, ",") != "info,debug" {
+			t.Errorf("expected %v got %v", "info,debug", levels)
+		}
+	})
+
+	t.Run("interfaces are watched", func(t *testing.T) {
+		ctx := di.New()
+		di.AddAs[io.Writer](ctx, os.Stdout)
+		var got io.Writer
+		di.Watch(ctx, func(old, new io.Writer) { got = new })
+		di.AddAs[io.Writer](ctx, os.Stderr)
+		if got != os.Stderr {
+			t.Errorf("expected %v got %v", os.Stderr, got)
+		}
+	})
+
+	t.Run("watching can be stopped", func(t *testing.T) {
+		ctx := di.New().Add(os.Stdout)
+		stop := di.Watch(ctx, func(old, new *os.File) { t.Errorf("expected watcher to not be called") })
+		stop()
+		ctx.Add(os.Stderr)
+	})
+}